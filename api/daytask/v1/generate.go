@@ -0,0 +1,4 @@
+// Package daytaskv1 holds the Go code generated from task.proto.
+package daytaskv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative task.proto
@@ -0,0 +1,18 @@
+// Command daytask is the command-line client of the daytask server, see
+// "daytask help". The server itself is cmd/daytaskd.
+package main
+
+import (
+	"context"
+	"daytask/internal/cli"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := cli.Run(ctx, os.Args[1:], cli.IO{In: os.Stdin, Out: os.Stdout, Err: os.Stderr})
+	stop()
+
+	os.Exit(code)
+}
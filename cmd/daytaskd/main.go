@@ -0,0 +1,123 @@
+// Command daytaskd is the daytask server, see cmd/daytask for its
+// command-line client.
+package main
+
+import (
+	"context"
+	"daytask/internal/app"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogtrace"
+	"daytask/internal/lib/logger/sl"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gopkg.in/yaml.v3"
+)
+
+// @title Daytask API
+// @version 1.0
+// @description It's a simple task planner.
+
+// @host petstore.swagger.io
+// @BasePath /v2
+
+func main() {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(checkConfig(os.Args[3:]))
+	}
+
+	os.Exit(run())
+}
+
+func run() int {
+	configPath, err := config.ResolvePath(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg, err := config.LoadFile(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	level := new(slog.LevelVar)
+	level.Set(cfg.Level())
+	log := setupLogger(cfg.Env, level)
+
+	log.Info("Starting", slog.String("env", cfg.Env))
+	log.Debug("debug enable")
+
+	application, err := app.New(log, cfg)
+	if err != nil {
+		log.Error("failed to start", sl.Err(err))
+		return 1
+	}
+
+	application.AddWorker(config.NewWatcher(log, configPath, func(next *config.Config) {
+		level.Set(next.Level())
+		application.Reload(next)
+	}).Run)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := application.Run(ctx); err != nil {
+		log.Error("server stopped with error", sl.Err(err))
+		return 1
+	}
+
+	log.Info("server stopped")
+
+	return 0
+}
+
+// checkConfig implements "daytask config check [--config path]": it loads and
+// validates the config exactly like the server would and prints the effective
+// values with secrets redacted.
+func checkConfig(args []string) int {
+	configPath, err := config.ResolvePath(args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg, err := config.LoadFile(configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("# %s\n", configPath)
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg.Redacted()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func setupLogger(env string, level slog.Leveler) *slog.Logger {
+	var log *slog.Logger
+
+	switch env {
+	case config.EnvLocal:
+		log = slog.New(slogtrace.NewTraceHandler(
+			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+	case config.EnvDev:
+		log = slog.New(slogtrace.NewTraceHandler(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+	default: // config.EnvProd, Validate rejects anything else
+		log = slog.New(slogtrace.NewTraceHandler(
+			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level})))
+	}
+
+	return log
+}
@@ -15,9 +15,104 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/task": {
+        "/admin/audit": {
+            "get": {
+                "description": "Lists the recorded creates, updates and deletes oldest first, at most limit (100 by default) after after_id. With format \"ndjson\" every matching entry is streamed as newline delimited JSON.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Query audit log",
+                "parameters": [
+                    {
+                        "description": "filter",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/audit.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/lockouts": {
+            "get": {
+                "description": "Failed login counters per username and IP, including the locked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List failed logins",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/lockouts.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/tasks/count": {
+            "get": {
+                "description": "Number of tasks of every user",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Task counts",
+                "responses": {
+                    "200": {
+                        "description": "Tasks per owner",
+                        "schema": {
+                            "$ref": "#/definitions/taskCounts.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/unlock": {
             "post": {
-                "description": "Save task",
+                "description": "Clears the failed logins and the lockout of a username, an IP or both",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,37 +120,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "admin"
                 ],
-                "summary": "Save task",
+                "summary": "Unlock login",
                 "parameters": [
                     {
-                        "description": "user task",
-                        "name": "task",
+                        "description": "username and/or IP",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/save.Request"
+                            "$ref": "#/definitions/unlock.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Number of counters cleared",
+                        "schema": {
+                            "$ref": "#/definitions/unlock.Response"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
-                    "404": {
-                        "description": "Not Found"
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Accounts stored in the database, the ones from the config file are not listed",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/listUsers.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden"
                     },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
             },
-            "delete": {
-                "description": "Delete task",
+            "post": {
+                "description": "Adds an account that can log in with Basic auth",
                 "consumes": [
                     "application/json"
                 ],
@@ -63,29 +187,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "admin"
                 ],
-                "summary": "Delete task",
+                "summary": "Create user",
                 "parameters": [
                     {
-                        "description": "task ID",
-                        "name": "id",
+                        "description": "username, password and role",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "integer"
+                            "$ref": "#/definitions/createUser.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "ID of the user",
+                        "schema": {
+                            "$ref": "#/definitions/createUser.Response"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
-                    "404": {
-                        "description": "Not Found"
+                    "403": {
+                        "description": "Forbidden"
                     },
                     "500": {
                         "description": "Internal Server Error"
@@ -93,7 +220,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
-                "description": "Update task",
+                "description": "Changes the role of an account, disables or enables it or resets its password",
                 "consumes": [
                     "application/json"
                 ],
@@ -101,27 +228,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "admin"
                 ],
-                "summary": "Update task",
+                "summary": "Update user",
                 "parameters": [
                     {
-                        "description": "updated task",
-                        "name": "task",
+                        "description": "username and the fields to change",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/updateTask.Request"
+                            "$ref": "#/definitions/updateUser.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/updateUser.Response"
+                        }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
+                    "403": {
+                        "description": "Forbidden"
+                    },
                     "404": {
                         "description": "Not Found"
                     },
@@ -131,9 +264,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/task/all": {
-            "get": {
-                "description": "Gives tasks for the whole time",
+        "/graphql": {
+            "post": {
+                "description": "Runs a GraphQL query or mutation against the schema of internal/http-server/handlers/graphql/schema.graphql: the tasks of a day, a date range or all time, filtered by status and type, with their subtasks, comments and activity loaded in one query per field for the whole list, plus users and workspaces. The mutations saveTask, updateTask and deleteTask mirror POST /task/save, PATCH /task and DELETE /task and need the admin or member role, saveTask also saves subtasks under a parentId the caller may see. The response follows the GraphQL spec, errors are listed in errors.",
                 "consumes": [
                     "application/json"
                 ],
@@ -141,42 +274,117 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "graphql"
                 ],
-                "summary": "Get all tasks",
+                "summary": "GraphQL",
                 "parameters": [
                     {
-                        "description": "owner's login",
-                        "name": "owner",
+                        "description": "query, operationName and variables",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/graphql.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Quantity and Tasks array",
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is up and serving requests",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/getAllTasks.Response"
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether the database is reachable and migrated",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sync": {
+            "get": {
+                "description": "Returns, in server_rev order, the tasks the caller owns or is assigned to that changed after revision since, and tombstones for those deleted or no longer theirs. Start from since=0 and pass the returned rev as since next time, right away while more is set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sync"
+                ],
+                "summary": "Pull changes",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "last revision synced, 0 for everything",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page size, 500 by default, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/pull.Response"
                         }
                     },
                     "400": {
                         "description": "Bad Request"
                     },
-                    "404": {
-                        "description": "Not Found"
-                    },
                     "500": {
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        },
-        "/task/day": {
-            "get": {
-                "description": "Get the day's tasks",
+            },
+            "post": {
+                "description": "Applies a batch of changes made offline to the caller's personal tasks, in order, each on its own, and returns the outcome of each. An update or delete conflicts when the task is no longer at base_rev. Conflicts are resolved the same way every time: an update that sent base is merged field by field, fields changed on both sides keep the server value; without base it is rejected. A delete is applied only if the server changed none of the fields of base. Changes to a task deleted on the server are rejected.",
                 "consumes": [
                     "application/json"
                 ],
@@ -184,35 +392,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "sync"
                 ],
-                "summary": "Get tasks",
+                "summary": "Push changes",
                 "parameters": [
                     {
-                        "description": "owner's login",
-                        "name": "owner",
+                        "description": "changes in the order they were made",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/push.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/push.Response"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task": {
+            "post": {
+                "description": "Save task",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Save task",
+                "parameters": [
                     {
-                        "description": "date",
-                        "name": "date",
+                        "description": "user task",
+                        "name": "task",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/save.Request"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Quantity and Tasks array",
-                        "schema": {
-                            "$ref": "#/definitions/getTask.Response"
-                        }
+                        "description": "OK"
                     },
                     "400": {
                         "description": "Bad Request"
@@ -224,82 +460,2559 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "getAllTasks.Response": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                },
-                "quantity": {
-                    "type": "integer"
-                },
-                "status": {
-                    "type": "string"
-                },
-                "tasks": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/storage.Task"
-                    }
-                }
-            }
+            },
+            "delete": {
+                "description": "Moves a task to the trash, from where it can be restored until the retention period passes. Only the owner and assignees of a personal task may delete it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Delete task",
+                "parameters": [
+                    {
+                        "description": "task ID",
+                        "name": "id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "description": "With application/json the body replaces every field of the task, omitted status and type fall back to their defaults and an omitted owner keeps the task with its owner. With application/merge-patch+json (RFC 7396) or application/json-patch+json (RFC 6902) the body is a patch of the Document of the task given by the id query parameter, only the fields it changes are written. Only the owner and assignees of a personal task may update it, and only admins may change its owner.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Update task",
+                "parameters": [
+                    {
+                        "description": "updated task, or a patch of Document",
+                        "name": "task",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/daytask_internal_http-server_handlers_task_updateTask.Request"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "task ID, required by patches",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/daytask_internal_http-server_handlers_task_updateTask.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/activity": {
+            "get": {
+                "description": "Lists, newest first, changes made by a user or to tasks they own or are assigned to. Pass the smallest ID seen as before_id to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Activity feed",
+                "parameters": [
+                    {
+                        "description": "user, page size and cursor",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/activity.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/activity.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/all": {
+            "get": {
+                "description": "Gives tasks for the whole time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Get all tasks",
+                "parameters": [
+                    {
+                        "description": "owner's login",
+                        "name": "owner",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quantity and Tasks array",
+                        "schema": {
+                            "$ref": "#/definitions/getAllTasks.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/comment": {
+            "post": {
+                "description": "Adds a comment to a task, workspace tasks only take comments from members and personal tasks from their owner and assignees",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Add comment",
+                "parameters": [
+                    {
+                        "description": "task ID and comment text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/addComment.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "comment ID",
+                        "schema": {
+                            "$ref": "#/definitions/addComment.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a comment, only its author may",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Delete comment",
+                "parameters": [
+                    {
+                        "description": "comment ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deleteComment.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deleteComment.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Replaces the text of a comment, only its author may",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Edit comment",
+                "parameters": [
+                    {
+                        "description": "comment ID and new text",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/editComment.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/editComment.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/day": {
+            "get": {
+                "description": "Get the day's tasks. Comments and activity are only included for the tasks the caller owns, is assigned to or shares a workspace with.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Get tasks",
+                "parameters": [
+                    {
+                        "description": "owner's login",
+                        "name": "owner",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "date",
+                        "name": "date",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "description": "comments and/or activity",
+                        "name": "include",
+                        "in": "body",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quantity and Tasks array",
+                        "schema": {
+                            "$ref": "#/definitions/getTask.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/reorder": {
+            "post": {
+                "description": "Sets the order GET /task/day lists the caller's tasks of a day in. ids must list every task of the day exactly once, first to last; tasks added later come last until the day is ordered again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Reorder day",
+                "parameters": [
+                    {
+                        "description": "date and task IDs in their new order",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/reorder.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/reorder.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/restore": {
+            "post": {
+                "description": "Takes a task out of the caller's trash",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Restore task",
+                "parameters": [
+                    {
+                        "description": "task ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/restore.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/restore.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/revert": {
+            "post": {
+                "description": "Sets a task back to an earlier revision, itself saved as a new revision so the revert can be undone too. Personal tasks may only be reverted by their owner, workspace tasks by their owner and the workspace owners.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Revert task",
+                "parameters": [
+                    {
+                        "description": "task ID and revision",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/revert.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/revert.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/revisions": {
+            "get": {
+                "description": "Lists the kept versions of a task, newest first. Workspace tasks are only shown to members, personal tasks to their owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "List task revisions",
+                "parameters": [
+                    {
+                        "description": "task ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/revisions.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/revisions.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/revisions/diff": {
+            "get": {
+                "description": "Lists the fields that changed between two revisions of a task. Workspace tasks are only shown to members, personal tasks to their owner.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Diff task revisions",
+                "parameters": [
+                    {
+                        "description": "task ID and revisions",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/diff.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/diff.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/task/trash": {
+            "get": {
+                "description": "Deleted tasks the caller may restore: its own and those of the workspaces it owns, most recently deleted first. They are deleted for good once the retention period passes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "List trash",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/trash.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/tasks/stream": {
+            "get": {
+                "description": "Server-Sent Events stream of the changes to the tasks the caller owns or is assigned to. Every event is named after its type (task.created, task.updated, task.completed or task.deleted) and carries its ID, which a reconnecting client sends back as Last-Event-ID, or the last_event_id query parameter, to get the events it missed. A reset event means those are no longer available and the client has to reload.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "Task stream",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID of the last event received, when the header cannot be set",
+                        "name": "last_event_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/events.Event"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    }
+                }
+            }
+        },
+        "/tasks/ws": {
+            "get": {
+                "description": "WebSocket on which a client subscribes to a date range with {\"type\":\"subscribe\",\"from\":\"2024-01-01\",\"to\":\"2024-01-07\"}, gets a snapshot of the tasks it owns or is assigned to in it and then an event for every change to them. It may send {\"type\":\"save\"} messages whose task is validated like the body of POST /task/save and belongs to the caller unless an admin names another owner, and {\"type\":\"update\"} messages whose task holds the id of the task and the fields to change, applied as a merge patch like PATCH /task/ with application/merge-patch+json and with the same checks. Replies echo the id of the message they answer. A reset message means changes were missed and the client has to subscribe again.",
+                "tags": [
+                    "task"
+                ],
+                "summary": "Task sync socket",
+                "parameters": [
+                    {
+                        "description": "messages sent on the socket",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/ws.Message"
+                        }
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/ws.Reply"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Version, commit and build time of the running binary",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Build version",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/version.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "get": {
+                "description": "Lists the caller's webhooks, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "List webhooks",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/listWebhooks.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Registers a URL to receive the events of the caller's tasks. Every delivery is signed with the returned secret, which is not shown again. The URL must resolve to public addresses, unless webhooks.allow_private is set, and redirects are not followed.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Create webhook",
+                "parameters": [
+                    {
+                        "description": "URL and events",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/createWebhook.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/createWebhook.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes one of the caller's webhooks along with its pending deliveries and delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Delete webhook",
+                "parameters": [
+                    {
+                        "description": "webhook ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deleteWebhook.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deleteWebhook.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/webhook/deliveries": {
+            "get": {
+                "description": "Lists, newest first, the deliveries of one of the caller's webhooks with the outcome of their last attempt. Pass the smallest ID seen as before_id to get the next page.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Webhook deliveries",
+                "parameters": [
+                    {
+                        "description": "webhook ID, status, page size and cursor",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deliveries.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deliveries.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/workspace": {
+            "get": {
+                "description": "Workspaces the current user is a member of, with its role in each",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "List workspaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/list.Response"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a workspace owned by the current user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Create workspace",
+                "parameters": [
+                    {
+                        "description": "workspace name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/create.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ID of the workspace",
+                        "schema": {
+                            "$ref": "#/definitions/create.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/workspace/day": {
+            "get": {
+                "description": "The day's tasks of a workspace, either the ones assigned to the current user or the whole team's",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Get workspace tasks",
+                "parameters": [
+                    {
+                        "description": "workspace, date and view",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/day.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quantity and Tasks array",
+                        "schema": {
+                            "$ref": "#/definitions/day.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/workspace/members": {
+            "get": {
+                "description": "Members of a workspace the current user belongs to",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "List workspace members",
+                "parameters": [
+                    {
+                        "description": "workspace",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/members.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/members.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a member to a workspace or changes its role, only workspace owners may",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Set workspace member",
+                "parameters": [
+                    {
+                        "description": "workspace, username and role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/setMember.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/setMember.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a member from a workspace and its task assignments, only workspace owners may",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Remove workspace member",
+                "parameters": [
+                    {
+                        "description": "workspace and username",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/removeMember.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/removeMember.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/workspace/task": {
+            "post": {
+                "description": "Adds a task owned by the current user to a workspace, assigned to some of its members",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Save workspace task",
+                "parameters": [
+                    {
+                        "description": "task",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/saveTask.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ID of the task",
+                        "schema": {
+                            "$ref": "#/definitions/saveTask.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Moves a workspace task to the trash, only its owner and the workspace owners may",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Delete workspace task",
+                "parameters": [
+                    {
+                        "description": "task ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/deleteTask.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/deleteTask.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "patch": {
+                "description": "Changes a workspace task. Its owner and the workspace owners may change everything, assignees only the status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Update workspace task",
+                "parameters": [
+                    {
+                        "description": "task ID and the fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/daytask_internal_http-server_handlers_workspace_updateTask.Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/daytask_internal_http-server_handlers_workspace_updateTask.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "403": {
+                        "description": "Forbidden"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "activity.Request": {
+            "type": "object",
+            "properties": {
+                "before_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 500,
+                    "minimum": 1
+                },
+                "user": {
+                    "description": "User defaults to the caller, only admins may read someone else's feed.",
+                    "type": "string"
+                }
+            }
+        },
+        "activity.Response": {
+            "type": "object",
+            "properties": {
+                "activity": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Activity"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "addComment.Request": {
+            "type": "object",
+            "required": [
+                "task_id",
+                "text"
+            ],
+            "properties": {
+                "task_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 4000
+                }
+            }
+        },
+        "addComment.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Request": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "entity": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "Format \"ndjson\" exports every matching entry, one JSON object per\nline, ignoring Limit.",
+                    "type": "string",
+                    "enum": [
+                        "json",
+                        "ndjson"
+                    ]
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 1000,
+                    "minimum": 1
+                },
+                "since": {
+                    "type": "string"
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "audit.Response": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.AuditEntry"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "create.Request": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "create.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "createUser.Request": {
+            "type": "object",
+            "required": [
+                "password",
+                "role",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member",
+                        "read-only"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "createUser.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "createWebhook.Request": {
+            "type": "object",
+            "required": [
+                "events",
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "createWebhook.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "description": "Secret signs the deliveries, it is only ever returned here.",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "day.Request": {
+            "type": "object",
+            "required": [
+                "date",
+                "workspace_id"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "view": {
+                    "description": "View is \"my\" for the tasks assigned to the current user or \"team\",\nthe default, for every task of the workspace.",
+                    "type": "string",
+                    "enum": [
+                        "my",
+                        "team"
+                    ]
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "day.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Task"
+                    }
+                }
+            }
+        },
+        "daytask_internal_http-server_handlers_task_updateTask.Request": {
+            "type": "object",
+            "required": [
+                "date"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "daytask_internal_http-server_handlers_task_updateTask.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "task": {
+                    "description": "Task is the patched task, it is only returned for patch requests.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/storage.Task"
+                        }
+                    ]
+                }
+            }
+        },
+        "daytask_internal_http-server_handlers_workspace_updateTask.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "daytask_internal_http-server_handlers_workspace_updateTask.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "deleteComment.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "deleteComment.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "deleteTask.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "deleteTask.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "deleteWebhook.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "deleteWebhook.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "deliveries.Request": {
+            "type": "object",
+            "required": [
+                "webhook_id"
+            ],
+            "properties": {
+                "before_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "limit": {
+                    "type": "integer",
+                    "maximum": 500,
+                    "minimum": 1
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "delivered",
+                        "failed"
+                    ]
+                },
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "deliveries.Response": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Delivery"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "diff.Request": {
+            "type": "object",
+            "required": [
+                "from",
+                "id"
+            ],
+            "properties": {
+                "from": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "description": "To defaults to the latest revision.",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "diff.Response": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Change"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "editComment.Request": {
+            "type": "object",
+            "required": [
+                "id",
+                "text"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 4000
+                }
+            }
+        },
+        "editComment.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "events.Event": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Change"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "task": {
+                    "$ref": "#/definitions/storage.Task"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "getAllTasks.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Task"
+                    }
+                }
+            }
+        },
+        "getTask.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Task"
+                    }
+                }
+            }
+        },
+        "graphql.Request": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "list.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "workspaces": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Workspace"
+                    }
+                }
+            }
+        },
+        "listUsers.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.User"
+                    }
+                }
+            }
+        },
+        "listWebhooks.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "webhooks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Webhook"
+                    }
+                }
+            }
+        },
+        "lockouts.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "lockouts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.LoginFailures"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "members.Request": {
+            "type": "object",
+            "required": [
+                "workspace_id"
+            ],
+            "properties": {
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "members.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "members": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.WorkspaceMember"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "pull.Response": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.SyncChange"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "more": {
+                    "type": "boolean"
+                },
+                "rev": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "push.Change": {
+            "type": "object",
+            "required": [
+                "op"
+            ],
+            "properties": {
+                "base": {
+                    "description": "Base is the task as of BaseRev, with it conflicting changes are\nmerged rather than rejected.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/push.Fields"
+                        }
+                    ]
+                },
+                "base_rev": {
+                    "type": "integer"
+                },
+                "client_id": {
+                    "description": "ClientID names a task created offline, creates retried with the same\nClientID create it once.",
+                    "type": "string",
+                    "maxLength": 128
+                },
+                "id": {
+                    "description": "ID and BaseRev are the task and the server_rev the client changed it\nfrom.",
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "task": {
+                    "$ref": "#/definitions/push.Fields"
+                }
+            }
+        },
+        "push.Fields": {
+            "type": "object",
+            "required": [
+                "date"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "push.Request": {
+            "type": "object",
+            "required": [
+                "changes"
+            ],
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "maxItems": 500,
+                    "items": {
+                        "$ref": "#/definitions/push.Change"
+                    }
+                }
+            }
+        },
+        "push.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/push.Result"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "push.Result": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is applied, merged, rejected or failed.",
+                    "type": "string"
+                },
+                "task": {
+                    "description": "Task is the task as now stored, with Deleted set if it is deleted.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/storage.Task"
+                        }
+                    ]
+                }
+            }
+        },
+        "removeMember.Request": {
+            "type": "object",
+            "required": [
+                "username",
+                "workspace_id"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "removeMember.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "reorder.Request": {
+            "type": "object",
+            "required": [
+                "date",
+                "ids"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "ids": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "reorder.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "response.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "restore.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "restore.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "revert.Request": {
+            "type": "object",
+            "required": [
+                "id",
+                "rev"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "rev": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "revert.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "revisions.Request": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "revisions.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "revisions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Revision"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "save.Request": {
+            "type": "object",
+            "required": [
+                "date"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "saveTask.Request": {
+            "type": "object",
+            "required": [
+                "date",
+                "workspace_id"
+            ],
+            "properties": {
+                "assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "date": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "saveTask.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "setMember.Request": {
+            "type": "object",
+            "required": [
+                "role",
+                "username",
+                "workspace_id"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "member"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "setMember.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Activity": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "at": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "entity": {
+                    "type": "string"
+                },
+                "entity_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "remote_addr": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Change": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
         },
-        "getTask.Response": {
+        "storage.Comment": {
             "type": "object",
             "properties": {
-                "error": {
+                "author": {
                     "type": "string"
                 },
-                "quantity": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "status": {
+                "task_id": {
+                    "type": "integer"
+                },
+                "text": {
                     "type": "string"
                 },
-                "tasks": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/storage.Task"
-                    }
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "save.Request": {
+        "storage.Delivery": {
             "type": "object",
-            "required": [
-                "date"
-            ],
             "properties": {
-                "date": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "description": {
+                "delivered_at": {
                     "type": "string"
                 },
-                "owner": {
+                "event": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_error": {
+                    "description": "LastError and ResponseCode describe the last attempt.",
+                    "type": "string"
+                },
+                "next_attempt": {
                     "type": "string"
                 },
+                "payload": {
+                    "type": "object"
+                },
+                "response_code": {
+                    "type": "integer"
+                },
                 "status": {
                     "type": "string"
                 },
-                "title": {
+                "webhook_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.LoginFailures": {
+            "type": "object",
+            "properties": {
+                "failures": {
+                    "type": "integer"
+                },
+                "kind": {
                     "type": "string"
                 },
-                "type": {
+                "last_failure": {
+                    "type": "string"
+                },
+                "locked_until": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Revision": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "at": {
                     "type": "string"
+                },
+                "rev": {
+                    "type": "integer"
+                },
+                "task": {
+                    "$ref": "#/definitions/storage.Task"
+                }
+            }
+        },
+        "storage.SyncChange": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "server_rev": {
+                    "type": "integer"
+                },
+                "task": {
+                    "$ref": "#/definitions/storage.Task"
                 }
             }
         },
         "storage.Task": {
             "type": "object",
             "properties": {
+                "activity": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Activity"
+                    }
+                },
+                "assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "comments": {
+                    "description": "Comments and Activity are only filled in when asked for.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Comment"
+                    }
+                },
                 "date": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "description": "DeletedAt is set for tasks in the trash.",
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -309,6 +3022,14 @@ const docTemplate = `{
                 "owner": {
                     "type": "string"
                 },
+                "parent_id": {
+                    "description": "ParentID is set for subtasks, see SaveSubtask.",
+                    "type": "integer"
+                },
+                "server_rev": {
+                    "description": "ServerRev increases with every change to any task, see SyncDelta.",
+                    "type": "integer"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -317,33 +3038,260 @@ const docTemplate = `{
                 },
                 "type": {
                     "type": "string"
+                },
+                "workspace_id": {
+                    "description": "WorkspaceID is set for tasks shared in a workspace, which may be\nassigned to any of its members.",
+                    "type": "integer"
                 }
             }
         },
-        "updateTask.Request": {
+        "storage.User": {
             "type": "object",
-            "required": [
-                "date"
-            ],
             "properties": {
-                "date": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "role": {
                     "type": "string"
                 },
-                "description": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "owner": {
                     "type": "string"
                 },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.Workspace": {
+            "type": "object",
+            "properties": {
                 "id": {
                     "type": "integer"
                 },
+                "name": {
+                    "type": "string"
+                },
                 "owner": {
                     "type": "string"
                 },
+                "role": {
+                    "description": "Role is the role of the user the workspace was listed for.",
+                    "type": "string"
+                }
+            }
+        },
+        "storage.WorkspaceMember": {
+            "type": "object",
+            "properties": {
+                "role": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "taskCounts.Response": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "trash.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
-                "title": {
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Task"
+                    }
+                }
+            }
+        },
+        "unlock.Request": {
+            "type": "object",
+            "properties": {
+                "ip": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "unlock.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "unlocked": {
+                    "type": "integer"
+                }
+            }
+        },
+        "updateUser.Request": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "member",
+                        "read-only"
+                    ]
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "updateUser.Response": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "version.Response": {
+            "type": "object",
+            "properties": {
+                "build_time": {
+                    "type": "string"
+                },
+                "commit": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "go_version": {
+                    "type": "string"
+                },
+                "modified": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "ws.Message": {
+            "type": "object",
+            "required": [
+                "type"
+            ],
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is chosen by the client and echoed in the reply.",
+                    "type": "string"
+                },
+                "task": {
+                    "description": "Task is a save.Request, or for updates the id of the task and a merge\npatch of its updateTask.Document.",
+                    "type": "object"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "subscribe",
+                        "unsubscribe",
+                        "save",
+                        "update"
+                    ]
+                }
+            }
+        },
+        "ws.Reply": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "$ref": "#/definitions/events.Event"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "status": {
                     "type": "string"
                 },
+                "task_id": {
+                    "description": "TaskID is the ID of a saved task.",
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Task"
+                    }
+                },
                 "type": {
                     "type": "string"
                 }
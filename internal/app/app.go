@@ -0,0 +1,548 @@
+package app
+
+import (
+	"context"
+	daytaskv1 "daytask/api/daytask/v1"
+	"daytask/internal/config"
+	"daytask/internal/grpc-server/interceptor"
+	"daytask/internal/grpc-server/taskservice"
+	"daytask/internal/http-server/handlers/admin/audit"
+	"daytask/internal/http-server/handlers/admin/createUser"
+	"daytask/internal/http-server/handlers/admin/listUsers"
+	"daytask/internal/http-server/handlers/admin/lockouts"
+	"daytask/internal/http-server/handlers/admin/taskCounts"
+	"daytask/internal/http-server/handlers/admin/unlock"
+	"daytask/internal/http-server/handlers/admin/updateUser"
+	"daytask/internal/http-server/handlers/graphql"
+	"daytask/internal/http-server/handlers/health/healthz"
+	"daytask/internal/http-server/handlers/health/readyz"
+	"daytask/internal/http-server/handlers/health/version"
+	"daytask/internal/http-server/handlers/sync/pull"
+	"daytask/internal/http-server/handlers/sync/push"
+	"daytask/internal/http-server/handlers/task/activity"
+	"daytask/internal/http-server/handlers/task/addComment"
+	"daytask/internal/http-server/handlers/task/delete"
+	"daytask/internal/http-server/handlers/task/deleteComment"
+	"daytask/internal/http-server/handlers/task/diff"
+	"daytask/internal/http-server/handlers/task/editComment"
+	"daytask/internal/http-server/handlers/task/getAllTasks"
+	"daytask/internal/http-server/handlers/task/getTask"
+	"daytask/internal/http-server/handlers/task/reorder"
+	"daytask/internal/http-server/handlers/task/restore"
+	"daytask/internal/http-server/handlers/task/revert"
+	"daytask/internal/http-server/handlers/task/revisions"
+	"daytask/internal/http-server/handlers/task/save"
+	"daytask/internal/http-server/handlers/task/stream"
+	"daytask/internal/http-server/handlers/task/trash"
+	"daytask/internal/http-server/handlers/task/updateTask"
+	"daytask/internal/http-server/handlers/task/ws"
+	"daytask/internal/http-server/handlers/webhook/createWebhook"
+	"daytask/internal/http-server/handlers/webhook/deleteWebhook"
+	"daytask/internal/http-server/handlers/webhook/deliveries"
+	"daytask/internal/http-server/handlers/webhook/listWebhooks"
+	"daytask/internal/http-server/handlers/workspace/create"
+	"daytask/internal/http-server/handlers/workspace/day"
+	"daytask/internal/http-server/handlers/workspace/deleteTask"
+	"daytask/internal/http-server/handlers/workspace/list"
+	"daytask/internal/http-server/handlers/workspace/members"
+	"daytask/internal/http-server/handlers/workspace/removeMember"
+	"daytask/internal/http-server/handlers/workspace/saveTask"
+	"daytask/internal/http-server/handlers/workspace/setMember"
+	wsUpdateTask "daytask/internal/http-server/handlers/workspace/updateTask"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/http-server/middleware/cors"
+	mwLogger "daytask/internal/http-server/middleware/logger"
+	mwMetrics "daytask/internal/http-server/middleware/metrics"
+	"daytask/internal/http-server/middleware/ratelimit"
+	"daytask/internal/http-server/middleware/timeout"
+	mwTracing "daytask/internal/http-server/middleware/tracing"
+	"daytask/internal/lib/events"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/login"
+	"daytask/internal/lib/metrics"
+	"daytask/internal/lib/tlsconfig"
+	"daytask/internal/lib/tracing"
+	libTrash "daytask/internal/lib/trash"
+	"daytask/internal/lib/webhook"
+	"daytask/internal/storage"
+	"daytask/internal/storage/sqlite"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	_ "daytask/docs"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Worker is a background job run for the lifetime of the App. It must
+// return once ctx is cancelled.
+type Worker func(ctx context.Context)
+
+type App struct {
+	log             *slog.Logger
+	storage         *sqlite.Storage
+	srv             *http.Server
+	ln              net.Listener
+	redirect        *http.Server
+	redirectLn      net.Listener
+	grpcSrv         *grpc.Server
+	grpcLn          net.Listener
+	shutdownTimeout time.Duration
+	shutdownTracing func(context.Context) error
+	workers         []Worker
+
+	// Settings applied live by Reload.
+	mu       sync.Mutex
+	cfg      *config.Config
+	creds    *auth.Credentials
+	guard    *login.Guard
+	timeouts *timeout.Timeouts
+	origins  *cors.Origins
+	limiter  *ratelimit.Limiter
+}
+
+// New opens the storage, builds the router and binds the listen address, so
+// every startup failure is reported here rather than from Run.
+func New(log *slog.Logger, cfg *config.Config) (*App, error) {
+	const op = "app.New"
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	store, err := sqlite.New(cfg.StoragePath, cfg.SQLite)
+	if err != nil {
+		shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a := &App{
+		log:             log,
+		storage:         store,
+		shutdownTimeout: cfg.HTTPServer.ShutdownTimeout,
+		shutdownTracing: shutdownTracing,
+		cfg:             cfg,
+		creds:           auth.NewCredentials(cfg.HTTPServer.Credentials()),
+		timeouts:        timeout.NewTimeouts(cfg.HTTPServer.Timeout, cfg.HTTPServer.Timeout),
+		origins:         cors.NewOrigins(cfg.CORS.AllowedOrigins),
+		limiter:         ratelimit.NewLimiter(cfg.RateLimit),
+	}
+	a.guard = login.NewGuard(log, store, a.creds, cfg.Lockout, cfg.HTTPServer.Roles)
+	a.AddWorker(a.limiter.Run)
+	a.AddWorker(a.guard.Run)
+	a.AddWorker(libTrash.NewPurger(log, store, cfg.Trash).Run)
+	a.AddWorker(webhook.NewDispatcher(log, store, cfg.Webhooks).Run)
+
+	bus := events.NewBus(cfg.Stream.BufferSize, cfg.Stream.Retention)
+	store.PublishTo(bus)
+
+	reg := metrics.NewRegistry()
+	store.Instrument(metrics.NewStorage(reg))
+	reg.MustRegister(metrics.NewStorageCollector(store))
+
+	router := chi.NewRouter()
+
+	router.Use(middleware.RequestID)
+	router.Use(mwMetrics.New(metrics.NewHTTP(reg)))
+	router.Use(mwTracing.New())
+	router.Use(timeout.New(a.timeouts))
+	router.Use(cors.New(a.origins))
+
+	// Probes and the metrics endpoint are polled every few seconds, so they
+	// stay out of the request log and do not require credentials.
+	router.Group(func(r chi.Router) {
+		r.Use(middleware.Recoverer)
+		r.Get("/healthz", healthz.New())
+		r.Get("/readyz", readyz.New(log, store))
+		r.Get("/version", version.New())
+		r.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(mwLogger.New(log))
+		r.Use(middleware.Recoverer)
+
+		r.Route("/task", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Get("/day", getTask.New(log, store))
+			r.Get("/all", getAllTasks.New(log, store))
+			r.Get("/activity", activity.New(log, store))
+			r.Get("/trash", trash.New(log, store))
+			r.Get("/revisions", revisions.New(log, store))
+			r.Get("/revisions/diff", diff.New(log, store))
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole(storage.RoleAdmin, storage.RoleMember))
+				r.Post("/save", save.New(log, store))
+				r.Delete("/", delete.New(log, store))
+				r.Patch("/", updateTask.New(log, store))
+				r.Post("/comment", addComment.New(log, store))
+				r.Patch("/comment", editComment.New(log, store))
+				r.Delete("/comment", deleteComment.New(log, store))
+				r.Post("/restore", restore.New(log, store))
+				r.Post("/revert", revert.New(log, store))
+				r.Post("/reorder", reorder.New(log, store))
+			})
+		})
+
+		r.Route("/tasks", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Get("/stream", stream.New(log, bus, cfg.Stream.Heartbeat))
+			r.Get("/ws", ws.New(log, store, bus, a.origins))
+		})
+
+		r.Route("/sync", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Get("/", pull.New(log, store))
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole(storage.RoleAdmin, storage.RoleMember))
+				r.Post("/", push.New(log, store))
+			})
+		})
+
+		// Queries are POSTed as well, so mutations check the role
+		// themselves and every request counts against the write budget.
+		r.Route("/graphql", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Post("/", graphql.New(log, store))
+		})
+
+		r.Route("/workspace", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Get("/", list.New(log, store))
+			r.Get("/members", members.New(log, store))
+			r.Get("/day", day.New(log, store))
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole(storage.RoleAdmin, storage.RoleMember))
+				r.Post("/", create.New(log, store))
+				r.Post("/members", setMember.New(log, store))
+				r.Delete("/members", removeMember.New(log, store))
+				r.Post("/task", saveTask.New(log, store))
+				r.Patch("/task", wsUpdateTask.New(log, store))
+				r.Delete("/task", deleteTask.New(log, store))
+			})
+		})
+
+		r.Route("/webhook", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(ratelimit.New(log, a.limiter))
+			r.Get("/", listWebhooks.New(log, store))
+			r.Get("/deliveries", deliveries.New(log, store))
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireRole(storage.RoleAdmin, storage.RoleMember))
+				r.Post("/", createWebhook.New(log, store, cfg.Webhooks.AllowPrivate))
+				r.Delete("/", deleteWebhook.New(log, store))
+			})
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(auth.New(log, "daytask", a.guard))
+			r.Use(auth.RequireRole(storage.RoleAdmin))
+			r.Get("/users", listUsers.New(log, store))
+			r.Post("/users", createUser.New(log, store))
+			r.Patch("/users", updateUser.New(log, store))
+			r.Get("/tasks/count", taskCounts.New(log, store))
+			r.Get("/lockouts", lockouts.New(log, store))
+			r.Post("/unlock", unlock.New(log, store))
+			r.Get("/audit", audit.New(log, store))
+		})
+
+		r.Get("/swagger/*", httpSwagger.Handler())
+	})
+
+	ln, err := net.Listen("tcp", cfg.HTTPServer.Address)
+	if err != nil {
+		store.Close()
+		shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.ln = ln
+	a.srv = &http.Server{
+		Handler: router,
+		// Read and write deadlines are set per request by the timeout
+		// middleware so they can be reloaded; the header timeout only guards
+		// against clients that never finish sending headers.
+		ReadHeaderTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:       cfg.HTTPServer.IdleTimeout,
+	}
+	// Streams never finish on their own, closing the bus ends them so
+	// Shutdown can drain the connections.
+	a.srv.RegisterOnShutdown(bus.Close)
+
+	if cfg.HTTPServer.TLS.Enabled {
+		if err := a.setupTLS(cfg.HTTPServer); err != nil {
+			a.closeListeners()
+			store.Close()
+			shutdownTracing(context.Background())
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if cfg.GRPCServer.Enabled {
+		if err := a.setupGRPC(cfg.GRPCServer, store, bus); err != nil {
+			a.closeListeners()
+			store.Close()
+			shutdownTracing(context.Background())
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return a, nil
+}
+
+// setupGRPC binds the gRPC listener and builds its server, authenticating
+// with the same accounts and lockout as the HTTP server. It must run after
+// setupTLS to serve with the same certificate.
+func (a *App) setupGRPC(cfg config.GRPCServer, store *sqlite.Storage, bus *events.Bus) error {
+	recoverUnary, recoverStream := interceptor.Recoverer(a.log)
+	authUnary, authStream := interceptor.Auth(a.log, a.guard)
+	logUnary, logStream := interceptor.Logger(a.log)
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(recoverUnary, authUnary, logUnary),
+		grpc.ChainStreamInterceptor(recoverStream, authStream, logStream),
+	}
+	if a.srv.TLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(a.srv.TLSConfig.Clone())))
+	}
+
+	ln, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return err
+	}
+
+	a.grpcLn = ln
+	a.grpcSrv = grpc.NewServer(opts...)
+	daytaskv1.RegisterTaskServiceServer(a.grpcSrv, taskservice.New(a.log, store, bus))
+
+	return nil
+}
+
+// setupTLS makes the server speak HTTPS (and HTTP/2) with a certificate that
+// is reloaded on change, and binds the optional HTTP to HTTPS redirect
+// listener.
+func (a *App) setupTLS(cfg config.HTTPServer) error {
+	certs, err := tlsconfig.NewCertReloader(a.log, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	a.srv.TLSConfig, err = tlsconfig.New(cfg.TLS, certs)
+	if err != nil {
+		return err
+	}
+
+	a.AddWorker(certs.Run)
+
+	if cfg.TLS.RedirectAddress == "" {
+		return nil
+	}
+
+	a.redirectLn, err = net.Listen("tcp", cfg.TLS.RedirectAddress)
+	if err != nil {
+		return err
+	}
+
+	_, port, _ := net.SplitHostPort(a.ln.Addr().String())
+	a.redirect = &http.Server{
+		Handler:           redirectToHTTPS(port),
+		ReadHeaderTimeout: cfg.Timeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+
+	return nil
+}
+
+// redirectToHTTPS sends every request to the same host and path on the
+// HTTPS port.
+func redirectToHTTPS(port string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	}
+}
+
+func (a *App) closeListeners() {
+	a.ln.Close()
+	if a.redirectLn != nil {
+		a.redirectLn.Close()
+	}
+	if a.grpcLn != nil {
+		a.grpcLn.Close()
+	}
+}
+
+// Addr returns the address the server is listening on.
+func (a *App) Addr() net.Addr {
+	return a.ln.Addr()
+}
+
+// RedirectAddr returns the address of the HTTP to HTTPS redirect listener,
+// or nil if it is disabled.
+func (a *App) RedirectAddr() net.Addr {
+	if a.redirectLn == nil {
+		return nil
+	}
+
+	return a.redirectLn.Addr()
+}
+
+// GRPCAddr returns the address of the gRPC listener, or nil if it is
+// disabled.
+func (a *App) GRPCAddr() net.Addr {
+	if a.grpcLn == nil {
+		return nil
+	}
+
+	return a.grpcLn.Addr()
+}
+
+// AddWorker registers a background job. Workers are started by Run and
+// stopped after the HTTP server has drained.
+func (a *App) AddWorker(w Worker) {
+	a.workers = append(a.workers, w)
+}
+
+// Run serves HTTP until ctx is cancelled or the server fails, then shuts
+// down in order: the HTTP server (draining in-flight requests for up to the
+// configured grace period), background workers and finally the storage.
+func (a *App) Run(ctx context.Context) error {
+	const op = "app.Run"
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var wg sync.WaitGroup
+	for _, w := range a.workers {
+		wg.Add(1)
+		go func(w Worker) {
+			defer wg.Done()
+			w(workersCtx)
+		}(w)
+	}
+
+	a.log.Info("starting server", slog.String("address", a.Addr().String()), slog.Bool("tls", a.srv.TLSConfig != nil))
+
+	serveErr := make(chan error, 3)
+	go func() {
+		if a.srv.TLSConfig != nil {
+			serveErr <- a.srv.ServeTLS(a.ln, "", "")
+			return
+		}
+		serveErr <- a.srv.Serve(a.ln)
+	}()
+
+	if a.redirect != nil {
+		a.log.Info("redirecting HTTP to HTTPS", slog.String("address", a.RedirectAddr().String()))
+		go func() {
+			serveErr <- a.redirect.Serve(a.redirectLn)
+		}()
+	}
+
+	if a.grpcSrv != nil {
+		a.log.Info("starting gRPC server", slog.String("address", a.GRPCAddr().String()))
+		go func() {
+			serveErr <- a.grpcSrv.Serve(a.grpcLn)
+		}()
+	}
+
+	var errs []error
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("%s: serve: %w", op, err))
+		}
+		// Any listener failing takes the others down with it.
+		a.srv.Close()
+		if a.grpcSrv != nil {
+			a.grpcSrv.Stop()
+		}
+	case <-ctx.Done():
+		a.log.Info("shutting down server", slog.Duration("grace_period", a.shutdownTimeout))
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+		defer cancel()
+
+		if err := a.srv.Shutdown(shutdownCtx); err != nil {
+			a.log.Error("failed to drain connections", sl.Err(err))
+			errs = append(errs, fmt.Errorf("%s: shutdown: %w", op, err))
+			a.srv.Close()
+		}
+
+		// Watch streams have already ended with the bus, so the rest of
+		// the grace period is left for unary calls.
+		if a.grpcSrv != nil {
+			a.stopGRPC(shutdownCtx)
+		}
+	}
+
+	if a.redirect != nil {
+		a.redirect.Close()
+	}
+
+	stopWorkers()
+	wg.Wait()
+
+	if err := a.storage.Close(); err != nil {
+		a.log.Error("failed to close storage", sl.Err(err))
+		errs = append(errs, fmt.Errorf("%s: %w", op, err))
+	}
+
+	// Flushed last so spans of the requests drained above are exported.
+	flushCtx, cancel := context.WithTimeout(context.Background(), a.shutdownTimeout)
+	defer cancel()
+
+	if err := a.shutdownTracing(flushCtx); err != nil {
+		a.log.Error("failed to flush traces", sl.Err(err))
+		errs = append(errs, fmt.Errorf("%s: %w", op, err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// stopGRPC waits for in-flight gRPC calls to finish until ctx is done, then
+// closes the remaining connections.
+func (a *App) stopGRPC(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.log.Error("failed to drain gRPC calls", sl.Err(ctx.Err()))
+		a.grpcSrv.Stop()
+		<-stopped
+	}
+}
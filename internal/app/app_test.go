@@ -0,0 +1,1321 @@
+package app_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	daytaskv1 "daytask/api/daytask/v1"
+	"daytask/internal/app"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/lib/tlsconfig/tlstest"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	return &config.Config{
+		Env:         "local",
+		StoragePath: filepath.Join(t.TempDir(), "storage.db"),
+		SQLite: config.SQLite{
+			JournalMode:  "WAL",
+			BusyTimeout:  time.Second,
+			Synchronous:  "NORMAL",
+			MaxOpenConns: 2,
+			MaxIdleConns: 2,
+		},
+		HTTPServer: config.HTTPServer{
+			Address:         "127.0.0.1:0",
+			Timeout:         time.Second,
+			IdleTimeout:     time.Second,
+			ShutdownTimeout: 5 * time.Second,
+			User:            "test_user",
+			Password:        "pass",
+		},
+	}
+}
+
+// startApp runs the app configured by cfg until the test ends.
+func startApp(t *testing.T, cfg *config.Config) *app.App {
+	t.Helper()
+
+	a, err := app.New(slogdiscard.NewDiscardLogger(), cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-runErr)
+	})
+
+	return a
+}
+
+func TestRunShutdown(t *testing.T) {
+	a, err := app.New(slogdiscard.NewDiscardLogger(), testConfig(t))
+	require.NoError(t, err)
+
+	workerStopped := make(chan struct{})
+	a.AddWorker(func(ctx context.Context) {
+		<-ctx.Done()
+		close(workerStopped)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run(ctx)
+	}()
+
+	url := "http://" + a.Addr().String() + "/task/all"
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.SetBasicAuth("test_user", "pass")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+
+	select {
+	case err := <-runErr:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after shutdown")
+	}
+
+	select {
+	case <-workerStopped:
+	default:
+		t.Fatal("worker was not stopped before Run returned")
+	}
+
+	_, err = net.DialTimeout("tcp", a.Addr().String(), time.Second)
+	require.Error(t, err)
+}
+
+func TestNewAddressInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	cfg := testConfig(t)
+	cfg.Address = ln.Addr().String()
+
+	_, err = app.New(slogdiscard.NewDiscardLogger(), cfg)
+	require.Error(t, err)
+}
+
+func TestProbes(t *testing.T) {
+	a := startApp(t, testConfig(t))
+
+	for _, path := range []string{"/healthz", "/readyz", "/version", "/metrics"} {
+		resp, err := http.Get("http://" + a.Addr().String() + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+	}
+}
+
+func TestReload(t *testing.T) {
+	cfg := testConfig(t)
+
+	a := startApp(t, cfg)
+
+	status := func(user, password string) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+a.Addr().String()+"/task/all", nil)
+		require.NoError(t, err)
+		req.SetBasicAuth(user, password)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusUnauthorized, status("new_user", "secret"))
+
+	next := *cfg
+	next.HTTPServer.Users = map[string]string{"new_user": "secret"}
+	next.StoragePath = "elsewhere.db"
+	a.Reload(&next)
+
+	require.Equal(t, http.StatusOK, status("new_user", "secret"))
+	require.Equal(t, http.StatusOK, status("test_user", "pass"))
+
+	next.HTTPServer.Password = "changed"
+	a.Reload(&next)
+
+	require.Equal(t, http.StatusUnauthorized, status("test_user", "pass"))
+	require.Equal(t, http.StatusOK, status("test_user", "changed"))
+}
+
+func TestLockout(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"bob": "secret"}
+	cfg.HTTPServer.Roles = map[string]string{"test_user": "admin"}
+	cfg.Lockout = config.Lockout{
+		Enabled:       true,
+		MaxFailures:   3,
+		MaxIPFailures: 100,
+		Window:        time.Hour,
+		Duration:      time.Hour,
+	}
+
+	a := startApp(t, cfg)
+
+	do := func(method, path, user, password, body string) *http.Response {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, password)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp
+	}
+
+	for i := 0; i < cfg.Lockout.MaxFailures; i++ {
+		require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/task/all", "bob", "guess", "").StatusCode)
+	}
+
+	resp := do(http.MethodGet, "/task/all", "bob", "secret", "")
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/admin/lockouts", "test_user", "pass", "").StatusCode)
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/unlock", "test_user", "pass", `{"username":"bob"}`).StatusCode)
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/all", "bob", "secret", "").StatusCode)
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/lockouts", "bob", "secret", "").StatusCode)
+}
+
+func TestRoles(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"viewer": "secret"}
+	cfg.HTTPServer.Roles = map[string]string{"test_user": "admin", "viewer": "read-only"}
+
+	a := startApp(t, cfg)
+
+	do := func(method, path, user, password, body string) int {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, password)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	task := `{"title":"t","owner":"viewer","date":"2024-01-01"}`
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/all", "viewer", "secret", `{"owner":"viewer"}`))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/task/save", "viewer", "secret", task))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPatch, "/task/", "viewer", "secret", `{"id":1}`))
+	require.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/task/", "viewer", "secret", `{"id":1}`))
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/users", "viewer", "secret", ""))
+
+	// Accounts created by an admin log in from the database.
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/users", "test_user", "pass",
+		`{"username":"carol","password":"password1","role":"member"}`))
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/task/save", "carol", "password1", task))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/admin/tasks/count", "test_user", "pass", ""))
+
+	require.Equal(t, http.StatusOK, do(http.MethodPatch, "/admin/users", "test_user", "pass",
+		`{"username":"carol","password":"password2"}`))
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/task/all", "carol", "password1", ""))
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/all", "carol", "password2", ""))
+
+	require.Equal(t, http.StatusOK, do(http.MethodPatch, "/admin/users", "test_user", "pass",
+		`{"username":"carol","disabled":true}`))
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/task/all", "carol", "password2", ""))
+
+	// Every change to carol is in the audit log, attributed to the admin.
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/admin/audit", "viewer", "secret", ""))
+
+	req, err := http.NewRequest(http.MethodGet, "http://"+a.Addr().String()+"/admin/audit",
+		strings.NewReader(`{"entity":"user","entity_id":"carol","format":"ndjson"}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("test_user", "pass")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	var actions []string
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		var e struct {
+			Actor      string `json:"actor"`
+			Action     string `json:"action"`
+			RemoteAddr string `json:"remote_addr"`
+		}
+		require.NoError(t, dec.Decode(&e))
+		require.Equal(t, "test_user", e.Actor)
+		require.NotEmpty(t, e.RemoteAddr)
+		actions = append(actions, e.Action)
+	}
+	require.Equal(t, []string{"create", "update", "update"}, actions)
+}
+
+func TestWorkspaces(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"bob": "b", "carol": "c"}
+
+	a := startApp(t, cfg)
+
+	passwords := map[string]string{"test_user": "pass", "bob": "b", "carol": "c"}
+
+	// do returns the status code and decodes the body into out.
+	do := func(method, path, user, body string, out any) int {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, passwords[user])
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		if out != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+
+		return resp.StatusCode
+	}
+
+	var created struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		ID     int64  `json:"id"`
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/workspace/", "test_user", `{"name":"team"}`, &created))
+	require.Equal(t, "OK", created.Status, created.Error)
+	ws := created.ID
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/workspace/members", "test_user",
+		fmt.Sprintf(`{"workspace_id":%d,"username":"bob","role":"member"}`, ws), nil))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/workspace/members", "bob",
+		fmt.Sprintf(`{"workspace_id":%d,"username":"carol","role":"member"}`, ws), nil))
+
+	// Assignees must be members.
+	do(http.MethodPost, "/workspace/task", "test_user",
+		fmt.Sprintf(`{"workspace_id":%d,"title":"t","date":"2024-01-01","assignees":["carol"]}`, ws), &created)
+	require.Equal(t, "Error", created.Status)
+
+	do(http.MethodPost, "/workspace/task", "test_user",
+		fmt.Sprintf(`{"workspace_id":%d,"title":"t","date":"2024-01-01","assignees":["bob"]}`, ws), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	task := created.ID
+	do(http.MethodPost, "/workspace/task", "test_user",
+		fmt.Sprintf(`{"workspace_id":%d,"title":"unassigned","date":"2024-01-01"}`, ws), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+
+	var dayResp struct {
+		Tasks []struct {
+			ID        int64    `json:"id"`
+			Status    string   `json:"status"`
+			Assignees []string `json:"assignees"`
+		} `json:"tasks"`
+	}
+	dayBody := func(view string) string {
+		return fmt.Sprintf(`{"workspace_id":%d,"date":"2024-01-01","view":"%s"}`, ws, view)
+	}
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/workspace/day", "bob", dayBody("team"), &dayResp))
+	require.Len(t, dayResp.Tasks, 2)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/workspace/day", "bob", dayBody("my"), &dayResp))
+	require.Len(t, dayResp.Tasks, 1)
+	require.Equal(t, []string{"bob"}, dayResp.Tasks[0].Assignees)
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/workspace/day", "carol", dayBody("team"), nil))
+
+	// Assignees may only change the status, and only owners may delete.
+	require.Equal(t, http.StatusOK, do(http.MethodPatch, "/workspace/task", "bob",
+		fmt.Sprintf(`{"id":%d,"status":"done"}`, task), nil))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPatch, "/workspace/task", "bob",
+		fmt.Sprintf(`{"id":%d,"title":"mine"}`, task), nil))
+	require.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/workspace/task", "bob",
+		fmt.Sprintf(`{"id":%d}`, task), nil))
+
+	// The personal task endpoints leave workspace tasks alone.
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, "/task/", "bob", fmt.Sprintf(`{"id":%d}`, task), nil))
+
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/workspace/day", "bob", dayBody("my"), &dayResp))
+	require.Len(t, dayResp.Tasks, 1)
+	require.Equal(t, "done", dayResp.Tasks[0].Status)
+
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, "/workspace/task", "test_user",
+		fmt.Sprintf(`{"id":%d}`, task), &created))
+	require.Equal(t, "OK", created.Status, created.Error)
+	dayResp.Tasks = nil
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/workspace/day", "bob", dayBody("my"), &dayResp))
+	require.Empty(t, dayResp.Tasks)
+
+	// Deleted tasks wait in the trash of the workspace owner.
+	var trash struct {
+		Tasks []struct {
+			ID int64 `json:"id"`
+		} `json:"tasks"`
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/trash", "bob", "", &trash))
+	require.Empty(t, trash.Tasks)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/trash", "test_user", "", &trash))
+	require.Len(t, trash.Tasks, 1)
+	require.Equal(t, task, trash.Tasks[0].ID)
+
+	do(http.MethodPost, "/task/restore", "bob", fmt.Sprintf(`{"id":%d}`, task), &created)
+	require.Equal(t, "Error", created.Status)
+	do(http.MethodPost, "/task/restore", "test_user", fmt.Sprintf(`{"id":%d}`, task), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/workspace/day", "bob", dayBody("my"), &dayResp))
+	require.Len(t, dayResp.Tasks, 1)
+}
+
+func TestCommentsAndActivity(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"bob": "b"}
+
+	a := startApp(t, cfg)
+
+	passwords := map[string]string{"test_user": "pass", "bob": "b"}
+
+	// do returns the status code and decodes the body into out.
+	do := func(method, path, user, body string, out any) int {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, passwords[user])
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		if out != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+
+		return resp.StatusCode
+	}
+
+	var created struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		ID     int64  `json:"id"`
+	}
+	do(http.MethodPost, "/task/save", "test_user", `{"title":"t","owner":"test_user","date":"2024-01-01"}`, &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	task := created.ID
+
+	do(http.MethodPatch, "/task/", "test_user",
+		fmt.Sprintf(`{"id":%d,"title":"t","owner":"test_user","date":"2024-01-01","status":"done"}`, task), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+
+	// Personal tasks take comments from their owner and assignees only.
+	require.Equal(t, http.StatusForbidden,
+		do(http.MethodPost, "/task/comment", "bob", fmt.Sprintf(`{"task_id":%d,"text":"first"}`, task), nil))
+
+	do(http.MethodPost, "/task/comment", "test_user", fmt.Sprintf(`{"task_id":%d,"text":"first"}`, task), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	comment := created.ID
+
+	// Only the author may edit or delete a comment.
+	do(http.MethodPatch, "/task/comment", "bob", fmt.Sprintf(`{"id":%d,"text":"mine"}`, comment), &created)
+	require.Equal(t, "Error", created.Status)
+	do(http.MethodPatch, "/task/comment", "test_user", fmt.Sprintf(`{"id":%d,"text":"edited"}`, comment), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+
+	var day struct {
+		Tasks []struct {
+			Comments []struct {
+				Author string `json:"author"`
+				Text   string `json:"text"`
+			} `json:"comments"`
+			Activity []struct {
+				Actor string `json:"actor"`
+				Field string `json:"field"`
+				From  string `json:"from"`
+				To    string `json:"to"`
+			} `json:"activity"`
+		} `json:"tasks"`
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/day", "test_user",
+		`{"owner":"test_user","date":"2024-01-01","include":["comments","activity"]}`, &day))
+	require.Len(t, day.Tasks, 1)
+	require.Len(t, day.Tasks[0].Comments, 1)
+	require.Equal(t, "test_user", day.Tasks[0].Comments[0].Author)
+	require.Equal(t, "edited", day.Tasks[0].Comments[0].Text)
+	require.Len(t, day.Tasks[0].Activity, 1)
+	require.Equal(t, "test_user", day.Tasks[0].Activity[0].Actor)
+	require.Equal(t, "status", day.Tasks[0].Activity[0].Field)
+	require.Equal(t, "done", day.Tasks[0].Activity[0].To)
+
+	// Others get the tasks without them.
+	day.Tasks = nil
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/day", "bob",
+		`{"owner":"test_user","date":"2024-01-01","include":["comments","activity"]}`, &day))
+	require.Len(t, day.Tasks, 1)
+	require.Empty(t, day.Tasks[0].Comments)
+	require.Empty(t, day.Tasks[0].Activity)
+
+	// The owner sees the changes to its tasks, others only their own.
+	var feed struct {
+		Activity []struct {
+			Actor string `json:"actor"`
+		} `json:"activity"`
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/activity", "test_user", "", &feed))
+	require.Len(t, feed.Activity, 1)
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/task/activity", "bob", `{"user":"test_user"}`, nil))
+
+	do(http.MethodDelete, "/task/comment", "test_user", fmt.Sprintf(`{"id":%d}`, comment), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	day.Tasks = nil
+	do(http.MethodGet, "/task/day", "test_user", `{"owner":"test_user","date":"2024-01-01","include":["comments"]}`, &day)
+	require.Len(t, day.Tasks, 1)
+	require.Empty(t, day.Tasks[0].Comments)
+}
+
+func TestRevisionsOwnerOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"bob": "b"}
+
+	a := startApp(t, cfg)
+
+	passwords := map[string]string{"test_user": "pass", "bob": "b"}
+
+	do := func(method, path, user, body string, out any) int {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, passwords[user])
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		if out != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+
+		return resp.StatusCode
+	}
+
+	var created struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		ID     int64  `json:"id"`
+	}
+	do(http.MethodPost, "/task/save", "test_user", `{"title":"t","owner":"test_user","date":"2024-01-01"}`, &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+	task := created.ID
+
+	// The history of a personal task belongs to its owner alone.
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/task/revisions", "bob", fmt.Sprintf(`{"id":%d}`, task), nil))
+	require.Equal(t, http.StatusForbidden, do(http.MethodGet, "/task/revisions/diff", "bob", fmt.Sprintf(`{"id":%d,"from":1}`, task), nil))
+	require.Equal(t, http.StatusForbidden, do(http.MethodPost, "/task/revert", "bob", fmt.Sprintf(`{"id":%d,"rev":1}`, task), nil))
+
+	var revisions struct {
+		Status    string            `json:"status"`
+		Revisions []json.RawMessage `json:"revisions"`
+	}
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/task/revisions", "test_user", fmt.Sprintf(`{"id":%d}`, task), &revisions))
+	require.Equal(t, "OK", revisions.Status)
+	require.NotEmpty(t, revisions.Revisions)
+
+	do(http.MethodPost, "/task/revert", "test_user", fmt.Sprintf(`{"id":%d,"rev":1}`, task), &created)
+	require.Equal(t, "OK", created.Status, created.Error)
+}
+
+func TestTaskOwnerOnly(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"bob": "b"}
+
+	a := startApp(t, cfg)
+
+	passwords := map[string]string{"test_user": "pass", "bob": "b"}
+
+	type result struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		ID     int64  `json:"id"`
+	}
+	do := func(method, path, user, body string) (int, result) {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, passwords[user])
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var res result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+
+		return resp.StatusCode, res
+	}
+
+	_, created := do(http.MethodPost, "/task/save", "test_user", `{"title":"t","owner":"test_user","date":"2024-01-01"}`)
+	require.Equal(t, "OK", created.Status, created.Error)
+
+	code, _ := do(http.MethodPatch, "/task/", "bob", fmt.Sprintf(`{"id":%d,"title":"mine","owner":"bob","date":"2024-01-01"}`, created.ID))
+	require.Equal(t, http.StatusForbidden, code)
+	code, _ = do(http.MethodDelete, "/task/", "bob", fmt.Sprintf(`{"id":%d}`, created.ID))
+	require.Equal(t, http.StatusForbidden, code)
+
+	// Only admins give a task away, an omitted owner keeps it.
+	code, res := do(http.MethodPatch, "/task/", "test_user", fmt.Sprintf(`{"id":%d,"title":"t","owner":"bob","date":"2024-01-01"}`, created.ID))
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, "only admins may change the owner", res.Error)
+	_, res = do(http.MethodPatch, "/task/", "test_user", fmt.Sprintf(`{"id":%d,"title":"t2","date":"2024-01-01"}`, created.ID))
+	require.Equal(t, "OK", res.Status, res.Error)
+
+	var tasks struct {
+		Tasks []struct {
+			Title string `json:"title"`
+			Owner string `json:"owner"`
+		} `json:"tasks"`
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://"+a.Addr().String()+"/task/day", strings.NewReader(`{"owner":"test_user","date":"2024-01-01"}`))
+	require.NoError(t, err)
+	req.SetBasicAuth("test_user", "pass")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&tasks))
+	require.Len(t, tasks.Tasks, 1)
+	require.Equal(t, "t2", tasks.Tasks[0].Title)
+	require.Equal(t, "test_user", tasks.Tasks[0].Owner)
+
+	_, res = do(http.MethodDelete, "/task/", "test_user", fmt.Sprintf(`{"id":%d}`, created.ID))
+	require.Equal(t, "OK", res.Status, res.Error)
+}
+
+func TestTaskStream(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Stream = config.Stream{BufferSize: 10, Retention: time.Minute}
+
+	// Shutdown ends the streams still open.
+	a := startApp(t, cfg)
+
+	base := "http://" + a.Addr().String()
+
+	save := func(owner string) {
+		req, err := http.NewRequest(http.MethodPost, base+"/task/save",
+			strings.NewReader(`{"title":"t","owner":"`+owner+`","date":"2024-01-01"}`))
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// open starts a stream and returns its next event as "id event".
+	open := func(lastID string) func() string {
+		req, err := http.NewRequest(http.MethodGet, base+"/tasks/stream", nil)
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		sc := bufio.NewScanner(resp.Body)
+		return func() string {
+			var id, event string
+			for sc.Scan() {
+				line := sc.Text()
+				switch {
+				case line == "" && event != "":
+					return strings.TrimSpace(id + " " + event)
+				case strings.HasPrefix(line, "id: "):
+					id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					event = strings.TrimPrefix(line, "event: ")
+				}
+			}
+			require.NoError(t, sc.Err())
+			return ""
+		}
+	}
+
+	next := open("")
+	save("someone_else")
+	save("test_user")
+	require.Equal(t, "2 task.created", next())
+
+	// Resuming replays what was missed.
+	save("test_user")
+	require.Equal(t, "3 task.created", open("2")())
+
+	// Events from before a restart are gone.
+	require.Equal(t, "reset", open("99")())
+
+	require.Equal(t, "3 task.created", next())
+}
+
+func TestTaskSocket(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"viewer": "secret", "bob": "b"}
+	cfg.HTTPServer.Roles = map[string]string{"viewer": "read-only"}
+
+	a := startApp(t, cfg)
+
+	dial := func(user, password string) *websocket.Conn {
+		header := http.Header{}
+		req, err := http.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, err)
+		req.SetBasicAuth(user, password)
+		header.Set("Authorization", req.Header.Get("Authorization"))
+
+		conn, resp, err := websocket.DefaultDialer.Dial("ws://"+a.Addr().String()+"/tasks/ws", header)
+		require.NoError(t, err)
+		resp.Body.Close()
+		t.Cleanup(func() { conn.Close() })
+
+		return conn
+	}
+
+	type reply struct {
+		Type   string `json:"type"`
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		TaskID int64  `json:"task_id"`
+		Tasks  []struct {
+			ID int64 `json:"id"`
+		} `json:"tasks"`
+		Event *struct {
+			Type string `json:"type"`
+			Task struct {
+				ID     int64  `json:"id"`
+				Title  string `json:"title"`
+				Date   string `json:"date"`
+				Status string `json:"status"`
+			} `json:"task"`
+		} `json:"event"`
+	}
+
+	send := func(conn *websocket.Conn, msg string) reply {
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(msg)))
+
+		var r reply
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, conn.ReadJSON(&r))
+		return r
+	}
+
+	next := func(conn *websocket.Conn) reply {
+		var r reply
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, conn.ReadJSON(&r))
+		return r
+	}
+
+	conn := dial("test_user", "pass")
+
+	r := send(conn, `{"type":"save","id":"1","task":{"title":"before","owner":"test_user","date":"2024-01-02","status":"done"}}`)
+	require.Equal(t, "OK", r.Status, r.Error)
+	first := r.TaskID
+
+	r = send(conn, `{"type":"subscribe","id":"2","from":"2024-01-01","to":"2024-01-07"}`)
+	require.Equal(t, "snapshot", r.Type)
+	require.Equal(t, "2", r.ID)
+	require.Len(t, r.Tasks, 1)
+	require.Equal(t, first, r.Tasks[0].ID)
+
+	// Mutations are validated like the REST handlers.
+	r = send(conn, `{"type":"save","id":"3","task":{"title":"t","owner":"test_user","date":"tomorrow"}}`)
+	require.Equal(t, "result", r.Type)
+	require.Equal(t, "Error", r.Status)
+	require.Equal(t, "field Date is not valid", r.Error)
+
+	// Outside the range, no event. The owner defaults to the caller.
+	r = send(conn, `{"type":"save","id":"4","task":{"title":"t","date":"2024-03-01"}}`)
+	require.Equal(t, "OK", r.Status, r.Error)
+
+	// Moving a task out of the range is an event. Updates are merge
+	// patches, the fields left out keep their value.
+	r = send(conn, fmt.Sprintf(`{"type":"update","id":"5","task":{"id":%d,"date":"2024-02-01"}}`, first))
+	require.Equal(t, "result", r.Type)
+	require.Equal(t, "OK", r.Status, r.Error)
+
+	r = next(conn)
+	require.Equal(t, "event", r.Type)
+	require.Equal(t, "task.updated", r.Event.Type)
+	require.Equal(t, first, r.Event.Task.ID)
+	require.Equal(t, "2024-02-01", r.Event.Task.Date)
+	require.Equal(t, "before", r.Event.Task.Title)
+	require.Equal(t, "done", r.Event.Task.Status)
+
+	r = send(conn, fmt.Sprintf(`{"type":"update","id":"6","task":{"id":%d,"date":null}}`, first))
+	require.Equal(t, "Error", r.Status)
+	r = send(conn, `{"type":"update","id":"7","task":{"title":"no id"}}`)
+	require.Equal(t, "field id is a required field", r.Error)
+
+	// Only the owner and assignees change a task, only admins give it away.
+	r = send(conn, fmt.Sprintf(`{"type":"update","id":"8","task":{"id":%d,"owner":"bob"}}`, first))
+	require.Equal(t, "only admins may change the owner", r.Error)
+
+	bob := dial("bob", "b")
+	r = send(bob, fmt.Sprintf(`{"type":"update","id":"9","task":{"id":%d,"title":"mine"}}`, first))
+	require.Equal(t, "forbidden", r.Error)
+	r = send(bob, `{"type":"save","id":"10","task":{"title":"t","owner":"test_user","date":"2024-01-02"}}`)
+	require.Equal(t, "only admins may save tasks of another owner", r.Error)
+
+	viewer := dial("viewer", "secret")
+	r = send(viewer, `{"type":"save","id":"11","task":{"title":"t","owner":"viewer","date":"2024-01-02"}}`)
+	require.Equal(t, "forbidden", r.Error)
+}
+
+func TestSync(t *testing.T) {
+	a := startApp(t, testConfig(t))
+
+	do := func(method, path, contentType, body string, out any) {
+		req, err := http.NewRequest(method, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+		req.Header.Set("Content-Type", contentType)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		if out != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+	}
+
+	type task struct {
+		ID          int64  `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		ServerRev   int64  `json:"server_rev"`
+	}
+	type result struct {
+		ID        int64    `json:"id"`
+		Status    string   `json:"status"`
+		Conflicts []string `json:"conflicts"`
+		Error     string   `json:"error"`
+		Task      *task    `json:"task"`
+		Deleted   bool     `json:"deleted"`
+	}
+
+	// push sends one change and returns its result.
+	push := func(change string) result {
+		var resp struct {
+			Status  string   `json:"status"`
+			Error   string   `json:"error"`
+			Results []result `json:"results"`
+		}
+		do(http.MethodPost, "/sync", "application/json", `{"changes":[`+change+`]}`, &resp)
+		require.Equal(t, "OK", resp.Status, resp.Error)
+		require.Len(t, resp.Results, 1)
+		return resp.Results[0]
+	}
+
+	r := push(`{"op":"create","client_id":"p1","task":{"title":"t","date":"2024-01-01"}}`)
+	require.Equal(t, "applied", r.Status, r.Error)
+	id, base := r.Task.ID, r.Task.ServerRev
+
+	// A retried create is not duplicated.
+	r = push(`{"op":"create","client_id":"p1","task":{"title":"t","date":"2024-01-01"}}`)
+	require.Equal(t, id, r.ID)
+
+	var pulled struct {
+		Rev     int64 `json:"rev"`
+		Changes []struct {
+			ID      int64 `json:"id"`
+			Deleted bool  `json:"deleted"`
+			Task    *task `json:"task"`
+		} `json:"changes"`
+	}
+	do(http.MethodGet, "/sync?since=0", "", "", &pulled)
+	require.Len(t, pulled.Changes, 1)
+	require.Equal(t, "t", pulled.Changes[0].Task.Title)
+	since := pulled.Rev
+
+	// Changed on the server meanwhile.
+	do(http.MethodPatch, fmt.Sprintf("/task/?id=%d", id), "application/merge-patch+json", `{"title":"server"}`, nil)
+
+	baseFields := `"base":{"title":"t","date":"2024-01-01"}`
+
+	r = push(fmt.Sprintf(`{"op":"update","id":%d,"base_rev":%d,"task":{"title":"t","description":"offline","date":"2024-01-01"}}`, id, base))
+	require.Equal(t, "rejected", r.Status)
+	require.Equal(t, "server", r.Task.Title)
+
+	r = push(fmt.Sprintf(`{"op":"update","id":%d,"base_rev":%d,%s,"task":{"title":"t","description":"offline","date":"2024-01-01"}}`, id, base, baseFields))
+	require.Equal(t, "merged", r.Status)
+	require.Empty(t, r.Conflicts)
+	require.Equal(t, "server", r.Task.Title)
+	require.Equal(t, "offline", r.Task.Description)
+
+	r = push(fmt.Sprintf(`{"op":"update","id":%d,"base_rev":%d,%s,"task":{"title":"phone","date":"2024-01-01"}}`, id, base, baseFields))
+	require.Equal(t, "merged", r.Status)
+	require.Equal(t, []string{"title"}, r.Conflicts)
+	require.Equal(t, "server", r.Task.Title)
+
+	r = push(fmt.Sprintf(`{"op":"delete","id":%d,"base_rev":%d,%s}`, id, base, baseFields))
+	require.Equal(t, "rejected", r.Status)
+	require.False(t, r.Deleted)
+
+	r = push(fmt.Sprintf(`{"op":"delete","id":%d,"base_rev":%d}`, id, r.Task.ServerRev))
+	require.Equal(t, "applied", r.Status)
+	require.True(t, r.Deleted)
+
+	r = push(fmt.Sprintf(`{"op":"update","id":%d,"base_rev":%d,"task":{"title":"late","date":"2024-01-01"}}`, id, r.Task.ServerRev))
+	require.Equal(t, "rejected", r.Status)
+	require.True(t, r.Deleted)
+
+	r = push(`{"op":"update","id":9999,"base_rev":1,"task":{"title":"t","date":"2024-01-01"}}`)
+	require.Equal(t, "failed", r.Status)
+
+	do(http.MethodGet, fmt.Sprintf("/sync?since=%d", since), "", "", &pulled)
+	require.Len(t, pulled.Changes, 1)
+	require.True(t, pulled.Changes[0].Deleted)
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, dir, "ca")
+	server := tlstest.NewServer(t, dir, "server", ca)
+	client := tlstest.NewClient(t, dir, "client", ca)
+
+	cfg := testConfig(t)
+	cfg.HTTPServer.TLS = config.TLS{
+		Enabled:         true,
+		CertFile:        server.CertFile,
+		KeyFile:         server.KeyFile,
+		MinVersion:      "1.2",
+		ClientCAFile:    ca.CertFile,
+		RedirectAddress: "127.0.0.1:0",
+	}
+
+	a := startApp(t, cfg)
+
+	url := "https://" + a.Addr().String() + "/healthz"
+
+	httpClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   &tls.Config{RootCAs: ca.Pool(), Certificates: certs},
+				ForceAttemptHTTP2: true,
+			},
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+
+	resp, err := httpClient(client.TLSCertificate(t)).Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, resp.ProtoMajor)
+
+	// mTLS: a client without a certificate is rejected during the handshake.
+	_, err = httpClient().Get(url)
+	require.Error(t, err)
+
+	resp, err = httpClient().Get("http://" + a.RedirectAddr().String() + "/task/day?x=1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+
+	_, port, err := net.SplitHostPort(a.Addr().String())
+	require.NoError(t, err)
+	require.Equal(t, "https://127.0.0.1:"+port+"/task/day?x=1", resp.Header.Get("Location"))
+}
+
+func TestGRPC(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"reader": "secret", "bob": "b", "boss": "admin"}
+	cfg.HTTPServer.Roles = map[string]string{"reader": "read-only", "boss": "admin"}
+	cfg.GRPCServer = config.GRPCServer{Enabled: true, Address: "127.0.0.1:0"}
+	cfg.Stream = config.Stream{BufferSize: 10, Retention: time.Minute}
+
+	a := startApp(t, cfg)
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	client := daytaskv1.NewTaskServiceClient(conn)
+
+	as := func(user, password string) context.Context {
+		creds := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+creds)
+	}
+	member := as("test_user", "pass")
+
+	_, err = client.ListAll(context.Background(), &daytaskv1.ListAllRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.ListAll(as("test_user", "wrong"), &daytaskv1.ListAllRequest{})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	watchCtx, stopWatch := context.WithCancel(member)
+	defer stopWatch()
+	watch, err := client.Watch(watchCtx, &daytaskv1.WatchRequest{})
+	require.NoError(t, err)
+	// The stream is subscribed once the server sends its headers.
+	_, err = watch.Header()
+	require.NoError(t, err)
+
+	_, err = client.Create(member, &daytaskv1.CreateRequest{Title: "t", Date: "2024-13-01"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	created, err := client.Create(member, &daytaskv1.CreateRequest{Title: "t", Date: "2024-01-01"})
+	require.NoError(t, err)
+	task := created.GetTask()
+	require.NotZero(t, task.GetId())
+	require.Equal(t, "test_user", task.GetOwner())
+	require.Equal(t, "2024-01-01", task.GetDate())
+	require.Equal(t, "unstarted", task.GetStatus())
+
+	event, err := watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "task.created", event.GetType())
+	require.Equal(t, task.GetId(), event.GetTask().GetId())
+
+	got, err := client.Get(member, &daytaskv1.GetRequest{Id: task.GetId()})
+	require.NoError(t, err)
+	require.Equal(t, "t", got.GetTask().GetTitle())
+
+	day, err := client.ListByDay(member, &daytaskv1.ListByDayRequest{Date: "2024-01-01"})
+	require.NoError(t, err)
+	require.Len(t, day.GetTasks(), 1)
+
+	// Updates leave the fields they do not set as they are.
+	updated, err := client.Update(member, &daytaskv1.UpdateRequest{Id: task.GetId(), Title: proto.String("t2"), Status: proto.String("done")})
+	require.NoError(t, err)
+	require.Equal(t, "t2", updated.GetTask().GetTitle())
+	require.Equal(t, "done", updated.GetTask().GetStatus())
+	require.Equal(t, "2024-01-01", updated.GetTask().GetDate())
+	require.Equal(t, "test_user", updated.GetTask().GetOwner())
+	require.Greater(t, updated.GetTask().GetServerRev(), task.GetServerRev())
+
+	event, err = watch.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "task.updated", event.GetType())
+	require.NotEmpty(t, event.GetChanges())
+
+	// Only admins give tasks to someone else.
+	_, err = client.Update(member, &daytaskv1.UpdateRequest{Id: task.GetId(), Owner: proto.String("bob")})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Others may neither change nor list the task.
+	bob := as("bob", "b")
+	_, err = client.Update(bob, &daytaskv1.UpdateRequest{Id: task.GetId(), Title: proto.String("mine")})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = client.Delete(bob, &daytaskv1.DeleteRequest{Id: task.GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	all, err := client.ListAll(bob, &daytaskv1.ListAllRequest{Owner: "test_user"})
+	require.NoError(t, err)
+	require.Empty(t, all.GetTasks())
+	day, err = client.ListByDay(bob, &daytaskv1.ListByDayRequest{Owner: "test_user", Date: "2024-01-01"})
+	require.NoError(t, err)
+	require.Empty(t, day.GetTasks())
+
+	// Read-only users read but do not write.
+	reader := as("reader", "secret")
+	all, err = client.ListAll(reader, &daytaskv1.ListAllRequest{})
+	require.NoError(t, err)
+	require.Empty(t, all.GetTasks())
+
+	// Single tasks are only shown to those who may see them: their owner
+	// and assignees, or the members of their workspace.
+	_, err = client.Get(reader, &daytaskv1.GetRequest{Id: task.GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	post := func(path, body string) int64 {
+		req, err := http.NewRequest(http.MethodPost, "http://"+a.Addr().String()+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var created struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+			ID     int64  `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		require.Equal(t, "OK", created.Status, created.Error)
+
+		return created.ID
+	}
+	ws := post("/workspace/", `{"name":"team"}`)
+	wsTask := post("/workspace/task", fmt.Sprintf(`{"workspace_id":%d,"title":"shared","date":"2024-01-01"}`, ws))
+
+	got, err = client.Get(member, &daytaskv1.GetRequest{Id: wsTask})
+	require.NoError(t, err)
+	require.Equal(t, "shared", got.GetTask().GetTitle())
+	_, err = client.Get(reader, &daytaskv1.GetRequest{Id: wsTask})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Delete(reader, &daytaskv1.DeleteRequest{Id: task.GetId()})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	boss := as("boss", "admin")
+	own, err := client.Create(boss, &daytaskv1.CreateRequest{Title: "for bob", Date: "2024-01-01"})
+	require.NoError(t, err)
+	updated, err = client.Update(boss, &daytaskv1.UpdateRequest{Id: own.GetTask().GetId(), Owner: proto.String("bob")})
+	require.NoError(t, err)
+	require.Equal(t, "bob", updated.GetTask().GetOwner())
+
+	_, err = client.Delete(member, &daytaskv1.DeleteRequest{Id: task.GetId()})
+	require.NoError(t, err)
+
+	_, err = client.Get(member, &daytaskv1.GetRequest{Id: task.GetId()})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Update(member, &daytaskv1.UpdateRequest{Id: task.GetId(), Date: proto.String("2024-01-02")})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGraphQL(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.HTTPServer.Users = map[string]string{"reader": "secret", "bob": "b"}
+	cfg.HTTPServer.Roles = map[string]string{"reader": "read-only"}
+
+	a := startApp(t, cfg)
+
+	base := "http://" + a.Addr().String()
+	passwords := map[string]string{"test_user": "pass", "reader": "secret", "bob": "b"}
+
+	type result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	query := func(user, q string, vars map[string]any) result {
+		body, err := json.Marshal(map[string]any{"query": q, "variables": vars})
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPost, base+"/graphql", strings.NewReader(string(body)))
+		require.NoError(t, err)
+		req.SetBasicAuth(user, passwords[user])
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var res result
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+
+		return res
+	}
+
+	const save = `mutation($input: TaskInput!) { saveTask(input: $input) { id owner status } }`
+
+	var ids []string
+	for _, title := range []string{"a", "b", "c"} {
+		res := query("test_user", save, map[string]any{"input": map[string]any{"title": title, "date": "2024-01-01"}})
+		require.Empty(t, res.Errors)
+
+		var saved struct {
+			SaveTask struct {
+				ID     string `json:"id"`
+				Owner  string `json:"owner"`
+				Status string `json:"status"`
+			} `json:"saveTask"`
+		}
+		require.NoError(t, json.Unmarshal(res.Data, &saved))
+		require.Equal(t, "test_user", saved.SaveTask.Owner)
+		require.Equal(t, "unstarted", saved.SaveTask.Status)
+		ids = append(ids, saved.SaveTask.ID)
+
+		req, err := http.NewRequest(http.MethodPost, base+"/task/comment",
+			strings.NewReader(`{"task_id":`+saved.SaveTask.ID+`,"text":"on `+title+`"}`))
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	res := query("test_user", `mutation($id: ID!) {
+		updateTask(id: $id, input: {title: "b", date: "2024-01-01", status: "done"}) { status }
+	}`, map[string]any{"id": ids[1]})
+	require.Empty(t, res.Errors)
+
+	res = query("reader", `mutation($id: ID!) { deleteTask(id: $id) }`, map[string]any{"id": ids[2]})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "forbidden", res.Errors[0].Message)
+
+	// Updates keep the fields they leave out, the owner is only changed by
+	// admins.
+	res = query("test_user", `mutation($id: ID!) { updateTask(id: $id, input: {description: "d"}) { title status description } }`,
+		map[string]any{"id": ids[1]})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"updateTask":{"title":"b","status":"done","description":"d"}}`, string(res.Data))
+	res = query("test_user", `mutation($id: ID!) { updateTask(id: $id, input: {owner: "bob"}) { owner } }`,
+		map[string]any{"id": ids[1]})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "only admins may change the owner", res.Errors[0].Message)
+
+	// Other members neither list nor change the tasks of test_user.
+	res = query("bob", `mutation($id: ID!) { updateTask(id: $id, input: {title: "mine"}) { title } }`, map[string]any{"id": ids[1]})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "task not found", res.Errors[0].Message)
+	res = query("bob", `mutation($id: ID!) { deleteTask(id: $id) }`, map[string]any{"id": ids[1]})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "task not found", res.Errors[0].Message)
+	res = query("bob", `{ tasks(owner: "test_user") { title comments { text } } }`, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"tasks":[]}`, string(res.Data))
+
+	res = query("test_user", `{ tasks(date: "2024-01-01") { title comments { text } activity { field to } } }`, nil)
+	require.Empty(t, res.Errors)
+
+	var day struct {
+		Tasks []struct {
+			Title    string `json:"title"`
+			Comments []struct {
+				Text string `json:"text"`
+			} `json:"comments"`
+			Activity []struct {
+				Field string `json:"field"`
+				To    string `json:"to"`
+			} `json:"activity"`
+		} `json:"tasks"`
+	}
+	require.NoError(t, json.Unmarshal(res.Data, &day))
+	require.Len(t, day.Tasks, 3)
+	for _, task := range day.Tasks {
+		require.Len(t, task.Comments, 1)
+		require.Equal(t, "on "+task.Title, task.Comments[0].Text)
+	}
+	require.Len(t, day.Tasks[1].Activity, 2)
+	require.Equal(t, "done", day.Tasks[1].Activity[0].To)
+
+	res = query("test_user", `{ tasks(status: "done") { title } }`, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"tasks":[{"title":"b"}]}`, string(res.Data))
+
+	// The comments and activity of the three tasks took one query each.
+	resp, err := http.Get(base + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	metrics, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(metrics), `daytask_storage_query_duration_seconds_count{op="storage.sqlite.GetComments"} 1`)
+	require.Contains(t, string(metrics), `daytask_storage_query_duration_seconds_count{op="storage.sqlite.GetActivity"} 1`)
+
+	const saveSub = `mutation($input: TaskInput!, $parent: ID) { saveTask(input: $input, parentId: $parent) { owner parentId } }`
+	for _, title := range []string{"a1", "a2"} {
+		res = query("test_user", saveSub, map[string]any{
+			"input": map[string]any{"title": title, "date": "2024-01-02"}, "parent": ids[0],
+		})
+		require.Empty(t, res.Errors)
+		require.JSONEq(t, `{"saveTask":{"owner":"test_user","parentId":"`+ids[0]+`"}}`, string(res.Data))
+	}
+	res = query("test_user", saveSub, map[string]any{
+		"input": map[string]any{"title": "lost", "date": "2024-01-02"}, "parent": "999999",
+	})
+	require.Len(t, res.Errors, 1)
+	require.Equal(t, "parent task not found", res.Errors[0].Message)
+
+	// The subtasks of every task of the day take one query.
+	res = query("test_user", `{ tasks(date: "2024-01-01") { title subtasks { title } } }`, nil)
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"tasks":[
+		{"title":"a","subtasks":[{"title":"a1"},{"title":"a2"}]},
+		{"title":"b","subtasks":[]},
+		{"title":"c","subtasks":[]}
+	]}`, string(res.Data))
+
+	resp, err = http.Get(base + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	metrics, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(metrics), `daytask_storage_query_duration_seconds_count{op="storage.sqlite.GetSubtasks"} 1`)
+
+	res = query("test_user", `mutation($id: ID!) { deleteTask(id: $id) }`, map[string]any{"id": ids[2]})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"deleteTask":true}`, string(res.Data))
+
+	res = query("test_user", `query($id: ID!) { task(id: $id) { id } }`, map[string]any{"id": ids[2]})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"task":null}`, string(res.Data))
+
+	// Others' tasks, with their comments and activity, are hidden like
+	// missing ones.
+	const get = `query($id: ID!) { task(id: $id) { title comments { text } } }`
+	res = query("reader", get, map[string]any{"id": ids[0]})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"task":null}`, string(res.Data))
+
+	post := func(path, body string) string {
+		req, err := http.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth("test_user", "pass")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var created struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+			ID     int64  `json:"id"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		require.Equal(t, "OK", created.Status, created.Error)
+
+		return fmt.Sprint(created.ID)
+	}
+	ws := post("/workspace/", `{"name":"team"}`)
+	wsTask := post("/workspace/task", `{"workspace_id":`+ws+`,"title":"shared","date":"2024-01-01"}`)
+
+	res = query("test_user", get, map[string]any{"id": wsTask})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"task":{"title":"shared","comments":[]}}`, string(res.Data))
+	res = query("reader", get, map[string]any{"id": wsTask})
+	require.Empty(t, res.Errors)
+	require.JSONEq(t, `{"task":null}`, string(res.Data))
+
+	res = query("test_user", `{ users { username } }`, nil)
+	require.Len(t, res.Errors, 1)
+}
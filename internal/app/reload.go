@@ -0,0 +1,47 @@
+package app
+
+import (
+	"daytask/internal/config"
+	"log/slog"
+	"strings"
+)
+
+// Reload applies the settings of next that are safe to change while serving:
+// Basic auth users and their roles, login lockout, CORS origins, rate limits and
+// request timeouts. Settings bound at startup, such as the listen address or
+// the storage path, are logged and keep their current value until the next
+// restart.
+func (a *App) Reload(next *config.Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if changed := a.cfg.RestartRequired(next); len(changed) > 0 {
+		a.log.Warn("config changes require a restart and were not applied",
+			slog.String("settings", strings.Join(changed, ", ")))
+	}
+
+	a.creds.Set(next.HTTPServer.Credentials())
+	a.origins.Set(next.CORS.AllowedOrigins)
+	a.timeouts.Set(next.HTTPServer.Timeout, next.HTTPServer.Timeout)
+	a.limiter.Set(next.RateLimit)
+	a.guard.Set(next.Lockout, next.HTTPServer.Roles)
+
+	applied := *a.cfg
+	applied.LogLevel = next.LogLevel
+	applied.HTTPServer.Timeout = next.HTTPServer.Timeout
+	applied.HTTPServer.User = next.HTTPServer.User
+	applied.HTTPServer.Password = next.HTTPServer.Password
+	applied.HTTPServer.Users = next.HTTPServer.Users
+	applied.HTTPServer.Roles = next.HTTPServer.Roles
+	applied.CORS = next.CORS
+	applied.RateLimit = next.RateLimit
+	applied.Lockout = next.Lockout
+	a.cfg = &applied
+
+	a.log.Info("config applied",
+		slog.Int("users", len(next.HTTPServer.Credentials())),
+		slog.Any("cors_origins", next.CORS.AllowedOrigins),
+		slog.Duration("timeout", next.HTTPServer.Timeout),
+		slog.Bool("rate_limit", next.RateLimit.Enabled),
+	)
+}
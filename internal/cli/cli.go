@@ -0,0 +1,412 @@
+// Package cli is the daytask command-line client, a thin layer over the HTTP
+// API for adding and listing the tasks of the logged in user.
+package cli
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"daytask/internal/storage"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const usage = `Usage: daytask <command> [flags]
+
+Commands:
+  login --url URL --user USER   store the server and credentials, the password is prompted for
+  add TITLE [--date DATE]       add a task, for today unless --date is given
+  today                         list today's tasks
+  ls [--date DATE | --week | --all]
+                                list the tasks of a day, this week or all time
+  done ID...                    mark tasks as done
+  rm ID...                      move tasks to the trash
+  tui [--date DATE]             browse and edit the tasks day by day in a full-screen view
+
+Dates are 2006-01-02, today, tomorrow, yesterday, a weekday such as "fri",
+"next week", "+3" or "in 3 days".
+
+Every command takes --config PATH, the client config written by login, and
+--json to print JSON instead of a table.
+`
+
+// IO is where a command reads and writes.
+type IO struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// Run runs the command in args, without the program name, and returns the
+// exit code: 0 on success, 1 on failure and 2 on misuse.
+func Run(ctx context.Context, args []string, stdio IO) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdio.Err, usage)
+		return 2
+	}
+
+	commands := map[string]func(context.Context, *command) error{
+		"login": login,
+		"add":   add,
+		"today": today,
+		"ls":    list,
+		"done":  done,
+		"rm":    remove,
+		"tui":   runTUI,
+	}
+
+	run, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stdio.Err, "daytask: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	c := newCommand(args[0], stdio)
+	setup := map[string]func(*command){
+		"login": func(c *command) {
+			c.fs.StringVar(&c.url, "url", "", "server URL, e.g. http://localhost:8082")
+			c.fs.StringVar(&c.user, "user", "", "username")
+		},
+		"add": func(c *command) {
+			c.fs.StringVar(&c.date, "date", "today", "date of the task")
+			c.fs.StringVar(&c.description, "description", "", "description of the task")
+			c.fs.StringVar(&c.taskType, "type", "", "type of the task")
+		},
+		"tui": func(c *command) {
+			c.fs.StringVar(&c.date, "date", "today", "day to start on")
+		},
+		"ls": func(c *command) {
+			c.fs.StringVar(&c.date, "date", "", "list the tasks of this date")
+			c.fs.BoolVar(&c.week, "week", false, "list the tasks of this week, Monday to Sunday")
+			c.fs.BoolVar(&c.all, "all", false, "list every task")
+		},
+	}
+	if f, ok := setup[args[0]]; ok {
+		f(c)
+	}
+
+	if err := c.parse(args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(stdio.Err, "daytask %s: %v\n", args[0], err)
+		}
+		return 2
+	}
+
+	if err := run(ctx, c); err != nil {
+		fmt.Fprintf(stdio.Err, "daytask %s: %v\n", args[0], err)
+		return 1
+	}
+
+	return 0
+}
+
+// command holds the flags and arguments of a single run.
+type command struct {
+	IO
+	fs   *flag.FlagSet
+	args []string
+	now  time.Time
+
+	configPath string
+	json       bool
+
+	url, user                   string
+	date, description, taskType string
+	week, all                   bool
+}
+
+func newCommand(name string, stdio IO) *command {
+	c := &command{IO: stdio, fs: flag.NewFlagSet("daytask "+name, flag.ContinueOnError), now: time.Now()}
+	c.fs.SetOutput(stdio.Err)
+	c.fs.StringVar(&c.configPath, "config", "", "client config, see daytask login")
+	c.fs.BoolVar(&c.json, "json", false, "print JSON instead of a table")
+
+	return c
+}
+
+// parse accepts flags before and after the positional arguments, as in
+// daytask add "title" --date tomorrow.
+func (c *command) parse(args []string) error {
+	for {
+		if err := c.fs.Parse(args); err != nil {
+			return err
+		}
+
+		args = c.fs.Args()
+		if len(args) == 0 {
+			return nil
+		}
+
+		c.args = append(c.args, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *command) config() (Config, string, error) {
+	path := c.configPath
+	if path == "" {
+		p, err := DefaultConfigPath()
+		if err != nil {
+			return Config{}, "", err
+		}
+		path = p
+	}
+
+	cfg, err := LoadConfig(path)
+
+	return cfg, path, err
+}
+
+func (c *command) client() (*Client, Config, error) {
+	cfg, _, err := c.config()
+	if err != nil {
+		return nil, Config{}, err
+	}
+
+	return NewClient(cfg), cfg, nil
+}
+
+func login(ctx context.Context, c *command) error {
+	if c.url == "" || c.user == "" {
+		return errors.New("--url and --user are required")
+	}
+
+	cfg, path, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+
+	cfg.URL, cfg.User, cfg.Password = c.url, c.user, password
+
+	// Only credentials that work are stored.
+	if _, err := NewClient(cfg).All(ctx); err != nil {
+		return err
+	}
+
+	if err := cfg.Save(path); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.Out, "logged in as %s, credentials saved to %s\n", cfg.User, path)
+
+	return nil
+}
+
+// readPassword prompts for the password on a terminal, otherwise it reads
+// the first line of the input.
+func (c *command) readPassword() (string, error) {
+	if f, ok := c.In.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		fmt.Fprint(c.Err, "Password: ")
+		password, err := term.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(c.Err)
+
+		return string(password), err
+	}
+
+	line, err := bufio.NewReader(c.In).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func add(ctx context.Context, c *command) error {
+	if len(c.args) != 1 {
+		return errors.New("expected the title as the only argument")
+	}
+
+	date, err := ParseDate(c.date, c.now)
+	if err != nil {
+		return err
+	}
+
+	client, cfg, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	id, err := client.Add(ctx, NewTask{Title: c.args[0], Description: c.description, Owner: cfg.User, Date: date, Type: c.taskType})
+	if err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.printJSON(map[string]any{"id": id, "date": date})
+	}
+
+	fmt.Fprintf(c.Out, "added task %d for %s\n", id, date)
+
+	return nil
+}
+
+func today(ctx context.Context, c *command) error {
+	c.date = "today"
+
+	return list(ctx, c)
+}
+
+func list(ctx context.Context, c *command) error {
+	if len(c.args) > 0 {
+		return fmt.Errorf("unexpected argument %q", c.args[0])
+	}
+
+	client, _, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	var tasks []storage.Task
+	switch {
+	case c.all || c.week:
+		tasks, err = client.All(ctx)
+		if err == nil && c.week {
+			from, to := Week(c.now)
+			tasks = slices.DeleteFunc(tasks, func(t storage.Task) bool {
+				date := dateOnly(t.Date)
+				return date < from || date > to
+			})
+		}
+	default:
+		date := c.date
+		if date == "" {
+			date = "today"
+		}
+
+		date, err = ParseDate(date, c.now)
+		if err != nil {
+			return err
+		}
+
+		tasks, err = client.Day(ctx, date)
+	}
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(tasks, func(a, b storage.Task) int {
+		if d := strings.Compare(dateOnly(a.Date), dateOnly(b.Date)); d != 0 {
+			return d
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return c.printTasks(tasks)
+}
+
+func done(ctx context.Context, c *command) error {
+	return eachID(ctx, c, func(client *Client, id int64) (string, error) {
+		task, err := client.SetStatus(ctx, id, storage.StatusDone)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("task %d %q done", id, task.Title), nil
+	})
+}
+
+func remove(ctx context.Context, c *command) error {
+	return eachID(ctx, c, func(client *Client, id int64) (string, error) {
+		if err := client.Delete(ctx, id); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("task %d moved to the trash", id), nil
+	})
+}
+
+// eachID runs fn for every ID argument, going on after a failure, and
+// reports whether any failed.
+func eachID(ctx context.Context, c *command, fn func(client *Client, id int64) (string, error)) error {
+	if len(c.args) == 0 {
+		return errors.New("expected at least one task ID")
+	}
+
+	ids := make([]int64, len(c.args))
+	for i, arg := range c.args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid task ID %q", arg)
+		}
+		ids[i] = id
+	}
+
+	client, _, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, id := range ids {
+		msg, err := fn(client, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("task %d: %w", id, err))
+			continue
+		}
+		if !c.json {
+			fmt.Fprintln(c.Out, msg)
+		}
+	}
+
+	if c.json {
+		if err := c.printJSON(map[string]any{"ids": ids, "failed": len(errs)}); err != nil {
+			return err
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *command) printTasks(tasks []storage.Task) error {
+	if c.json {
+		if tasks == nil {
+			tasks = []storage.Task{}
+		}
+		return c.printJSON(tasks)
+	}
+
+	if len(tasks) == 0 {
+		fmt.Fprintln(c.Out, "no tasks")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tSTATUS\tTITLE")
+	for _, t := range tasks {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", t.ID, dateOnly(t.Date), t.Status, t.Title)
+	}
+
+	return w.Flush()
+}
+
+func (c *command) printJSON(v any) error {
+	enc := json.NewEncoder(c.Out)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// dateOnly drops the time the API may return with a date.
+func dateOnly(date string) string {
+	if len(date) > len(time.DateOnly) {
+		return date[:len(time.DateOnly)]
+	}
+
+	return date
+}
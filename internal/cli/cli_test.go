@@ -0,0 +1,116 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/app"
+	"daytask/internal/cli"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/storage"
+)
+
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	a, err := app.New(slogdiscard.NewDiscardLogger(), &config.Config{
+		Env:         "local",
+		StoragePath: filepath.Join(t.TempDir(), "storage.db"),
+		SQLite:      config.SQLite{JournalMode: "WAL", Synchronous: "NORMAL", MaxOpenConns: 2},
+		HTTPServer: config.HTTPServer{
+			Address:         "127.0.0.1:0",
+			Timeout:         time.Second,
+			IdleTimeout:     time.Second,
+			ShutdownTimeout: 5 * time.Second,
+			User:            "alice",
+			Password:        "secret",
+		},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- a.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-runErr)
+	})
+
+	return "http://" + a.Addr().String()
+}
+
+// run runs the CLI with the given input and returns its exit code, output
+// and errors.
+func run(t *testing.T, in string, args ...string) (int, string, string) {
+	t.Helper()
+
+	var out, errOut bytes.Buffer
+	code := cli.Run(context.Background(), args, cli.IO{In: strings.NewReader(in), Out: &out, Err: &errOut})
+
+	return code, out.String(), errOut.String()
+}
+
+func TestCLI(t *testing.T) {
+	url := startServer(t)
+	configPath := filepath.Join(t.TempDir(), "daytask", "client.yaml")
+	t.Setenv("DAYTASK_CONFIG", configPath)
+
+	code, _, errOut := run(t, "wrong\n", "login", "--url", url, "--user", "alice")
+	require.Equal(t, 1, code)
+	require.Contains(t, errOut, "wrong user or password")
+	require.NoFileExists(t, configPath)
+
+	code, _, errOut = run(t, "secret\n", "login", "--url", url, "--user", "alice")
+	require.Equal(t, 0, code, errOut)
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	code, out, errOut := run(t, "", "add", "write report", "--date", "today")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, "added task 1")
+
+	code, _, errOut = run(t, "", "add", "call bob", "--date", "tomorrow")
+	require.Equal(t, 0, code, errOut)
+
+	code, out, errOut = run(t, "", "today")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, "write report")
+	require.NotContains(t, out, "call bob")
+
+	code, out, errOut = run(t, "", "done", "1")
+	require.Equal(t, 0, code, errOut)
+	require.Contains(t, out, `task 1 "write report" done`)
+
+	code, out, errOut = run(t, "", "ls", "--all", "--json")
+	require.Equal(t, 0, code, errOut)
+
+	var tasks []storage.Task
+	require.NoError(t, json.Unmarshal([]byte(out), &tasks))
+	require.Len(t, tasks, 2)
+	require.Equal(t, "write report", tasks[0].Title)
+	require.Equal(t, storage.StatusDone, tasks[0].Status)
+	require.Equal(t, "call bob", tasks[1].Title)
+
+	code, _, errOut = run(t, "", "done", "99")
+	require.Equal(t, 1, code)
+	require.Contains(t, errOut, "task 99: task not found")
+
+	code, _, _ = run(t, "", "add", "x", "--date", "someday")
+	require.Equal(t, 1, code)
+
+	code, _, _ = run(t, "", "frobnicate")
+	require.Equal(t, 2, code)
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds every API call of the client.
+const requestTimeout = 10 * time.Second
+
+// Client calls the HTTP API of a daytask server as one user.
+type Client struct {
+	base     string
+	user     string
+	password string
+	http     *http.Client
+}
+
+func NewClient(cfg Config) *Client {
+	return &Client{
+		base:     strings.TrimSuffix(cfg.URL, "/"),
+		user:     cfg.User,
+		password: cfg.Password,
+		http:     &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// NewTask is the body of /task/save.
+type NewTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Owner       string `json:"owner"`
+	Date        string `json:"date"`
+	Type        string `json:"type,omitempty"`
+}
+
+// Add saves a task and returns its ID.
+func (c *Client) Add(ctx context.Context, task NewTask) (int64, error) {
+	var resp struct {
+		response.Response
+		ID int64 `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/task/save", "application/json", task, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+// Day returns the tasks of the user on date.
+func (c *Client) Day(ctx context.Context, date string) ([]storage.Task, error) {
+	var resp struct {
+		response.Response
+		Tasks []storage.Task `json:"tasks"`
+	}
+	body := map[string]string{"owner": c.user, "date": date}
+	if err := c.do(ctx, http.MethodGet, "/task/day", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Tasks, nil
+}
+
+// All returns every task of the user.
+func (c *Client) All(ctx context.Context) ([]storage.Task, error) {
+	var resp struct {
+		response.Response
+		Tasks []storage.Task `json:"tasks"`
+	}
+	body := map[string]string{"owner": c.user}
+	if err := c.do(ctx, http.MethodGet, "/task/all", "application/json", body, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Tasks, nil
+}
+
+// SetStatus changes the status of task id and returns the task.
+func (c *Client) SetStatus(ctx context.Context, id int64, status string) (storage.Task, error) {
+	return c.Update(ctx, id, map[string]string{"status": status})
+}
+
+// Update sets the fields of task id, keyed by their JSON names, leaving the
+// others as they are, and returns the task.
+func (c *Client) Update(ctx context.Context, id int64, fields map[string]string) (storage.Task, error) {
+	var resp struct {
+		response.Response
+		Task *storage.Task `json:"task"`
+	}
+	path := "/task?id=" + url.QueryEscape(strconv.FormatInt(id, 10))
+	if err := c.do(ctx, http.MethodPatch, path, "application/merge-patch+json", fields, &resp); err != nil {
+		return storage.Task{}, err
+	}
+	if resp.Task == nil {
+		return storage.Task{}, errors.New("no task in response")
+	}
+
+	return *resp.Task, nil
+}
+
+// Delete moves task id to the trash.
+func (c *Client) Delete(ctx context.Context, id int64) error {
+	var resp response.Response
+
+	return c.do(ctx, http.MethodDelete, "/task", "application/json", map[string]int64{"id": id}, &resp)
+}
+
+// Reorder sets the order of the tasks of the user on date, ids lists all
+// of them first to last.
+func (c *Client) Reorder(ctx context.Context, date string, ids []int64) error {
+	var resp response.Response
+	body := struct {
+		Date string  `json:"date"`
+		IDs  []int64 `json:"ids"`
+	}{date, ids}
+
+	return c.do(ctx, http.MethodPost, "/task/reorder", "application/json", body, &resp)
+}
+
+// do sends body as JSON and decodes the answer into out, failing with the
+// error the server reported.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body any, out any) error {
+	if c.base == "" {
+		return errors.New("no server configured, run daytask login")
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.base+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.SetBasicAuth(c.user, c.password)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return errors.New("wrong user or password, run daytask login")
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("too many requests, retry in %ss", resp.Header.Get("Retry-After"))
+	default:
+		return fmt.Errorf("server answered %s: %s", resp.Status, message(resp.Body))
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var status response.Response
+	if err := json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	if status.Status != response.StatusOK {
+		return errors.New(status.Error)
+	}
+
+	return json.Unmarshal(raw, out)
+}
+
+// message returns the error of a failed response, or its start when it has
+// none.
+func message(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, 512))
+
+	var resp response.Response
+	if err := json.Unmarshal(raw, &resp); err == nil && resp.Error != "" {
+		return resp.Error
+	}
+
+	return strings.TrimSpace(string(raw))
+}
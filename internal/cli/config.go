@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is where the client finds the server and its credentials. It is
+// written by "daytask login" and every field can be overridden by the
+// environment variable named in its env tag.
+type Config struct {
+	URL      string `yaml:"url" env:"DAYTASK_URL"`
+	User     string `yaml:"user" env:"DAYTASK_USER"`
+	Password string `yaml:"password" env:"DAYTASK_PASSWORD"`
+}
+
+// DefaultConfigPath is client.yaml in the daytask directory of the user's
+// config directory, e.g. ~/.config/daytask/client.yaml, unless DAYTASK_CONFIG
+// is set.
+func DefaultConfigPath() (string, error) {
+	if p := os.Getenv("DAYTASK_CONFIG"); p != "" {
+		return p, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "daytask", "client.yaml"), nil
+}
+
+// LoadConfig reads the config at path, a missing file is an empty config,
+// and applies the environment overrides.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+
+	var err error
+	if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) {
+		err = cleanenv.ReadEnv(&cfg)
+	} else {
+		err = cleanenv.ReadConfig(path, &cfg)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("cannot read config %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes cfg to path, readable by the user only as it holds the
+// password.
+func (cfg Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseDate resolves s relative to now and formats it as 2006-01-02. Besides
+// such dates it understands today, tomorrow, yesterday, weekday names
+// ("fri", "next friday": the first one after today), "next week", "+3" and
+// "in 3 days" or "in 2 weeks".
+func ParseDate(s string, now time.Time) (string, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	if t, err := time.ParseInLocation(time.DateOnly, s, now.Location()); err == nil {
+		return t.Format(time.DateOnly), nil
+	}
+
+	switch s {
+	case "today", "tod":
+		return day.Format(time.DateOnly), nil
+	case "tomorrow", "tom", "tmr":
+		return day.AddDate(0, 0, 1).Format(time.DateOnly), nil
+	case "yesterday":
+		return day.AddDate(0, 0, -1).Format(time.DateOnly), nil
+	case "next week":
+		return day.AddDate(0, 0, 7).Format(time.DateOnly), nil
+	}
+
+	if wd, ok := weekdays[strings.TrimPrefix(s, "next ")]; ok {
+		days := (int(wd)-int(day.Weekday())+6)%7 + 1
+		return day.AddDate(0, 0, days).Format(time.DateOnly), nil
+	}
+
+	if n, err := strconv.Atoi(strings.TrimPrefix(s, "+")); err == nil && strings.HasPrefix(s, "+") {
+		return day.AddDate(0, 0, n).Format(time.DateOnly), nil
+	}
+
+	if rest, ok := strings.CutPrefix(s, "in "); ok {
+		if n, unit, ok := strings.Cut(rest, " "); ok {
+			count, err := strconv.Atoi(n)
+			if err == nil {
+				switch strings.TrimSuffix(unit, "s") {
+				case "day":
+					return day.AddDate(0, 0, count).Format(time.DateOnly), nil
+				case "week":
+					return day.AddDate(0, 0, 7*count).Format(time.DateOnly), nil
+				}
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unknown date %q, use 2006-01-02, today, tomorrow, a weekday or \"in 3 days\"", s)
+}
+
+// Week returns the Monday and Sunday of the week of now, formatted as
+// 2006-01-02.
+func Week(now time.Time) (from, to string) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+
+	return monday.Format(time.DateOnly), monday.AddDate(0, 0, 6).Format(time.DateOnly)
+}
@@ -0,0 +1,47 @@
+package cli_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/cli"
+)
+
+func TestParseDate(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, 5, 15, 18, 30, 0, 0, time.UTC)
+
+	cases := map[string]string{
+		"2024-06-01":  "2024-06-01",
+		"today":       "2024-05-15",
+		"Tomorrow":    "2024-05-16",
+		"yesterday":   "2024-05-14",
+		"fri":         "2024-05-17",
+		"next monday": "2024-05-20",
+		"wednesday":   "2024-05-22",
+		"next week":   "2024-05-22",
+		"+3":          "2024-05-18",
+		"in 1 day":    "2024-05-16",
+		"in  2 weeks": "2024-05-29",
+	}
+	for in, want := range cases {
+		got, err := cli.ParseDate(in, now)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "someday", "in 3 months", "2024-13-01"} {
+		_, err := cli.ParseDate(in, now)
+		require.Error(t, err, in)
+	}
+}
+
+func TestWeek(t *testing.T) {
+	for _, day := range []int{13, 15, 19} {
+		from, to := cli.Week(time.Date(2024, 5, day, 12, 0, 0, 0, time.UTC))
+		require.Equal(t, "2024-05-13", from)
+		require.Equal(t, "2024-05-19", to)
+	}
+}
@@ -0,0 +1,517 @@
+package cli
+
+import (
+	"context"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"golang.org/x/term"
+)
+
+// The view is the header, the tasks of the day and the footer. Mouse
+// positions are mapped to tasks by counting the header lines.
+const (
+	tuiHeader = 2
+	tuiFooter = 3
+)
+
+var (
+	titleStyle     = lipgloss.NewStyle().Bold(true)
+	selectedStyle  = lipgloss.NewStyle().Reverse(true)
+	doneStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	unstartedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	startedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	helpStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+const (
+	tuiHelp       = "←/→ day · t today · ↑/↓ select · J/K or drag move · a add · e edit · space done · d delete · r reload · q quit"
+	tuiHelpTyping = "enter save · esc cancel"
+)
+
+type tuiMode int
+
+const (
+	browsing tuiMode = iota
+	adding
+	editing
+)
+
+// dayMsg carries the tasks of date as loaded from the server.
+type dayMsg struct {
+	date  string
+	tasks []storage.Task
+	err   error
+}
+
+// changedMsg reports a change to a task, after which the day is reloaded.
+type changedMsg struct {
+	status string
+	err    error
+}
+
+// orderedMsg reports that the order of the day was sent.
+type orderedMsg struct {
+	err error
+}
+
+type tuiModel struct {
+	ctx    context.Context
+	client *Client
+	today  time.Time
+	day    time.Time
+
+	tasks         []storage.Task
+	cursor        int
+	offset        int
+	width, height int
+
+	mode  tuiMode
+	input textinput.Model
+
+	// saving is set while an order is sent, orderDirty when the order on
+	// screen differs from the one last sent. Orders are sent one at a time
+	// so that the server ends up with the last one.
+	saving, orderDirty bool
+	dragging           bool
+
+	status string
+	err    error
+}
+
+// NewTUI returns the full-screen view of the tasks of the user of client,
+// starting on day. now decides which day is today.
+func NewTUI(ctx context.Context, client *Client, now, day time.Time) tea.Model {
+	input := textinput.New()
+	input.CharLimit = 200
+	input.Cursor.SetMode(cursor.CursorStatic)
+
+	return &tuiModel{
+		ctx:    ctx,
+		client: client,
+		today:  midnight(now),
+		day:    midnight(day),
+		input:  input,
+	}
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func runTUI(ctx context.Context, c *command) error {
+	if len(c.args) > 0 {
+		return fmt.Errorf("unexpected argument %q", c.args[0])
+	}
+
+	date, err := ParseDate(c.date, c.now)
+	if err != nil {
+		return err
+	}
+	day, err := time.ParseInLocation(time.DateOnly, date, c.now.Location())
+	if err != nil {
+		return err
+	}
+
+	if f, ok := c.Out.(*os.File); !ok || !term.IsTerminal(int(f.Fd())) {
+		return errors.New("the output is not a terminal")
+	}
+
+	client, _, err := c.client()
+	if err != nil {
+		return err
+	}
+
+	// Fail on a missing login before taking over the screen.
+	if _, err := client.Day(ctx, date); err != nil {
+		return err
+	}
+
+	p := tea.NewProgram(NewTUI(ctx, client, c.now, day),
+		tea.WithContext(ctx),
+		tea.WithInput(c.In),
+		tea.WithOutput(c.Out),
+		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
+	)
+	if _, err := p.Run(); err != nil && ctx.Err() == nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.load()
+}
+
+func (m *tuiModel) date() string {
+	return m.day.Format(time.DateOnly)
+}
+
+func (m *tuiModel) load() tea.Cmd {
+	date := m.date()
+
+	return func() tea.Msg {
+		tasks, err := m.client.Day(m.ctx, date)
+		return dayMsg{date: date, tasks: tasks, err: err}
+	}
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.scroll()
+	case dayMsg:
+		// Ignore the answer for a day left since.
+		if msg.date != m.date() {
+			return m, nil
+		}
+		m.err = msg.err
+		if msg.err == nil {
+			m.tasks = msg.tasks
+			m.orderDirty = false
+			m.selectTask(m.cursor)
+		}
+	case changedMsg:
+		m.status, m.err = msg.status, msg.err
+		return m, m.load()
+	case orderedMsg:
+		m.saving = false
+		if msg.err != nil {
+			m.err = msg.err
+			m.orderDirty = false
+			return m, m.load()
+		}
+		if cmd := m.saveOrder(); cmd != nil {
+			return m, cmd
+		}
+		return m, m.load()
+	case tea.MouseMsg:
+		if m.mode == browsing {
+			return m, m.mouse(msg)
+		}
+	case tea.KeyMsg:
+		if m.mode != browsing {
+			return m, m.typing(msg)
+		}
+		return m, m.key(msg)
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) key(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "left", "h":
+		return m.setDay(m.day.AddDate(0, 0, -1))
+	case "right", "l":
+		return m.setDay(m.day.AddDate(0, 0, 1))
+	case "t":
+		return m.setDay(m.today)
+	case "up", "k":
+		m.selectTask(m.cursor - 1)
+	case "down", "j":
+		m.selectTask(m.cursor + 1)
+	case "K", "shift+up":
+		return m.move(-1)
+	case "J", "shift+down":
+		return m.move(1)
+	case "r":
+		return m.load()
+	case "a":
+		m.startTyping(adding, "new task: ", "")
+	case "e", "enter":
+		if task, ok := m.selected(); ok {
+			m.startTyping(editing, "title: ", task.Title)
+		}
+	case " ", "x":
+		if task, ok := m.selected(); ok {
+			return m.toggle(task)
+		}
+	case "d", "delete":
+		if task, ok := m.selected(); ok {
+			return m.remove(task)
+		}
+	}
+
+	return nil
+}
+
+func (m *tuiModel) startTyping(mode tuiMode, prompt, value string) {
+	m.mode = mode
+	m.input.Prompt = prompt
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.status, m.err = "", nil
+}
+
+func (m *tuiModel) typing(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return tea.Quit
+	case tea.KeyEsc:
+		m.mode = browsing
+		m.input.Blur()
+		return nil
+	case tea.KeyEnter:
+		mode, title := m.mode, strings.TrimSpace(m.input.Value())
+		m.mode = browsing
+		m.input.Blur()
+		if title == "" {
+			return nil
+		}
+		if mode == adding {
+			return m.add(title)
+		}
+		if task, ok := m.selected(); ok && task.Title != title {
+			return m.rename(task, title)
+		}
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+
+	return cmd
+}
+
+func (m *tuiModel) setDay(day time.Time) tea.Cmd {
+	m.day = day
+	m.tasks = nil
+	m.cursor, m.offset = 0, 0
+	m.orderDirty, m.dragging = false, false
+	m.status, m.err = "", nil
+
+	return m.load()
+}
+
+func (m *tuiModel) selected() (storage.Task, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.tasks) {
+		return storage.Task{}, false
+	}
+
+	return m.tasks[m.cursor], true
+}
+
+func (m *tuiModel) selectTask(i int) {
+	m.cursor = max(min(i, len(m.tasks)-1), 0)
+	m.scroll()
+}
+
+// rows is the number of tasks that fit on the screen.
+func (m *tuiModel) rows() int {
+	if m.height == 0 {
+		return max(len(m.tasks), 1)
+	}
+
+	return max(m.height-tuiHeader-tuiFooter, 1)
+}
+
+// scroll keeps the selected task on the screen.
+func (m *tuiModel) scroll() {
+	rows := m.rows()
+	m.offset = min(m.offset, m.cursor)
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+	m.offset = max(m.offset, 0)
+}
+
+// change runs fn in the background and reloads the day once it is done.
+func (m *tuiModel) change(fn func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		status, err := fn()
+		if err != nil {
+			status = ""
+		}
+		return changedMsg{status: status, err: err}
+	}
+}
+
+func (m *tuiModel) add(title string) tea.Cmd {
+	task := NewTask{Title: title, Owner: m.client.user, Date: m.date()}
+	// New tasks come last, select it once reloaded.
+	m.cursor = len(m.tasks)
+
+	return m.change(func() (string, error) {
+		id, err := m.client.Add(m.ctx, task)
+		return fmt.Sprintf("added task %d", id), err
+	})
+}
+
+func (m *tuiModel) rename(task storage.Task, title string) tea.Cmd {
+	return m.change(func() (string, error) {
+		_, err := m.client.Update(m.ctx, task.ID, map[string]string{"title": title})
+		return fmt.Sprintf("renamed task %d", task.ID), err
+	})
+}
+
+func (m *tuiModel) toggle(task storage.Task) tea.Cmd {
+	status := storage.StatusDone
+	if task.Status == storage.StatusDone {
+		status = "unstarted"
+	}
+
+	return m.change(func() (string, error) {
+		_, err := m.client.SetStatus(m.ctx, task.ID, status)
+		return fmt.Sprintf("task %d %s", task.ID, status), err
+	})
+}
+
+func (m *tuiModel) remove(task storage.Task) tea.Cmd {
+	return m.change(func() (string, error) {
+		err := m.client.Delete(m.ctx, task.ID)
+		return fmt.Sprintf("task %d moved to the trash", task.ID), err
+	})
+}
+
+// move swaps the selected task with the one delta rows away.
+func (m *tuiModel) move(delta int) tea.Cmd {
+	to := m.cursor + delta
+	if to < 0 || to >= len(m.tasks) {
+		return nil
+	}
+
+	m.tasks[m.cursor], m.tasks[to] = m.tasks[to], m.tasks[m.cursor]
+	m.cursor = to
+	m.orderDirty = true
+	m.scroll()
+
+	return m.saveOrder()
+}
+
+// saveOrder sends the order on screen unless a drag is under way or an
+// order is being sent, in which case it is sent once that one is done.
+func (m *tuiModel) saveOrder() tea.Cmd {
+	if m.saving || m.dragging || !m.orderDirty {
+		return nil
+	}
+	m.saving, m.orderDirty = true, false
+
+	date := m.date()
+	ids := make([]int64, len(m.tasks))
+	for i, t := range m.tasks {
+		ids[i] = t.ID
+	}
+
+	return func() tea.Msg {
+		return orderedMsg{err: m.client.Reorder(m.ctx, date, ids)}
+	}
+}
+
+// mouse selects the clicked task and drags it while the button is held.
+func (m *tuiModel) mouse(msg tea.MouseMsg) tea.Cmd {
+	row := m.offset + msg.Y - tuiHeader
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.selectTask(m.cursor - 1)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.selectTask(m.cursor + 1)
+	case msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft:
+		if msg.Y >= tuiHeader && row < len(m.tasks) {
+			m.selectTask(row)
+			m.dragging = true
+		}
+	case msg.Action == tea.MouseActionMotion && m.dragging:
+		row = max(min(row, len(m.tasks)-1), 0)
+		if row != m.cursor {
+			task := m.tasks[m.cursor]
+			m.tasks = slices.Insert(slices.Delete(m.tasks, m.cursor, m.cursor+1), row, task)
+			m.orderDirty = true
+			m.selectTask(row)
+		}
+	case msg.Action == tea.MouseActionRelease && m.dragging:
+		m.dragging = false
+		return m.saveOrder()
+	}
+
+	return nil
+}
+
+func (m *tuiModel) View() string {
+	var b strings.Builder
+
+	label := m.day.Format("Mon 2006-01-02")
+	switch {
+	case m.day.Equal(m.today):
+		label += " (today)"
+	case m.day.Equal(m.today.AddDate(0, 0, 1)):
+		label += " (tomorrow)"
+	case m.day.Equal(m.today.AddDate(0, 0, -1)):
+		label += " (yesterday)"
+	}
+	m.line(&b, titleStyle.Render("daytask")+"  "+label+"  "+helpStyle.Render(m.client.user))
+	m.line(&b, "")
+
+	if len(m.tasks) == 0 {
+		m.line(&b, helpStyle.Render("  no tasks, press a to add one"))
+	}
+	for i := m.offset; i < min(m.offset+m.rows(), len(m.tasks)); i++ {
+		m.line(&b, m.taskLine(m.tasks[i], i == m.cursor))
+	}
+
+	m.line(&b, "")
+	switch {
+	case m.mode != browsing:
+		m.line(&b, m.input.View())
+		m.line(&b, helpStyle.Render(tuiHelpTyping))
+		return b.String()
+	case m.err != nil:
+		m.line(&b, errorStyle.Render(m.err.Error()))
+	default:
+		m.line(&b, m.status)
+	}
+	m.line(&b, helpStyle.Render(tuiHelp))
+
+	return b.String()
+}
+
+// line writes s cut to the width of the screen.
+func (m *tuiModel) line(b *strings.Builder, s string) {
+	if m.width > 0 {
+		s = lipgloss.NewStyle().MaxWidth(m.width).Render(s)
+	}
+	b.WriteString(s)
+	b.WriteByte('\n')
+}
+
+func (m *tuiModel) taskLine(t storage.Task, selected bool) string {
+	status := t.Status
+	if status == "" {
+		status = "unstarted"
+	}
+
+	check, title := "[ ]", t.Title
+	style := startedStyle
+	switch status {
+	case storage.StatusDone:
+		check, style = "[x]", doneStyle
+		title = lipgloss.NewStyle().Strikethrough(true).Render(title)
+	case "unstarted":
+		style = unstartedStyle
+	}
+
+	marker := "  "
+	if selected {
+		marker = "> "
+		title = selectedStyle.Render(title)
+	}
+
+	return marker + style.Render(check) + " " + title + "  " + style.Render(status)
+}
@@ -0,0 +1,104 @@
+package cli_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/cli"
+	"daytask/internal/storage"
+)
+
+// send updates m with each msg, running the commands it returns in turn as
+// the program would.
+func send(t *testing.T, m tea.Model, msgs ...tea.Msg) tea.Model {
+	t.Helper()
+
+	for _, msg := range msgs {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		for i := 0; cmd != nil; i++ {
+			require.Less(t, i, 10, "too many commands")
+			m, cmd = m.Update(cmd())
+		}
+	}
+
+	return m
+}
+
+func keys(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestTUI(t *testing.T) {
+	ctx := context.Background()
+	client := cli.NewClient(cli.Config{URL: startServer(t), User: "alice", Password: "secret"})
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.Local)
+
+	titles := func() []string {
+		tasks, err := client.Day(ctx, "2024-05-06")
+		require.NoError(t, err)
+
+		var got []string
+		for _, task := range tasks {
+			got = append(got, task.Title)
+		}
+		return got
+	}
+
+	m := cli.NewTUI(ctx, client, day, day)
+	m = send(t, m, tea.WindowSizeMsg{Width: 100, Height: 20}, m.Init()())
+	require.Contains(t, m.View(), "Mon 2024-05-06 (today)")
+	require.Contains(t, m.View(), "no tasks")
+
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	for _, title := range []string{"first", "second", "third"} {
+		m = send(t, m, keys("a"), keys(title), enter)
+	}
+	require.Equal(t, []string{"first", "second", "third"}, titles())
+	require.Contains(t, m.View(), "> [ ] third")
+
+	// The new task is selected, move it up.
+	m = send(t, m, keys("K"))
+	require.Equal(t, []string{"first", "third", "second"}, titles())
+
+	m = send(t, m, keys(" "))
+	tasks, err := client.Day(ctx, "2024-05-06")
+	require.NoError(t, err)
+	require.Equal(t, storage.StatusDone, tasks[1].Status)
+	require.Contains(t, m.View(), "[x]")
+
+	m = send(t, m, keys("e"), tea.KeyMsg{Type: tea.KeyCtrlU}, keys("3rd"), enter)
+	require.Equal(t, []string{"first", "3rd", "second"}, titles())
+
+	// Escape leaves the title as it was.
+	m = send(t, m, keys("e"), keys(" again"), tea.KeyMsg{Type: tea.KeyEsc})
+	require.Equal(t, []string{"first", "3rd", "second"}, titles())
+
+	// Drag the first task, on the line below the header, to the last row.
+	m = send(t, m,
+		tea.MouseMsg{X: 4, Y: 2, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress},
+		tea.MouseMsg{X: 4, Y: 3, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion},
+		tea.MouseMsg{X: 4, Y: 4, Button: tea.MouseButtonLeft, Action: tea.MouseActionMotion},
+	)
+	require.Equal(t, []string{"first", "3rd", "second"}, titles(), "the order is saved on release")
+	m = send(t, m, tea.MouseMsg{X: 4, Y: 4, Button: tea.MouseButtonLeft, Action: tea.MouseActionRelease})
+	require.Equal(t, []string{"3rd", "second", "first"}, titles())
+
+	m = send(t, m, keys("d"))
+	require.Equal(t, []string{"3rd", "second"}, titles())
+	require.Contains(t, m.View(), "moved to the trash")
+
+	m = send(t, m, keys("l"))
+	require.Contains(t, m.View(), "Tue 2024-05-07 (tomorrow)")
+	require.Contains(t, m.View(), "no tasks")
+
+	m = send(t, m, keys("t"))
+	require.Contains(t, m.View(), "3rd")
+
+	_, cmd := m.Update(keys("q"))
+	require.IsType(t, tea.QuitMsg{}, cmd())
+}
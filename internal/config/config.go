@@ -1,42 +1,420 @@
 package config
 
 import (
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+// DefaultPath is used when neither --config nor CONFIG_PATH is given.
+const DefaultPath = "config/local.yaml"
+
+const redacted = "[REDACTED]"
+
+// Every field can be overridden by the environment variable named in its env
+// tag, which takes precedence over the config file.
 type Config struct {
-	Env         string `yaml:"env" env-default:"local"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
+	Env string `yaml:"env" env:"ENV" env-default:"local"`
+	// LogLevel is one of debug, info, warn or error. When empty the level
+	// follows Env: debug for local and dev, info for prod.
+	LogLevel    string `yaml:"log_level" env:"LOG_LEVEL"`
+	StoragePath string `yaml:"storage_path" env:"STORAGE_PATH" env-required:"true"`
+	SQLite      `yaml:"sqlite"`
 	HTTPServer  `yaml:"http_server"`
+	// GRPCServer is a named field so Address keeps referring to the HTTP
+	// server.
+	GRPCServer GRPCServer `yaml:"grpc_server"`
+	CORS       `yaml:"cors"`
+	RateLimit  `yaml:"rate_limit"`
+	Lockout    `yaml:"lockout"`
+	Trash      `yaml:"trash"`
+	Webhooks   `yaml:"webhooks"`
+	Stream     `yaml:"stream"`
+	Tracing    `yaml:"tracing"`
+}
+
+type SQLite struct {
+	JournalMode     string        `yaml:"journal_mode" env:"SQLITE_JOURNAL_MODE" env-default:"WAL"`
+	BusyTimeout     time.Duration `yaml:"busy_timeout" env:"SQLITE_BUSY_TIMEOUT" env-default:"5s"`
+	Synchronous     string        `yaml:"synchronous" env:"SQLITE_SYNCHRONOUS" env-default:"NORMAL"`
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"SQLITE_MAX_OPEN_CONNS" env-default:"8"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"SQLITE_MAX_IDLE_CONNS" env-default:"8"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"SQLITE_CONN_MAX_LIFETIME" env-default:"0s"`
 }
 
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	Address     string        `yaml:"address" env:"HTTP_SERVER_ADDRESS" env-default:"localhost:8080"`
+	Timeout     time.Duration `yaml:"timeout" env:"HTTP_SERVER_TIMEOUT" env-default:"4s"`
+	IdleTimeout time.Duration `yaml:"idle_timeout" env:"HTTP_SERVER_IDLE_TIMEOUT" env-default:"60s"`
+	// ShutdownTimeout is the grace period in-flight requests get to finish
+	// once the server is asked to stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SERVER_SHUTDOWN_TIMEOUT" env-default:"10s"`
+	User            string        `yaml:"user" env:"HTTP_SERVER_USER" env-required:"true"`
+	Password        string        `yaml:"password" env:"HTTP_SERVER_PASSWORD" env-required:"true"`
+	// Users are additional Basic auth accounts, username to password. In the
+	// environment they are written as "user1:pass1,user2:pass2".
+	Users map[string]string `yaml:"users" env:"HTTP_SERVER_USERS"`
+	// Roles maps the Basic auth accounts above to admin, member or read-only,
+	// accounts not listed are members. In the environment they are written
+	// as "user1:admin,user2:read-only".
+	Roles map[string]string `yaml:"roles" env:"HTTP_SERVER_ROLES"`
+	TLS   TLS               `yaml:"tls"`
+}
+
+type TLS struct {
+	Enabled bool `yaml:"enabled" env:"HTTP_SERVER_TLS_ENABLED"`
+	// CertFile and KeyFile are PEM files, reloaded whenever they change.
+	CertFile string `yaml:"cert_file" env:"HTTP_SERVER_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"HTTP_SERVER_TLS_KEY_FILE"`
+	// MinVersion is "1.2" or "1.3".
+	MinVersion string `yaml:"min_version" env:"HTTP_SERVER_TLS_MIN_VERSION" env-default:"1.2"`
+	// ClientCAFile enables mutual TLS: clients must present a certificate
+	// signed by one of the CAs in this PEM file.
+	ClientCAFile string `yaml:"client_ca_file" env:"HTTP_SERVER_TLS_CLIENT_CA_FILE"`
+	// RedirectAddress, when set, starts a plain HTTP listener there that
+	// redirects every request to HTTPS.
+	RedirectAddress string `yaml:"redirect_address" env:"HTTP_SERVER_TLS_REDIRECT_ADDRESS"`
+}
+
+// GRPCServer serves the TaskService of api/daytask/v1 next to the HTTP
+// server, with the same accounts, and with TLS when http_server.tls is
+// enabled.
+type GRPCServer struct {
+	Enabled bool   `yaml:"enabled" env:"GRPC_SERVER_ENABLED"`
+	Address string `yaml:"address" env:"GRPC_SERVER_ADDRESS" env-default:"localhost:9090"`
+}
+
+type CORS struct {
+	// AllowedOrigins lists the origins allowed to call the API from a
+	// browser, "*" allows any. Empty disables CORS.
+	AllowedOrigins []string `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+}
+
+// RateLimit budgets are token buckets kept per authenticated user and per
+// remote IP, with separate buckets for reads (GET, HEAD, OPTIONS) and writes.
+type RateLimit struct {
+	Enabled bool           `yaml:"enabled" env:"RATE_LIMIT_ENABLED" env-default:"true"`
+	User    RateLimitScope `yaml:"user" env-prefix:"RATE_LIMIT_USER_"`
+	IP      RateLimitScope `yaml:"ip" env-prefix:"RATE_LIMIT_IP_"`
+}
+
+type RateLimitScope struct {
+	Read  Limit `yaml:"read" env-prefix:"READ_"`
+	Write Limit `yaml:"write" env-prefix:"WRITE_"`
+}
+
+type Limit struct {
+	// Rate is the sustained number of requests per second, zero means
+	// unlimited.
+	Rate float64 `yaml:"rate" env:"RATE"`
+	// Burst is the number of requests allowed at once.
+	Burst int `yaml:"burst" env:"BURST"`
+}
+
+// Lockout slows down and then blocks password guessing. Failed logins are
+// counted per username and per remote IP, each failure delays the response
+// and too many failures within Window lock the username or IP for Duration.
+type Lockout struct {
+	Enabled     bool `yaml:"enabled" env:"LOCKOUT_ENABLED" env-default:"true"`
+	MaxFailures int  `yaml:"max_failures" env:"LOCKOUT_MAX_FAILURES" env-default:"5"`
+	// MaxIPFailures is higher than MaxFailures as clients behind a NAT share
+	// an address.
+	MaxIPFailures int           `yaml:"max_ip_failures" env:"LOCKOUT_MAX_IP_FAILURES" env-default:"20"`
+	Window        time.Duration `yaml:"window" env:"LOCKOUT_WINDOW" env-default:"15m"`
+	Duration      time.Duration `yaml:"duration" env:"LOCKOUT_DURATION" env-default:"15m"`
+	// BaseDelay is the delay after the first failure, doubled with every
+	// further failure up to MaxDelay.
+	BaseDelay time.Duration `yaml:"base_delay" env:"LOCKOUT_BASE_DELAY" env-default:"250ms"`
+	MaxDelay  time.Duration `yaml:"max_delay" env:"LOCKOUT_MAX_DELAY" env-default:"4s"`
+}
+
+// Trash keeps deleted tasks restorable for Retention, after which they are
+// deleted for good. Expired tasks are looked for every PurgeInterval.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env:"TRASH_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"TRASH_PURGE_INTERVAL" env-default:"1h"`
+}
+
+// Webhooks sends the queued task events to the registered webhooks. Due
+// deliveries are looked for every PollInterval and a failed delivery is
+// retried after BaseDelay, doubled with every further failure up to MaxDelay,
+// until it failed MaxAttempts times. Webhooks may only target public
+// addresses unless AllowPrivate is set, which lets them reach loopback,
+// link-local and private networks, e.g. to test a receiver locally.
+type Webhooks struct {
+	Enabled      bool          `yaml:"enabled" env:"WEBHOOKS_ENABLED" env-default:"true"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" env-default:"2s"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"5s"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	BaseDelay    time.Duration `yaml:"base_delay" env:"WEBHOOKS_BASE_DELAY" env-default:"10s"`
+	MaxDelay     time.Duration `yaml:"max_delay" env:"WEBHOOKS_MAX_DELAY" env-default:"1h"`
+	AllowPrivate bool          `yaml:"allow_private" env:"WEBHOOKS_ALLOW_PRIVATE"`
+}
+
+// Stream pushes task changes to live clients. The last BufferSize events
+// are kept for at most Retention so a client that reconnects with
+// Last-Event-ID gets those it missed. Heartbeat keeps idle streams open
+// through proxies.
+type Stream struct {
+	BufferSize int           `yaml:"buffer_size" env:"STREAM_BUFFER_SIZE" env-default:"1000"`
+	Retention  time.Duration `yaml:"retention" env:"STREAM_RETENTION" env-default:"5m"`
+	Heartbeat  time.Duration `yaml:"heartbeat" env:"STREAM_HEARTBEAT" env-default:"15s"`
+}
+
+type Tracing struct {
+	// Exporter is one of "none", "stdout", "file" or "otlp".
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	// Endpoint is the OTLP/gRPC collector address, used by the "otlp" exporter.
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"localhost:4317"`
+	Insecure bool   `yaml:"insecure" env:"TRACING_INSECURE" env-default:"true"`
+	// File receives the spans of the "file" exporter, one JSON object per span.
+	File        string  `yaml:"file" env:"TRACING_FILE" env-default:"./traces.json"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"daytask"`
+}
+
+// Load reads the config file chosen by ResolvePath, applies the environment
+// overrides and validates the result.
+func Load(args []string) (*Config, error) {
+	configPath, err := ResolvePath(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadFile(configPath)
 }
 
-func MustLoad() *Config {
-	configPath := "config/local.yaml" //os.Getenv("CONFIG_PATH") //получаем из переменной окружения
-	if configPath == "" {
-		log.Fatal("CONFIG_FILE is not set")
+// ResolvePath returns the config file to read: the --config flag if present
+// in args, otherwise CONFIG_PATH, otherwise DefaultPath.
+func ResolvePath(args []string) (string, error) {
+	fs := flag.NewFlagSet("daytask", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", "", "path to the config file")
+
+	if err := fs.Parse(args); err != nil {
+		return "", fmt.Errorf("cannot parse flags: %w", err)
+	}
+
+	if *configPath != "" {
+		return *configPath, nil
 	}
 
+	if p := os.Getenv("CONFIG_PATH"); p != "" {
+		return p, nil
+	}
+
+	return DefaultPath, nil
+}
+
+func LoadFile(configPath string) (*Config, error) {
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		log.Fatalf("config file %s does not exist", configPath)
+		return nil, fmt.Errorf("config file %s does not exist", configPath)
 	}
 
 	var cfg Config
 
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		log.Fatalf("cannot read config: %s", err)
+		return nil, fmt.Errorf("cannot read config %s: %w", configPath, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", configPath, err)
+	}
+
+	return &cfg, nil
+}
+
+// Validate reports every invalid value in c, not just the first one.
+func (c *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(oneOf(c.Env, EnvLocal, EnvDev, EnvProd), "env: must be one of local, dev, prod, got %q", c.Env)
+	check(c.LogLevel == "" || oneOf(strings.ToLower(c.LogLevel), "debug", "info", "warn", "error"),
+		"log_level: must be one of debug, info, warn, error, got %q", c.LogLevel)
+	check(c.StoragePath != "", "storage_path: must not be empty")
+
+	check(oneOf(strings.ToUpper(c.SQLite.JournalMode), "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"),
+		"sqlite.journal_mode: unknown mode %q", c.SQLite.JournalMode)
+	check(oneOf(strings.ToUpper(c.SQLite.Synchronous), "OFF", "NORMAL", "FULL", "EXTRA"),
+		"sqlite.synchronous: unknown mode %q", c.SQLite.Synchronous)
+	check(c.SQLite.BusyTimeout >= 0, "sqlite.busy_timeout: must not be negative")
+	check(c.SQLite.MaxOpenConns >= 0, "sqlite.max_open_conns: must not be negative")
+	check(c.SQLite.MaxIdleConns >= 0, "sqlite.max_idle_conns: must not be negative")
+	check(c.SQLite.ConnMaxLifetime >= 0, "sqlite.conn_max_lifetime: must not be negative")
+
+	_, _, err := net.SplitHostPort(c.HTTPServer.Address)
+	check(err == nil, "http_server.address: %v", err)
+	check(c.HTTPServer.Timeout > 0, "http_server.timeout: must be positive")
+	check(c.HTTPServer.IdleTimeout > 0, "http_server.idle_timeout: must be positive")
+	check(c.HTTPServer.ShutdownTimeout > 0, "http_server.shutdown_timeout: must be positive")
+	check(c.HTTPServer.User != "", "http_server.user: must not be empty")
+	check(c.HTTPServer.Password != "", "http_server.password: must not be empty")
+	for user, password := range c.HTTPServer.Users {
+		check(user != "" && password != "", "http_server.users: empty username or password")
+	}
+	for user, role := range c.HTTPServer.Roles {
+		check(oneOf(role, "admin", "member", "read-only"),
+			"http_server.roles.%s: must be one of admin, member, read-only, got %q", user, role)
+	}
+
+	if tls := c.HTTPServer.TLS; tls.Enabled {
+		check(tls.CertFile != "" && tls.KeyFile != "", "http_server.tls: cert_file and key_file are required")
+		check(oneOf(tls.MinVersion, "1.2", "1.3"), "http_server.tls.min_version: must be 1.2 or 1.3, got %q", tls.MinVersion)
+		if tls.RedirectAddress != "" {
+			_, _, err := net.SplitHostPort(tls.RedirectAddress)
+			check(err == nil, "http_server.tls.redirect_address: %v", err)
+		}
+	}
+
+	if c.GRPCServer.Enabled {
+		_, _, err := net.SplitHostPort(c.GRPCServer.Address)
+		check(err == nil, "grpc_server.address: %v", err)
+	}
+
+	for name, l := range map[string]Limit{
+		"user.read": c.RateLimit.User.Read, "user.write": c.RateLimit.User.Write,
+		"ip.read": c.RateLimit.IP.Read, "ip.write": c.RateLimit.IP.Write,
+	} {
+		check(l.Rate >= 0, "rate_limit.%s.rate: must not be negative", name)
+		check(l.Rate == 0 || l.Burst > 0, "rate_limit.%s.burst: must be positive", name)
+	}
+
+	if l := c.Lockout; l.Enabled {
+		check(l.MaxFailures > 0, "lockout.max_failures: must be positive")
+		check(l.MaxIPFailures > 0, "lockout.max_ip_failures: must be positive")
+		check(l.Window > 0, "lockout.window: must be positive")
+		check(l.Duration > 0, "lockout.duration: must be positive")
+		check(l.BaseDelay >= 0 && l.MaxDelay >= l.BaseDelay, "lockout.max_delay: must not be below base_delay")
+	}
+
+	check(c.Trash.Retention > 0, "trash.retention: must be positive")
+	check(c.Trash.PurgeInterval > 0, "trash.purge_interval: must be positive")
+
+	if w := c.Webhooks; w.Enabled {
+		check(w.PollInterval > 0, "webhooks.poll_interval: must be positive")
+		check(w.Timeout > 0, "webhooks.timeout: must be positive")
+		check(w.MaxAttempts > 0, "webhooks.max_attempts: must be positive")
+		check(w.BaseDelay > 0 && w.MaxDelay >= w.BaseDelay, "webhooks.max_delay: must not be below base_delay")
+	}
+
+	check(c.Stream.BufferSize >= 0, "stream.buffer_size: must not be negative")
+	check(c.Stream.Retention >= 0, "stream.retention: must not be negative")
+	check(c.Stream.Heartbeat >= 0, "stream.heartbeat: must not be negative")
+
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "file", "otlp"),
+		"tracing.exporter: must be one of none, stdout, file, otlp, got %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "otlp" || c.Tracing.Endpoint != "", "tracing.endpoint: required by the otlp exporter")
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file: required by the file exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be within [0, 1]")
+
+	return errors.Join(errs...)
+}
+
+// Level returns the log level set by LogLevel, or the default for Env.
+func (c *Config) Level() slog.Level {
+	switch strings.ToLower(c.LogLevel) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	if c.Env == EnvProd {
+		return slog.LevelInfo
+	}
+
+	return slog.LevelDebug
+}
+
+// Credentials returns every Basic auth account: User and the extra Users.
+func (h HTTPServer) Credentials() map[string]string {
+	creds := make(map[string]string, len(h.Users)+1)
+	for user, password := range h.Users {
+		creds[user] = password
+	}
+	creds[h.User] = h.Password
+
+	return creds
+}
+
+// RestartRequired lists the settings that differ between c and next but
+// only take effect after a restart, e.g. because they are bound once at
+// startup like the listen address and the database.
+func (c *Config) RestartRequired(next *Config) []string {
+	var changed []string
+
+	diff := func(name string, differs bool) {
+		if differs {
+			changed = append(changed, name)
+		}
+	}
+
+	diff("env", c.Env != next.Env)
+	diff("storage_path", c.StoragePath != next.StoragePath)
+	diff("sqlite", c.SQLite != next.SQLite)
+	diff("http_server.address", c.HTTPServer.Address != next.HTTPServer.Address)
+	diff("http_server.idle_timeout", c.HTTPServer.IdleTimeout != next.HTTPServer.IdleTimeout)
+	diff("http_server.shutdown_timeout", c.HTTPServer.ShutdownTimeout != next.HTTPServer.ShutdownTimeout)
+	diff("http_server.tls", c.HTTPServer.TLS != next.HTTPServer.TLS)
+	diff("grpc_server", c.GRPCServer != next.GRPCServer)
+	diff("trash", c.Trash != next.Trash)
+	diff("webhooks", c.Webhooks != next.Webhooks)
+	diff("stream", c.Stream != next.Stream)
+	diff("tracing", c.Tracing != next.Tracing)
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// Redacted returns a copy of c with secrets masked, safe to print or log.
+func (c Config) Redacted() Config {
+	if c.HTTPServer.Password != "" {
+		c.HTTPServer.Password = redacted
+	}
+
+	if len(c.HTTPServer.Users) > 0 {
+		users := make(map[string]string, len(c.HTTPServer.Users))
+		for user := range c.HTTPServer.Users {
+			users[user] = redacted
+		}
+		c.HTTPServer.Users = users
+	}
+
+	return c
+}
+
+func oneOf(v string, allowed ...string) bool {
+	for _, a := range allowed {
+		if v == a {
+			return true
+		}
 	}
 
-	return &cfg
+	return false
 }
@@ -0,0 +1,100 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+)
+
+const validConfig = `
+env: "local"
+storage_path: "./storage/storage.db"
+http_server:
+  address: "localhost:8082"
+  user: "test_user"
+  password: "pass"
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+
+	return p
+}
+
+func TestResolvePath(t *testing.T) {
+	t.Setenv("CONFIG_PATH", "")
+
+	p, err := config.ResolvePath(nil)
+	require.NoError(t, err)
+	require.Equal(t, config.DefaultPath, p)
+
+	t.Setenv("CONFIG_PATH", "from/env.yaml")
+
+	p, err = config.ResolvePath(nil)
+	require.NoError(t, err)
+	require.Equal(t, "from/env.yaml", p)
+
+	p, err = config.ResolvePath([]string{"--config", "from/flag.yaml"})
+	require.NoError(t, err)
+	require.Equal(t, "from/flag.yaml", p)
+
+	_, err = config.ResolvePath([]string{"--unknown"})
+	require.Error(t, err)
+}
+
+func TestLoadFile(t *testing.T) {
+	cfg, err := config.LoadFile(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	require.Equal(t, "localhost:8082", cfg.Address)
+	require.Equal(t, 4*time.Second, cfg.HTTPServer.Timeout)
+	require.Equal(t, "WAL", cfg.SQLite.JournalMode)
+	require.Equal(t, "[REDACTED]", cfg.Redacted().Password)
+	require.Equal(t, "pass", cfg.Password)
+}
+
+func TestLoadFileEnvOverride(t *testing.T) {
+	t.Setenv("HTTP_SERVER_ADDRESS", "0.0.0.0:9000")
+	t.Setenv("SQLITE_MAX_OPEN_CONNS", "3")
+
+	cfg, err := config.LoadFile(writeConfig(t, validConfig))
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0:9000", cfg.Address)
+	require.Equal(t, 3, cfg.SQLite.MaxOpenConns)
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		env    string
+		value  string
+		errMsg string
+	}{
+		{name: "unknown env", env: "ENV", value: "staging", errMsg: "env: must be one of"},
+		{name: "bad address", env: "HTTP_SERVER_ADDRESS", value: "localhost", errMsg: "http_server.address"},
+		{name: "bad journal mode", env: "SQLITE_JOURNAL_MODE", value: "fast", errMsg: "sqlite.journal_mode"},
+		{name: "bad exporter", env: "TRACING_EXPORTER", value: "jaeger", errMsg: "tracing.exporter"},
+		{name: "bad sample ratio", env: "TRACING_SAMPLE_RATIO", value: "2", errMsg: "tracing.sample_ratio"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(tc.env, tc.value)
+
+			_, err := config.LoadFile(writeConfig(t, validConfig))
+			require.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	_, err := config.LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.ErrorContains(t, err, "does not exist")
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"daytask/internal/lib/logger/sl"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce coalesces the burst of events editors produce when saving.
+const debounce = 250 * time.Millisecond
+
+// Watcher reloads the config file whenever it changes on disk or the process
+// receives SIGHUP, and passes every valid result to its callback. Invalid
+// files are logged and otherwise ignored, so the last good config stays in
+// effect.
+type Watcher struct {
+	log      *slog.Logger
+	path     string
+	onChange func(*Config)
+}
+
+func NewWatcher(log *slog.Logger, path string, onChange func(*Config)) *Watcher {
+	return &Watcher{
+		log:      log.With(slog.String("component", "config/watcher"), slog.String("path", path)),
+		path:     path,
+		onChange: onChange,
+	}
+}
+
+// Run watches until ctx is cancelled.
+func (w *Watcher) Run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// The directory is watched rather than the file itself: editors and
+	// config management tools usually replace the file with a rename, which
+	// would silently end a watch on the old inode.
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.log.Error("cannot watch config file, only SIGHUP reloads it", sl.Err(err))
+	} else {
+		defer fsw.Close()
+		if err := fsw.Add(filepath.Dir(w.path)); err != nil {
+			w.log.Error("cannot watch config file, only SIGHUP reloads it", sl.Err(err))
+		}
+	}
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if fsw != nil {
+		events, errs = fsw.Events, fsw.Errors
+	}
+
+	name := filepath.Clean(w.path)
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-hup:
+			w.log.Info("SIGHUP received")
+			w.reload()
+		case ev := <-events:
+			if filepath.Clean(ev.Name) != name || ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			w.reload()
+		case err := <-errs:
+			w.log.Warn("config watch error", sl.Err(err))
+		}
+	}
+}
+
+func (w *Watcher) reload() {
+	cfg, err := LoadFile(w.path)
+	if err != nil {
+		w.log.Error("config reload rejected, keeping the current config", sl.Err(err))
+		return
+	}
+
+	w.log.Info("config reloaded")
+	w.onChange(cfg)
+}
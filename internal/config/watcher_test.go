@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestWatcher(t *testing.T) {
+	path := writeConfig(t, validConfig)
+
+	reloaded := make(chan *config.Config, 1)
+	w := config.NewWatcher(slogdiscard.NewDiscardLogger(), path, func(cfg *config.Config) {
+		reloaded <- cfg
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	// Give the watcher time to subscribe before touching the file.
+	time.Sleep(100 * time.Millisecond)
+
+	// An invalid file is rejected and never reaches the callback.
+	require.NoError(t, os.WriteFile(path, []byte(validConfig+"env: staging\n"), 0o600))
+	select {
+	case <-reloaded:
+		t.Fatal("invalid config was applied")
+	case <-time.After(time.Second):
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte(validConfig+"log_level: warn\n"), 0o600))
+	select {
+	case cfg := <-reloaded:
+		require.Equal(t, "warn", cfg.LogLevel)
+	case <-time.After(5 * time.Second):
+		t.Fatal("config was not reloaded")
+	}
+}
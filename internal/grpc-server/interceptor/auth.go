@@ -0,0 +1,119 @@
+package interceptor
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/login"
+	"daytask/internal/storage"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Auth returns interceptors that authenticate every call like the Basic auth
+// middleware of the HTTP server: the "authorization" metadata carries
+// "Basic " and the base64 of user:password. The authenticated user is stored
+// in the context, see auth.User and auth.Role.
+func Auth(log *slog.Logger, a auth.Authenticator) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	log = log.With(
+		slog.String("component", "interceptor/auth"),
+	)
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, log, a)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), log, a)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+
+	return unary, stream
+}
+
+func authenticate(ctx context.Context, log *slog.Logger, a auth.Authenticator) (context.Context, error) {
+	user, password, ok := basicAuth(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	var addr, ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		addr, ip = p.Addr.String(), p.Addr.String()
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			ip = host
+		}
+	}
+
+	account, err := a.Authenticate(ctx, user, password, ip)
+
+	var locked *login.LockedError
+	switch {
+	case err == nil:
+	case errors.As(err, &locked):
+		retry := time.Until(locked.Until).Round(time.Second)
+		return nil, status.Errorf(codes.ResourceExhausted, "too many failed logins, retry in %s", max(retry, time.Second))
+	case errors.Is(err, storage.ErrWrongPassword):
+		return nil, status.Error(codes.Unauthenticated, "wrong credentials")
+	case errors.Is(err, storage.ErrUserDisabled):
+		return nil, status.Error(codes.PermissionDenied, "account disabled")
+	default:
+		log.ErrorContext(ctx, "failed to authenticate", sl.Err(err))
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	ctx = auth.WithUser(ctx, account)
+
+	return storage.WithRemoteAddr(ctx, addr), nil
+}
+
+// basicAuth parses the Basic credentials of the "authorization" metadata.
+func basicAuth(ctx context.Context) (user, password string, ok bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return "", "", false
+	}
+
+	const prefix = "basic "
+	if len(values[0]) < len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(values[0][len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+
+	return strings.Cut(string(decoded), ":")
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,90 @@
+package interceptor
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Logger returns interceptors that log every completed call, the gRPC
+// counterpart of the request log of the HTTP server. They must run after
+// Auth to log the user.
+func Logger(log *slog.Logger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	log = log.With(
+		slog.String("component", "interceptor/logger"),
+	)
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		t1 := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, log, info.FullMethod, t1, err)
+
+		return resp, err
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		t1 := time.Now()
+		err := handler(srv, ss)
+		logCall(ss.Context(), log, info.FullMethod, t1, err)
+
+		return err
+	}
+
+	return unary, stream
+}
+
+func logCall(ctx context.Context, log *slog.Logger, method string, t1 time.Time, err error) {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+
+	log.InfoContext(ctx, "call completed",
+		slog.String("method", method),
+		slog.String("remote_addr", addr),
+		slog.String("user", auth.User(ctx)),
+		slog.String("code", status.Code(err).String()),
+		slog.String("duration", time.Since(t1).String()),
+	)
+}
+
+// Recoverer returns interceptors that answer a panicking call with
+// codes.Internal instead of crashing the server, like chi's
+// middleware.Recoverer.
+func Recoverer(log *slog.Logger) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	log = log.With(
+		slog.String("component", "interceptor/recoverer"),
+	)
+
+	recovered := func(ctx context.Context, method string, err *error) {
+		if rvr := recover(); rvr != nil {
+			log.ErrorContext(ctx, "panic",
+				slog.String("method", method),
+				slog.Any("panic", rvr),
+				slog.String("stack", string(debug.Stack())),
+			)
+			*err = status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	unary := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (_ any, err error) {
+		defer recovered(ctx, info.FullMethod, &err)
+
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer recovered(ss.Context(), info.FullMethod, &err)
+
+		return handler(srv, ss)
+	}
+
+	return unary, stream
+}
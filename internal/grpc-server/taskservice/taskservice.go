@@ -0,0 +1,366 @@
+// Package taskservice serves the TaskService of api/daytask/v1 from the same
+// storage as the /task HTTP endpoints.
+package taskservice
+
+import (
+	"context"
+	daytaskv1 "daytask/api/daytask/v1"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
+	"daytask/internal/lib/events"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type TaskStore interface {
+	SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error)
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetTaskForDay(ctx context.Context, taskOwner string, taskDate string) ([]storage.Task, error)
+	GetAllTasks(ctx context.Context, taskOwner string) ([]storage.Task, error)
+	PatchTask(ctx context.Context, taskID int64, patch storage.TaskPatch) (storage.Task, error)
+	DeleteTask(ctx context.Context, id int64) error
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+}
+
+type Subscriber interface {
+	Subscribe(lastID uint64) ([]events.Event, *events.Subscription, bool)
+}
+
+// writers are the roles allowed to change tasks, as on the HTTP server.
+var writers = []string{storage.RoleAdmin, storage.RoleMember}
+
+type Server struct {
+	daytaskv1.UnimplementedTaskServiceServer
+
+	log   *slog.Logger
+	store TaskStore
+	sub   Subscriber
+}
+
+func New(log *slog.Logger, store TaskStore, sub Subscriber) *Server {
+	return &Server{log: log, store: store, sub: sub}
+}
+
+func (s *Server) Create(ctx context.Context, req *daytaskv1.CreateRequest) (*daytaskv1.CreateResponse, error) {
+	const op = "grpc.taskservice.Create"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := requireWriter(ctx); err != nil {
+		return nil, err
+	}
+	if !validDate(req.GetDate()) {
+		return nil, status.Error(codes.InvalidArgument, "field Date is not valid")
+	}
+
+	owner := ownerOrCaller(ctx, req.GetOwner())
+	taskStatus := defaultString(req.GetStatus(), "unstarted")
+	taskType := defaultString(req.GetType(), "ordinary")
+
+	id, err := s.store.SaveTask(ctx, req.GetTitle(), req.GetDescription(), owner, req.GetDate(), taskStatus, taskType)
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, status.Error(codes.InvalidArgument, "incorrect date")
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to save task", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to save task")
+	}
+
+	task, err := s.store.GetTask(ctx, id)
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get task", sl.Err(err), slog.Int64("id", id))
+		return nil, status.Error(codes.Internal, "failed to save task")
+	}
+
+	log.InfoContext(ctx, "task added", slog.Int64("id", id))
+
+	return &daytaskv1.CreateResponse{Task: toProto(task)}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *daytaskv1.GetRequest) (*daytaskv1.GetResponse, error) {
+	const op = "grpc.taskservice.Get"
+
+	log := s.log.With(slog.String("op", op))
+
+	task, err := s.store.GetTask(ctx, req.GetId())
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to get task")
+	}
+
+	// Tasks the caller may not see are as good as missing.
+	ok, err := access.CanSee(ctx, s.store, task, auth.User(ctx))
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get workspace", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to get task")
+	}
+	if !ok {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+
+	return &daytaskv1.GetResponse{Task: toProto(task)}, nil
+}
+
+func (s *Server) ListByDay(ctx context.Context, req *daytaskv1.ListByDayRequest) (*daytaskv1.ListByDayResponse, error) {
+	const op = "grpc.taskservice.ListByDay"
+
+	log := s.log.With(slog.String("op", op))
+
+	if !validDate(req.GetDate()) {
+		return nil, status.Error(codes.InvalidArgument, "field Date is not valid")
+	}
+
+	tasks, err := s.store.GetTaskForDay(ctx, ownerOrCaller(ctx, req.GetOwner()), req.GetDate())
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, status.Error(codes.InvalidArgument, "incorrect date")
+	}
+	if err == nil {
+		tasks, err = access.Visible(ctx, s.store, tasks, auth.User(ctx))
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to get task")
+	}
+
+	return &daytaskv1.ListByDayResponse{Tasks: toProtos(tasks)}, nil
+}
+
+func (s *Server) ListAll(ctx context.Context, req *daytaskv1.ListAllRequest) (*daytaskv1.ListAllResponse, error) {
+	const op = "grpc.taskservice.ListAll"
+
+	log := s.log.With(slog.String("op", op))
+
+	tasks, err := s.store.GetAllTasks(ctx, ownerOrCaller(ctx, req.GetOwner()))
+	if err == nil {
+		tasks, err = access.Visible(ctx, s.store, tasks, auth.User(ctx))
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to get all tasks", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to get all tasks")
+	}
+
+	return &daytaskv1.ListAllResponse{Tasks: toProtos(tasks)}, nil
+}
+
+func (s *Server) Update(ctx context.Context, req *daytaskv1.UpdateRequest) (*daytaskv1.UpdateResponse, error) {
+	const op = "grpc.taskservice.Update"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := requireWriter(ctx); err != nil {
+		return nil, err
+	}
+	if req.Date != nil && !validDate(req.GetDate()) {
+		return nil, status.Error(codes.InvalidArgument, "field Date is not valid")
+	}
+
+	task, err := s.changeable(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	if req.Owner != nil && req.GetOwner() != task.Owner && !access.CanChangeOwner(auth.Role(ctx)) {
+		return nil, status.Error(codes.PermissionDenied, "only admins may change the owner")
+	}
+
+	task, err = s.store.PatchTask(ctx, task.ID, storage.TaskPatch{
+		Title:       req.Title,
+		Description: req.Description,
+		Owner:       req.Owner,
+		Date:        req.Date,
+		Status:      req.Status,
+		Type:        req.Type,
+	})
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		return nil, status.Error(codes.NotFound, "task not found")
+	}
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, status.Error(codes.InvalidArgument, "incorrect date")
+	}
+	if err != nil {
+		log.ErrorContext(ctx, "failed to update task", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to update task")
+	}
+
+	log.InfoContext(ctx, "task update", slog.Int64("id", task.ID))
+
+	return &daytaskv1.UpdateResponse{Task: toProto(task)}, nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *daytaskv1.DeleteRequest) (*daytaskv1.DeleteResponse, error) {
+	const op = "grpc.taskservice.Delete"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := requireWriter(ctx); err != nil {
+		return nil, err
+	}
+
+	task, err := s.changeable(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.DeleteTask(ctx, task.ID); err != nil {
+		log.ErrorContext(ctx, "failed to delete task", sl.Err(err))
+		return nil, status.Error(codes.Internal, "failed to delete task")
+	}
+
+	log.InfoContext(ctx, "task deleted", slog.Int64("id", task.ID))
+
+	return &daytaskv1.DeleteResponse{}, nil
+}
+
+// changeable returns the personal task id if the caller may change it, see
+// access.CanChange. Workspace tasks are changed through the workspace
+// endpoints, they and the tasks the caller may not change are not found.
+func (s *Server) changeable(ctx context.Context, id int64) (storage.Task, error) {
+	task, err := s.store.GetTask(ctx, id)
+	if errors.Is(err, storage.ErrTaskNotFound) || err == nil && (task.WorkspaceID != nil || !access.CanChange(task, auth.User(ctx))) {
+		return storage.Task{}, status.Error(codes.NotFound, "task not found")
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to get task", sl.Err(err), slog.Int64("id", id))
+		return storage.Task{}, status.Error(codes.Internal, "failed to get task")
+	}
+
+	return task, nil
+}
+
+// Watch streams the events of the tasks the caller owns or is assigned to
+// until the client cancels or the bus drops it, after which the client
+// resumes from the last event it got.
+func (s *Server) Watch(req *daytaskv1.WatchRequest, stream daytaskv1.TaskService_WatchServer) error {
+	const op = "grpc.taskservice.Watch"
+
+	ctx := stream.Context()
+	log := s.log.With(slog.String("op", op))
+	user := auth.User(ctx)
+
+	replay, subscription, ok := s.sub.Subscribe(req.GetLastEventId())
+	defer subscription.Close()
+
+	// Headers tell the client it is subscribed before any event is sent.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	if !ok {
+		if err := stream.Send(&daytaskv1.WatchResponse{Reset_: true}); err != nil {
+			return err
+		}
+	}
+	for _, e := range replay {
+		if err := send(stream, user, e); err != nil {
+			return err
+		}
+	}
+
+	log.DebugContext(ctx, "watch started", slog.Uint64("last_event_id", req.GetLastEventId()))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case e, open := <-subscription.C():
+			if !open {
+				// Too far behind or shutting down.
+				return status.Error(codes.Unavailable, "watch ended, resume from the last event")
+			}
+			if err := send(stream, user, e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// send streams e if it is about a task user owns or is assigned to.
+func send(stream daytaskv1.TaskService_WatchServer, user string, e events.Event) error {
+	if e.Task.Owner != user && !slices.Contains(e.Task.Assignees, user) {
+		return nil
+	}
+
+	changes := make([]*daytaskv1.Change, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = &daytaskv1.Change{Field: c.Field, From: c.From, To: c.To}
+	}
+
+	return stream.Send(&daytaskv1.WatchResponse{
+		Id:      e.ID,
+		Type:    e.Type,
+		At:      timestamppb.New(e.At),
+		Task:    toProto(e.Task),
+		Changes: changes,
+	})
+}
+
+func requireWriter(ctx context.Context) error {
+	if !slices.Contains(writers, auth.Role(ctx)) {
+		return status.Error(codes.PermissionDenied, "forbidden")
+	}
+
+	return nil
+}
+
+// validDate reports whether date is formatted as 2006-01-02, like the
+// datetime validation of the HTTP requests.
+func validDate(date string) bool {
+	_, err := time.Parse(time.DateOnly, date)
+	return err == nil
+}
+
+func ownerOrCaller(ctx context.Context, owner string) string {
+	if owner == "" {
+		return auth.User(ctx)
+	}
+
+	return owner
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+
+	return s
+}
+
+func toProto(t storage.Task) *daytaskv1.Task {
+	date := t.Date
+	// Stored dates may carry a time, the API takes and returns dates only.
+	if len(date) > len(time.DateOnly) {
+		date = date[:len(time.DateOnly)]
+	}
+
+	return &daytaskv1.Task{
+		Id:          t.ID,
+		Title:       t.Title,
+		Description: t.Description,
+		Owner:       t.Owner,
+		Date:        date,
+		Status:      t.Status,
+		Type:        t.Type,
+		WorkspaceId: t.WorkspaceID,
+		Assignees:   t.Assignees,
+		ServerRev:   t.ServerRev,
+	}
+}
+
+func toProtos(tasks []storage.Task) []*daytaskv1.Task {
+	out := make([]*daytaskv1.Task, len(tasks))
+	for i, t := range tasks {
+		out[i] = toProto(t)
+	}
+
+	return out
+}
@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// Request filters the audit log, empty fields match every entry.
+type Request struct {
+	Actor    string    `json:"actor,omitempty"`
+	Action   string    `json:"action,omitempty" validate:"omitempty,oneof=create update delete"`
+	Entity   string    `json:"entity,omitempty"`
+	EntityID string    `json:"entity_id,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+	AfterID  int64     `json:"after_id,omitempty" validate:"omitempty,min=1"`
+	Limit    int       `json:"limit,omitempty" validate:"omitempty,min=1,max=1000"`
+	// Format "ndjson" exports every matching entry, one JSON object per
+	// line, ignoring Limit.
+	Format string `json:"format,omitempty" validate:"omitempty,oneof=json ndjson"`
+}
+
+type Response struct {
+	response.Response
+	Entries []storage.AuditEntry `json:"entries"`
+}
+
+type AuditReader interface {
+	AuditLog(ctx context.Context, filter storage.AuditFilter, fn func(storage.AuditEntry) error) error
+}
+
+// Query audit log
+// @Summary      Query audit log
+// @Description  Lists the recorded creates, updates and deletes oldest first, at most limit (100 by default) after after_id. With format "ndjson" every matching entry is streamed as newline delimited JSON.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Produce      application/x-ndjson
+// @Param        request  body  Request  false  "filter"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /admin/audit [get]
+func New(log *slog.Logger, reader AuditReader) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.audit.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if r.ContentLength != 0 {
+			if err := render.DecodeJSON(r.Body, &req); err != nil {
+				log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		filter := storage.AuditFilter{
+			Actor:    req.Actor,
+			Action:   req.Action,
+			Entity:   req.Entity,
+			EntityID: req.EntityID,
+			Since:    req.Since,
+			Until:    req.Until,
+			AfterID:  req.AfterID,
+			Limit:    req.Limit,
+		}
+
+		if req.Format == "ndjson" {
+			export(w, r, log, reader, filter)
+			return
+		}
+
+		if filter.Limit == 0 {
+			filter.Limit = defaultLimit
+		}
+
+		entries := []storage.AuditEntry{}
+		err := reader.AuditLog(r.Context(), filter, func(e storage.AuditEntry) error {
+			entries = append(entries, e)
+			return nil
+		})
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to read audit log", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to read audit log"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Entries:  entries,
+		})
+	}
+}
+
+// export streams every entry matching filter as newline delimited JSON. Once
+// the first entry is written errors can only be logged, the client sees a
+// truncated body.
+func export(w http.ResponseWriter, r *http.Request, log *slog.Logger, reader AuditReader, filter storage.AuditFilter) {
+	filter.Limit = 0
+
+	// An export may take longer than the write timeout of ordinary requests.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+
+	n := 0
+	err := reader.AuditLog(r.Context(), filter, func(e storage.AuditEntry) error {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+
+		n++
+		if n%100 == 0 {
+			_ = rc.Flush()
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.ErrorContext(r.Context(), "audit export failed", sl.Err(err), slog.Int("written", n))
+		return
+	}
+
+	log.InfoContext(r.Context(), "audit log exported", slog.Int("entries", n))
+}
@@ -0,0 +1,102 @@
+package createUser
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/login"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+	Role     string `json:"role" validate:"required,oneof=admin member read-only"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id"`
+}
+
+type UserCreator interface {
+	CreateUser(ctx context.Context, username string, passwordHash string, role string) (int64, error)
+}
+
+// Create user
+// @Summary      Create user
+// @Description  Adds an account that can log in with Basic auth
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "username, password and role"
+// @Success      200  {object}	Response "ID of the user"
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /admin/users [post]
+func New(log *slog.Logger, creator UserCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.createUser.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		hash, err := login.HashPassword(req.Password)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to hash password", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to create user"))
+			return
+		}
+
+		id, err := creator.CreateUser(r.Context(), req.Username, hash, req.Role)
+		if errors.Is(err, storage.ErrLoginExists) {
+			log.InfoContext(r.Context(), "user already exists", slog.String("username", req.Username))
+			render.JSON(w, r, response.Error("user already exists"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to create user", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to create user"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "user created",
+			slog.String("admin", auth.User(r.Context())),
+			slog.String("username", req.Username),
+			slog.String("role", req.Role),
+			slog.Int64("id", id),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			ID:       id,
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package listUsers
+
+import (
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Users []storage.User `json:"users"`
+}
+
+type UserLister interface {
+	ListUsers(ctx context.Context) ([]storage.User, error)
+}
+
+// List users
+// @Summary      List users
+// @Description  Accounts stored in the database, the ones from the config file are not listed
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}	Response
+// @Failure      403
+// @Failure      500
+// @Router       /admin/users [get]
+func New(log *slog.Logger, lister UserLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.listUsers.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		users, err := lister.ListUsers(r.Context())
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list users", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list users"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Users:    users,
+		})
+	}
+}
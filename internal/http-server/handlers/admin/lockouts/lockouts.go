@@ -0,0 +1,54 @@
+package lockouts
+
+import (
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Lockouts []storage.LoginFailures `json:"lockouts"`
+}
+
+type LockoutLister interface {
+	ListLoginFailures(ctx context.Context) ([]storage.LoginFailures, error)
+}
+
+// List failed logins
+// @Summary      List failed logins
+// @Description  Failed login counters per username and IP, including the locked ones
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}	Response
+// @Failure      403
+// @Failure      500
+// @Router       /admin/lockouts [get]
+func New(log *slog.Logger, lister LockoutLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.lockouts.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		list, err := lister.ListLoginFailures(r.Context())
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list login failures", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list lockouts"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Lockouts: list,
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package taskCounts
+
+import (
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Counts map[string]int `json:"counts"`
+}
+
+type TaskCounter interface {
+	CountTasksByOwner(ctx context.Context) (map[string]int, error)
+}
+
+// Task counts
+// @Summary      Task counts
+// @Description  Number of tasks of every user
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}	Response "Tasks per owner"
+// @Failure      403
+// @Failure      500
+// @Router       /admin/tasks/count [get]
+func New(log *slog.Logger, counter TaskCounter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.taskCounts.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		counts, err := counter.CountTasksByOwner(r.Context())
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to count tasks", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to count tasks"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Counts:   counts,
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package unlock
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Username string `json:"username,omitempty" validate:"required_without=IP"`
+	IP       string `json:"ip,omitempty" validate:"omitempty,ip"`
+}
+
+type Response struct {
+	response.Response
+	Unlocked int64 `json:"unlocked"`
+}
+
+type LoginUnlocker interface {
+	ResetLoginFailures(ctx context.Context, keys ...storage.LoginKey) (int64, error)
+}
+
+// Unlock login
+// @Summary      Unlock login
+// @Description  Clears the failed logins and the lockout of a username, an IP or both
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "username and/or IP"
+// @Success      200  {object}	Response "Number of counters cleared"
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /admin/unlock [post]
+func New(log *slog.Logger, unlocker LoginUnlocker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.unlock.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		var keys []storage.LoginKey
+		if req.Username != "" {
+			keys = append(keys, storage.LoginKey{Kind: storage.LoginKeyUser, Value: req.Username})
+		}
+		if req.IP != "" {
+			keys = append(keys, storage.LoginKey{Kind: storage.LoginKeyIP, Value: req.IP})
+		}
+
+		n, err := unlocker.ResetLoginFailures(r.Context(), keys...)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to unlock login", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to unlock"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "login unlocked",
+			slog.String("admin", auth.User(r.Context())),
+			slog.String("username", req.Username),
+			slog.String("ip", req.IP),
+			slog.Int64("unlocked", n),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Unlocked: n,
+		})
+	}
+}
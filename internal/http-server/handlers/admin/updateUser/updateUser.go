@@ -0,0 +1,129 @@
+package updateUser
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/login"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// Request changes only the fields that are set.
+type Request struct {
+	Username string  `json:"username" validate:"required"`
+	Role     *string `json:"role,omitempty" validate:"omitempty,oneof=admin member read-only"`
+	Disabled *bool   `json:"disabled,omitempty"`
+	Password *string `json:"password,omitempty" validate:"omitempty,min=8"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type UserUpdater interface {
+	SetUserRole(ctx context.Context, username string, role string) error
+	SetUserDisabled(ctx context.Context, username string, disabled bool) error
+	SetUserPassword(ctx context.Context, username string, passwordHash string) error
+}
+
+// Update user
+// @Summary      Update user
+// @Description  Changes the role of an account, disables or enables it or resets its password
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "username and the fields to change"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /admin/users [patch]
+func New(log *slog.Logger, updater UserUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.updateUser.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		log = log.With(
+			slog.String("admin", auth.User(r.Context())),
+			slog.String("username", req.Username),
+		)
+
+		err = update(r.Context(), updater, req)
+		if errors.Is(err, storage.ErrLoginNotFound) {
+			log.InfoContext(r.Context(), "user not found")
+			render.JSON(w, r, response.Error("user not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to update user", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to update user"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "user updated",
+			slog.Any("role", req.Role),
+			slog.Any("disabled", req.Disabled),
+			slog.Bool("password_reset", req.Password != nil),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
+
+func update(ctx context.Context, updater UserUpdater, req Request) error {
+	if req.Role != nil {
+		if err := updater.SetUserRole(ctx, req.Username, *req.Role); err != nil {
+			return err
+		}
+	}
+
+	if req.Disabled != nil {
+		if err := updater.SetUserDisabled(ctx, req.Username, *req.Disabled); err != nil {
+			return err
+		}
+	}
+
+	if req.Password != nil {
+		hash, err := login.HashPassword(*req.Password)
+		if err != nil {
+			return err
+		}
+
+		if err := updater.SetUserPassword(ctx, req.Username, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+// Package graphql serves the GraphQL schema of schema.graphql, a single
+// endpoint to query tasks together with their subtasks, comments and
+// activity.
+package graphql
+
+import (
+	"context"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	_ "embed"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	gql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxDepth limits the nesting of queries, subtasks nest without end so it
+// also bounds how deep a tree of tasks is read.
+const maxDepth = 8
+
+// writers are the roles allowed to run mutations, as on the /task routes.
+var writers = []string{storage.RoleAdmin, storage.RoleMember}
+
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type Store interface {
+	SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error)
+	SaveSubtask(ctx context.Context, parentID int64, taskName string, taskDescription string, taskDate string, taskStatus string, taskType string) (int64, error)
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetTaskForDay(ctx context.Context, taskOwner string, taskDate string) ([]storage.Task, error)
+	GetAllTasks(ctx context.Context, taskOwner string) ([]storage.Task, error)
+	GetTasksInRange(ctx context.Context, username string, from string, to string) ([]storage.Task, error)
+	PatchTask(ctx context.Context, taskID int64, patch storage.TaskPatch) (storage.Task, error)
+	DeleteTask(ctx context.Context, id int64) error
+	GetComments(ctx context.Context, taskIDs ...int64) (map[int64][]storage.Comment, error)
+	GetActivity(ctx context.Context, taskIDs ...int64) (map[int64][]storage.Activity, error)
+	GetSubtasks(ctx context.Context, parentIDs ...int64) (map[int64][]storage.Task, error)
+	ListUsers(ctx context.Context) ([]storage.User, error)
+	ListWorkspaces(ctx context.Context, username string) ([]storage.Workspace, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+}
+
+// GraphQL
+// @Summary      GraphQL
+// @Description  Runs a GraphQL query or mutation against the schema of internal/http-server/handlers/graphql/schema.graphql: the tasks of a day, a date range or all time, filtered by status and type, with their subtasks, comments and activity loaded in one query per field for the whole list, plus users and workspaces. The mutations saveTask, updateTask and deleteTask mirror POST /task/save, PATCH /task and DELETE /task and need the admin or member role, saveTask also saves subtasks under a parentId the caller may see. The response follows the GraphQL spec, errors are listed in errors.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "query, operationName and variables"
+// @Success      200
+// @Failure      400
+// @Router       /graphql [post]
+func New(log *slog.Logger, store Store) http.HandlerFunc {
+	s := gql.MustParseSchema(schema, &resolver{log: log, store: store},
+		gql.UseStringDescriptions(),
+		gql.MaxDepth(maxDepth),
+	)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.graphql.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, &gql.Response{Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("failed to decode request")}})
+			return
+		}
+
+		log.DebugContext(r.Context(), "request body decoded", slog.String("operation", req.OperationName))
+
+		ctx := withLoader(r.Context(), store)
+		resp := s.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+		if len(resp.Errors) > 0 {
+			log.InfoContext(r.Context(), "query failed", slog.Any("errors", resp.Errors))
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
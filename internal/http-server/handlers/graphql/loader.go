@@ -0,0 +1,96 @@
+package graphql
+
+import (
+	"context"
+	"daytask/internal/storage"
+	"sync"
+)
+
+type loaderKey struct{}
+
+// loader batches the subtasks, comments and activity of the tasks resolved
+// by one request. Every task a query returns is registered with add, and the
+// first task asking for its comments loads those of every registered task in
+// one query, so a list of tasks costs one query per field instead of one per
+// task.
+type loader struct {
+	subtasks batch[[]storage.Task]
+	comments batch[[]storage.Comment]
+	activity batch[[]storage.Activity]
+}
+
+func withLoader(ctx context.Context, store Store) context.Context {
+	return context.WithValue(ctx, loaderKey{}, &loader{
+		subtasks: batch[[]storage.Task]{fetch: store.GetSubtasks},
+		comments: batch[[]storage.Comment]{fetch: store.GetComments},
+		activity: batch[[]storage.Activity]{fetch: store.GetActivity},
+	})
+}
+
+func loaderFrom(ctx context.Context) *loader {
+	return ctx.Value(loaderKey{}).(*loader)
+}
+
+// add registers tasks that may ask for their subtasks, comments or activity.
+func (l *loader) add(tasks ...storage.Task) {
+	ids := make([]int64, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+
+	l.subtasks.add(ids)
+	l.comments.add(ids)
+	l.activity.add(ids)
+}
+
+// batch loads the values of the registered keys at once and keeps them for
+// the rest of the request.
+type batch[V any] struct {
+	fetch func(ctx context.Context, ids ...int64) (map[int64]V, error)
+
+	mu      sync.Mutex
+	pending []int64
+	loaded  map[int64]V
+}
+
+func (b *batch[V]) add(ids []int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending = append(b.pending, ids...)
+}
+
+// load returns the value of id, fetching it with every pending key unless it
+// is loaded already. Concurrent resolvers wait for the fetch in progress
+// rather than starting their own.
+func (b *batch[V]) load(ctx context.Context, id int64) (V, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if v, ok := b.loaded[id]; ok {
+		return v, nil
+	}
+
+	ids := []int64{id}
+	for _, p := range b.pending {
+		if _, ok := b.loaded[p]; !ok && p != id {
+			ids = append(ids, p)
+		}
+	}
+
+	values, err := b.fetch(ctx, ids...)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+
+	if b.loaded == nil {
+		b.loaded = make(map[int64]V, len(ids))
+	}
+	for _, id := range ids {
+		b.loaded[id] = values[id]
+	}
+	b.pending = nil
+
+	return b.loaded[id], nil
+}
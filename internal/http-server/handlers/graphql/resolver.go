@@ -0,0 +1,506 @@
+package graphql
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	gql "github.com/graph-gophers/graphql-go"
+)
+
+var (
+	errForbidden      = errors.New("forbidden")
+	errTaskNotFound   = errors.New("task not found")
+	errParentNotFound = errors.New("parent task not found")
+	errInvalidDate    = errors.New("field Date is not valid")
+)
+
+// resolver is the root of the schema, its methods are the fields of Query
+// and Mutation.
+type resolver struct {
+	log   *slog.Logger
+	store Store
+}
+
+func (r *resolver) Me(ctx context.Context) *userResolver {
+	return &userResolver{u: storage.User{Username: auth.User(ctx), Role: auth.Role(ctx)}}
+}
+
+func (r *resolver) Task(ctx context.Context, args struct{ ID gql.ID }) (*taskResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, nil
+	}
+
+	task, err := r.store.GetTask(ctx, id)
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return nil, errors.New("failed to get task")
+	}
+
+	// Tasks the caller may not see are as good as missing.
+	ok, err := access.CanSee(ctx, r.store, task, auth.User(ctx))
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get workspace", sl.Err(err))
+		return nil, errors.New("failed to get task")
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	return taskResolvers(ctx, task)[0], nil
+}
+
+type tasksArgs struct {
+	Owner  *string
+	Date   *string
+	From   *string
+	To     *string
+	Status *string
+	Type   *string
+}
+
+func (r *resolver) Tasks(ctx context.Context, args tasksArgs) ([]*taskResolver, error) {
+	owner := auth.User(ctx)
+	if args.Owner != nil {
+		owner = *args.Owner
+	}
+
+	var (
+		tasks []storage.Task
+		err   error
+	)
+	switch {
+	case args.Date != nil:
+		if !validDate(*args.Date) {
+			return nil, errInvalidDate
+		}
+		tasks, err = r.store.GetTaskForDay(ctx, owner, *args.Date)
+	case args.From != nil || args.To != nil:
+		if args.From == nil || args.To == nil || !validDate(*args.From) || !validDate(*args.To) {
+			return nil, errors.New("fields From and To are required together and must be valid dates")
+		}
+		tasks, err = r.store.GetTasksInRange(ctx, owner, *args.From, *args.To)
+	default:
+		tasks, err = r.store.GetAllTasks(ctx, owner)
+	}
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, errors.New("incorrect date")
+	}
+	if err == nil {
+		tasks, err = access.Visible(ctx, r.store, tasks, auth.User(ctx))
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get tasks", sl.Err(err))
+		return nil, errors.New("failed to get tasks")
+	}
+
+	tasks = slices.DeleteFunc(tasks, func(t storage.Task) bool {
+		return args.Status != nil && t.Status != *args.Status || args.Type != nil && t.Type != *args.Type
+	})
+
+	return taskResolvers(ctx, tasks...), nil
+}
+
+func (r *resolver) Users(ctx context.Context) ([]*userResolver, error) {
+	if auth.Role(ctx) != storage.RoleAdmin {
+		return nil, errForbidden
+	}
+
+	users, err := r.store.ListUsers(ctx)
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to list users", sl.Err(err))
+		return nil, errors.New("failed to list users")
+	}
+
+	out := make([]*userResolver, len(users))
+	for i, u := range users {
+		out[i] = &userResolver{u: u}
+	}
+
+	return out, nil
+}
+
+func (r *resolver) Workspaces(ctx context.Context) ([]*workspaceResolver, error) {
+	workspaces, err := r.store.ListWorkspaces(ctx, auth.User(ctx))
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to list workspaces", sl.Err(err))
+		return nil, errors.New("failed to list workspaces")
+	}
+
+	out := make([]*workspaceResolver, len(workspaces))
+	for i, w := range workspaces {
+		out[i] = &workspaceResolver{w: w}
+	}
+
+	return out, nil
+}
+
+type taskInput struct {
+	Title       *string
+	Description *string
+	Owner       *string
+	Date        string
+	Status      *string
+	Type        *string
+}
+
+// fields returns the task fields set by in, with the defaults of the HTTP
+// requests for those it leaves out.
+func (in taskInput) fields(ctx context.Context) storage.Task {
+	t := storage.Task{
+		Owner:  auth.User(ctx),
+		Date:   in.Date,
+		Status: "unstarted",
+		Type:   "ordinary",
+	}
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&t.Title, in.Title},
+		{&t.Description, in.Description},
+		{&t.Owner, in.Owner},
+		{&t.Status, in.Status},
+		{&t.Type, in.Type},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+
+	return t
+}
+
+func (r *resolver) SaveTask(ctx context.Context, args struct {
+	Input    taskInput
+	ParentID *gql.ID
+}) (*taskResolver, error) {
+	if !slices.Contains(writers, auth.Role(ctx)) {
+		return nil, errForbidden
+	}
+	if !validDate(args.Input.Date) {
+		return nil, errInvalidDate
+	}
+
+	t := args.Input.fields(ctx)
+
+	var (
+		id     int64
+		parent storage.Task
+		err    error
+	)
+	if args.ParentID != nil {
+		parent, err = r.parent(ctx, *args.ParentID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Subtasks belong to the owner of their parent.
+		if args.Input.Owner == nil {
+			t.Owner = parent.Owner
+		}
+		if t.Owner != parent.Owner {
+			return nil, errors.New("field Owner must be the owner of the parent task")
+		}
+
+		id, err = r.store.SaveSubtask(ctx, parent.ID, t.Title, t.Description, t.Date, t.Status, t.Type)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			return nil, errParentNotFound
+		}
+	} else {
+		id, err = r.store.SaveTask(ctx, t.Title, t.Description, t.Owner, t.Date, t.Status, t.Type)
+	}
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, errors.New("incorrect date")
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to save task", sl.Err(err))
+		return nil, errors.New("failed to save task")
+	}
+
+	task, err := r.store.GetTask(ctx, id)
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get task", sl.Err(err), slog.Int64("id", id))
+		return nil, errors.New("failed to save task")
+	}
+
+	r.log.InfoContext(ctx, "task added", slog.Int64("id", id))
+
+	return taskResolvers(ctx, task)[0], nil
+}
+
+// parent returns the task a subtask is saved under: a personal task the
+// caller may see.
+func (r *resolver) parent(ctx context.Context, id gql.ID) (storage.Task, error) {
+	parentID, err := parseID(id)
+	if err != nil {
+		return storage.Task{}, errParentNotFound
+	}
+
+	parent, err := r.store.GetTask(ctx, parentID)
+	if errors.Is(err, storage.ErrTaskNotFound) || err == nil && parent.WorkspaceID != nil {
+		return storage.Task{}, errParentNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return storage.Task{}, errors.New("failed to save task")
+	}
+
+	ok, err := access.CanSee(ctx, r.store, parent, auth.User(ctx))
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get workspace", sl.Err(err))
+		return storage.Task{}, errors.New("failed to save task")
+	}
+	if !ok {
+		return storage.Task{}, errParentNotFound
+	}
+
+	return parent, nil
+}
+
+// taskPatch is the input of updateTask, the fields it leaves out are kept.
+type taskPatch struct {
+	Title       *string
+	Description *string
+	Owner       *string
+	Date        *string
+	Status      *string
+	Type        *string
+}
+
+func (r *resolver) UpdateTask(ctx context.Context, args struct {
+	ID    gql.ID
+	Input taskPatch
+}) (*taskResolver, error) {
+	if !slices.Contains(writers, auth.Role(ctx)) {
+		return nil, errForbidden
+	}
+	if args.Input.Date != nil && !validDate(*args.Input.Date) {
+		return nil, errInvalidDate
+	}
+
+	task, err := r.changeable(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if in := args.Input; in.Owner != nil && *in.Owner != task.Owner && !access.CanChangeOwner(auth.Role(ctx)) {
+		return nil, errors.New("only admins may change the owner")
+	}
+
+	task, err = r.store.PatchTask(ctx, task.ID, storage.TaskPatch{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+		Owner:       args.Input.Owner,
+		Date:        args.Input.Date,
+		Status:      args.Input.Status,
+		Type:        args.Input.Type,
+	})
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		return nil, errTaskNotFound
+	}
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return nil, errors.New("incorrect date")
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to update task", sl.Err(err))
+		return nil, errors.New("failed to update task")
+	}
+
+	r.log.InfoContext(ctx, "task update", slog.Int64("id", task.ID))
+
+	return taskResolvers(ctx, task)[0], nil
+}
+
+func (r *resolver) DeleteTask(ctx context.Context, args struct{ ID gql.ID }) (bool, error) {
+	if !slices.Contains(writers, auth.Role(ctx)) {
+		return false, errForbidden
+	}
+
+	task, err := r.changeable(ctx, args.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.store.DeleteTask(ctx, task.ID); err != nil {
+		r.log.ErrorContext(ctx, "failed to delete task", sl.Err(err))
+		return false, errors.New("failed to delete task")
+	}
+
+	r.log.InfoContext(ctx, "task deleted", slog.Int64("id", task.ID))
+
+	return true, nil
+}
+
+// changeable returns the personal task id if the caller may change it, see
+// access.CanChange. Workspace tasks are changed through the workspace
+// endpoints, they and the tasks the caller may not change are not found.
+func (r *resolver) changeable(ctx context.Context, id gql.ID) (storage.Task, error) {
+	taskID, err := parseID(id)
+	if err != nil {
+		return storage.Task{}, errTaskNotFound
+	}
+
+	task, err := r.store.GetTask(ctx, taskID)
+	if errors.Is(err, storage.ErrTaskNotFound) || err == nil && (task.WorkspaceID != nil || !access.CanChange(task, auth.User(ctx))) {
+		return storage.Task{}, errTaskNotFound
+	}
+	if err != nil {
+		r.log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return storage.Task{}, errors.New("failed to get task")
+	}
+
+	return task, nil
+}
+
+// taskResolvers wraps tasks in resolvers and registers them with the loader
+// of the request.
+func taskResolvers(ctx context.Context, tasks ...storage.Task) []*taskResolver {
+	loaderFrom(ctx).add(tasks...)
+
+	out := make([]*taskResolver, len(tasks))
+	for i, t := range tasks {
+		out[i] = &taskResolver{t: t}
+	}
+
+	return out
+}
+
+type taskResolver struct {
+	t storage.Task
+}
+
+func (r *taskResolver) ID() gql.ID          { return formatID(r.t.ID) }
+func (r *taskResolver) Title() string       { return r.t.Title }
+func (r *taskResolver) Description() string { return r.t.Description }
+func (r *taskResolver) Owner() string       { return r.t.Owner }
+func (r *taskResolver) Status() string      { return r.t.Status }
+func (r *taskResolver) Type() string        { return r.t.Type }
+func (r *taskResolver) Assignees() []string { return r.t.Assignees }
+
+func (r *taskResolver) Date() string {
+	// Stored dates may carry a time, the API takes and returns dates only.
+	if len(r.t.Date) > len(time.DateOnly) {
+		return r.t.Date[:len(time.DateOnly)]
+	}
+
+	return r.t.Date
+}
+
+func (r *taskResolver) WorkspaceID() *gql.ID {
+	if r.t.WorkspaceID == nil {
+		return nil
+	}
+
+	id := formatID(*r.t.WorkspaceID)
+	return &id
+}
+
+func (r *taskResolver) ParentID() *gql.ID {
+	if r.t.ParentID == nil {
+		return nil
+	}
+
+	id := formatID(*r.t.ParentID)
+	return &id
+}
+
+func (r *taskResolver) Subtasks(ctx context.Context) ([]*taskResolver, error) {
+	subtasks, err := loaderFrom(ctx).subtasks.load(ctx, r.t.ID)
+	if err != nil {
+		return nil, errors.New("failed to get subtasks")
+	}
+
+	return taskResolvers(ctx, subtasks...), nil
+}
+
+func (r *taskResolver) Comments(ctx context.Context) ([]*commentResolver, error) {
+	comments, err := loaderFrom(ctx).comments.load(ctx, r.t.ID)
+	if err != nil {
+		return nil, errors.New("failed to get comments")
+	}
+
+	out := make([]*commentResolver, len(comments))
+	for i, c := range comments {
+		out[i] = &commentResolver{c: c}
+	}
+
+	return out, nil
+}
+
+func (r *taskResolver) Activity(ctx context.Context) ([]*activityResolver, error) {
+	activity, err := loaderFrom(ctx).activity.load(ctx, r.t.ID)
+	if err != nil {
+		return nil, errors.New("failed to get activity")
+	}
+
+	out := make([]*activityResolver, len(activity))
+	for i, a := range activity {
+		out[i] = &activityResolver{a: a}
+	}
+
+	return out, nil
+}
+
+type commentResolver struct {
+	c storage.Comment
+}
+
+func (r *commentResolver) ID() gql.ID          { return formatID(r.c.ID) }
+func (r *commentResolver) Author() string      { return r.c.Author }
+func (r *commentResolver) Text() string        { return r.c.Text }
+func (r *commentResolver) CreatedAt() gql.Time { return gql.Time{Time: r.c.CreatedAt} }
+func (r *commentResolver) UpdatedAt() gql.Time { return gql.Time{Time: r.c.UpdatedAt} }
+
+type activityResolver struct {
+	a storage.Activity
+}
+
+func (r *activityResolver) ID() gql.ID    { return formatID(r.a.ID) }
+func (r *activityResolver) Actor() string { return r.a.Actor }
+func (r *activityResolver) Field() string { return r.a.Field }
+func (r *activityResolver) From() string  { return r.a.From }
+func (r *activityResolver) To() string    { return r.a.To }
+func (r *activityResolver) At() gql.Time  { return gql.Time{Time: r.a.At} }
+
+type userResolver struct {
+	u storage.User
+}
+
+func (r *userResolver) Username() string { return r.u.Username }
+func (r *userResolver) Role() string     { return r.u.Role }
+func (r *userResolver) Disabled() bool   { return r.u.Disabled }
+
+type workspaceResolver struct {
+	w storage.Workspace
+}
+
+func (r *workspaceResolver) ID() gql.ID    { return formatID(r.w.ID) }
+func (r *workspaceResolver) Name() string  { return r.w.Name }
+func (r *workspaceResolver) Owner() string { return r.w.Owner }
+func (r *workspaceResolver) Role() string  { return r.w.Role }
+
+func formatID(id int64) gql.ID {
+	return gql.ID(strconv.FormatInt(id, 10))
+}
+
+func parseID(id gql.ID) (int64, error) {
+	return strconv.ParseInt(string(id), 10, 64)
+}
+
+// validDate reports whether date is formatted as 2006-01-02, like the
+// datetime validation of the HTTP requests.
+func validDate(date string) bool {
+	_, err := time.Parse(time.DateOnly, date)
+	return err == nil
+}
@@ -0,0 +1,21 @@
+package healthz
+
+import (
+	"daytask/internal/lib/api/response"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// Liveness probe
+// @Summary      Liveness probe
+// @Description  Reports that the process is up and serving requests
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} response.Response
+// @Router       /healthz [get]
+func New() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, response.OK())
+	}
+}
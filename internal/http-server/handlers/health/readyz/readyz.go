@@ -0,0 +1,44 @@
+package readyz
+
+import (
+	"context"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const checkTimeout = 2 * time.Second
+
+type ReadinessChecker interface {
+	Ready(ctx context.Context) error
+}
+
+// Readiness probe
+// @Summary      Readiness probe
+// @Description  Reports whether the database is reachable and migrated
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} response.Response
+// @Failure      503  {object} response.Response
+// @Router       /readyz [get]
+func New(log *slog.Logger, checker ReadinessChecker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.readyz.New"
+
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		if err := checker.Ready(ctx); err != nil {
+			log.Warn("not ready", slog.String("op", op), sl.Err(err))
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, response.Error("not ready"))
+			return
+		}
+
+		render.JSON(w, r, response.OK())
+	}
+}
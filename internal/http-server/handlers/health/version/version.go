@@ -0,0 +1,32 @@
+package version
+
+import (
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/buildinfo"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	buildinfo.Info
+}
+
+// Build version
+// @Summary      Build version
+// @Description  Version, commit and build time of the running binary
+// @Tags         health
+// @Produce      json
+// @Success      200  {object} Response
+// @Router       /version [get]
+func New() http.HandlerFunc {
+	info := buildinfo.Get()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Info:     info,
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package pull
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 500
+	maxLimit     = 1000
+)
+
+type Response struct {
+	response.Response
+	storage.SyncDelta
+}
+
+type ChangeGetter interface {
+	SyncChanges(ctx context.Context, username string, since int64, limit int) (storage.SyncDelta, error)
+}
+
+// Pull changes
+// @Summary      Pull changes
+// @Description  Returns, in server_rev order, the tasks the caller owns or is assigned to that changed after revision since, and tombstones for those deleted or no longer theirs. Start from since=0 and pass the returned rev as since next time, right away while more is set.
+// @Tags         sync
+// @Produce      json
+// @Param        since  query  int  false  "last revision synced, 0 for everything"
+// @Param        limit  query  int  false  "page size, 500 by default, at most 1000"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /sync [get]
+func New(log *slog.Logger, getter ChangeGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sync.pull.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		query := r.URL.Query()
+
+		var since int64
+		if s := query.Get("since"); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 0 {
+				render.JSON(w, r, response.Error("field since is not valid"))
+				return
+			}
+			since = n
+		}
+
+		limit := defaultLimit
+		if s := query.Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 1 || n > maxLimit {
+				render.JSON(w, r, response.Error("field limit is not valid"))
+				return
+			}
+			limit = n
+		}
+
+		delta, err := getter.SyncChanges(r.Context(), auth.User(r.Context()), since, limit)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get changes", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get changes"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.OK(),
+			SyncDelta: delta,
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package push
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// Change operations.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Outcomes of a change.
+const (
+	// StatusApplied changes were stored as sent.
+	StatusApplied = "applied"
+	// StatusMerged updates conflicted and were merged with the server's
+	// changes, the fields in Conflicts kept the server value.
+	StatusMerged = "merged"
+	// StatusRejected changes conflicted and were dropped, the server state
+	// wins.
+	StatusRejected = "rejected"
+	StatusFailed   = "failed"
+)
+
+type Request struct {
+	Changes []Change `json:"changes" validate:"required,max=500,dive"`
+}
+
+// Change is an offline change of a personal task.
+type Change struct {
+	Op string `json:"op" validate:"required,oneof=create update delete"`
+	// ClientID names a task created offline, creates retried with the same
+	// ClientID create it once.
+	ClientID string `json:"client_id,omitempty" validate:"required_if=Op create,max=128"`
+	// ID and BaseRev are the task and the server_rev the client changed it
+	// from.
+	ID      int64 `json:"id,omitempty" validate:"required_unless=Op create"`
+	BaseRev int64 `json:"base_rev,omitempty" validate:"required_unless=Op create"`
+	// Base is the task as of BaseRev, with it conflicting changes are
+	// merged rather than rejected.
+	Base *Fields `json:"base,omitempty"`
+	Task *Fields `json:"task,omitempty" validate:"required_unless=Op delete"`
+}
+
+// Fields are the editable fields of a task, empty status and type fall back
+// to their defaults and an empty owner to the caller.
+type Fields struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	Status      string `json:"status,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+type Result struct {
+	ClientID string `json:"client_id,omitempty"`
+	ID       int64  `json:"id,omitempty"`
+	// Status is applied, merged, rejected or failed.
+	Status    string   `json:"status"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	// Task is the task as now stored, with Deleted set if it is deleted.
+	Task    *storage.Task `json:"task,omitempty"`
+	Deleted bool          `json:"deleted,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	Results []Result `json:"results,omitempty"`
+}
+
+type ChangeApplier interface {
+	SyncCreateTask(ctx context.Context, username string, clientID string, task storage.Task) (storage.Task, bool, error)
+	SyncUpdateTask(ctx context.Context, id int64, change func(t *storage.Task)) (storage.Task, error)
+	SyncDeleteTask(ctx context.Context, id int64, ok func(t storage.Task) bool) (storage.Task, bool, error)
+}
+
+// Push changes
+// @Summary      Push changes
+// @Description  Applies a batch of changes made offline to the caller's personal tasks, in order, each on its own, and returns the outcome of each. An update or delete conflicts when the task is no longer at base_rev. Conflicts are resolved the same way every time: an update that sent base is merged field by field, fields changed on both sides keep the server value; without base it is rejected. A delete is applied only if the server changed none of the fields of base. Changes to a task deleted on the server are rejected.
+// @Tags         sync
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "changes in the order they were made"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /sync [post]
+func New(log *slog.Logger, applier ChangeApplier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.sync.push.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		results := make([]Result, len(req.Changes))
+		for i, c := range req.Changes {
+			res, err := apply(r.Context(), applier, user, c)
+			if errors.Is(err, storage.ErrTaskNotFound) {
+				res.Status, res.Error = StatusFailed, "task not found"
+			} else if err != nil {
+				log.ErrorContext(r.Context(), "failed to apply change", slog.String("change", c.Op), slog.Int64("id", c.ID), sl.Err(err))
+				res.Status, res.Error = StatusFailed, "failed to apply change"
+			}
+			results[i] = res
+		}
+
+		log.InfoContext(r.Context(), "changes pushed", slog.Int("count", len(results)))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Results:  results,
+		})
+	}
+}
+
+// apply resolves and stores one change of user.
+func apply(ctx context.Context, applier ChangeApplier, user string, c Change) (Result, error) {
+	res := Result{ClientID: c.ClientID, ID: c.ID}
+
+	switch c.Op {
+	case OpCreate:
+		task, _, err := applier.SyncCreateTask(ctx, user, c.ClientID, c.Task.task(user))
+		if err != nil {
+			return res, err
+		}
+		res.ID, res.Status, res.Task = task.ID, StatusApplied, &task
+
+		return res, nil
+	case OpUpdate:
+		var hidden bool
+		task, err := applier.SyncUpdateTask(ctx, c.ID, func(t *storage.Task) {
+			if !visible(*t, user) {
+				hidden = true
+				return
+			}
+
+			next := c.Task.task(user)
+			switch {
+			case t.ServerRev == c.BaseRev:
+				res.Status = StatusApplied
+			case c.Base != nil:
+				next, res.Conflicts = storage.Merge(c.Base.task(user), *t, next)
+				res.Status = StatusMerged
+			default:
+				res.Status = StatusRejected
+				return
+			}
+
+			t.Title, t.Description, t.Owner, t.Date, t.Status, t.Type = next.Title, next.Description, next.Owner, next.Date, next.Status, next.Type
+		})
+		if err != nil {
+			return res, err
+		}
+		// change is not called for trashed tasks.
+		if hidden || task.DeletedAt != nil && !visible(task, user) {
+			return res, storage.ErrTaskNotFound
+		}
+		if task.DeletedAt != nil {
+			// Deleted on the server, which wins.
+			res.Status, res.Deleted = StatusRejected, true
+		}
+		res.Task = &task
+
+		return res, nil
+	default:
+		var hidden bool
+		task, ok, err := applier.SyncDeleteTask(ctx, c.ID, func(t storage.Task) bool {
+			if !visible(t, user) {
+				hidden = true
+				return false
+			}
+
+			if t.ServerRev == c.BaseRev {
+				return true
+			}
+			if c.Base == nil {
+				return false
+			}
+			base := c.Base.task(user)
+			return len(storage.Diff(base, t)) == 0
+		})
+		if hidden {
+			return res, storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return res, err
+		}
+
+		res.Task, res.Deleted = &task, ok
+		res.Status = StatusApplied
+		if !ok {
+			res.Status = StatusRejected
+		}
+
+		return res, nil
+	}
+}
+
+func visible(t storage.Task, user string) bool {
+	return t.Owner == user || slices.Contains(t.Assignees, user)
+}
+
+func (f *Fields) task(user string) storage.Task {
+	t := storage.Task{
+		Title:       f.Title,
+		Description: f.Description,
+		Owner:       f.Owner,
+		Date:        f.Date,
+		Status:      f.Status,
+		Type:        f.Type,
+	}
+	if t.Owner == "" {
+		t.Owner = user
+	}
+	if t.Status == "" {
+		t.Status = "unstarted"
+	}
+	if t.Type == "" {
+		t.Type = "ordinary"
+	}
+
+	return t
+}
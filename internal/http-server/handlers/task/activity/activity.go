@@ -0,0 +1,98 @@
+package activity
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultLimit = 50
+
+type Request struct {
+	// User defaults to the caller, only admins may read someone else's feed.
+	User     string `json:"user,omitempty"`
+	Limit    int    `json:"limit,omitempty" validate:"omitempty,min=1,max=500"`
+	BeforeID int64  `json:"before_id,omitempty" validate:"omitempty,min=1"`
+}
+
+type Response struct {
+	response.Response
+	Activity []storage.Activity `json:"activity,omitempty"`
+}
+
+type FeedGetter interface {
+	ActivityFeed(ctx context.Context, username string, beforeID int64, limit int) ([]storage.Activity, error)
+}
+
+// Activity feed
+// @Summary      Activity feed
+// @Description  Lists, newest first, changes made by a user or to tasks they own or are assigned to. Pass the smallest ID seen as before_id to get the next page.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  false  "user, page size and cursor"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /task/activity [get]
+func New(log *slog.Logger, getter FeedGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.activity.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		if r.ContentLength != 0 {
+			if err := render.DecodeJSON(r.Body, &req); err != nil {
+				log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to decode request"))
+				return
+			}
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+		if req.User == "" {
+			req.User = user
+		}
+		if req.User != user && auth.Role(r.Context()) != storage.RoleAdmin {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+		if req.Limit == 0 {
+			req.Limit = defaultLimit
+		}
+
+		list, err := getter.ActivityFeed(r.Context(), req.User, req.BeforeID, req.Limit)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get activity", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get activity"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Activity: list,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package addComment
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	TaskID int64  `json:"task_id" validate:"required"`
+	Text   string `json:"text" validate:"required,max=4000"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id,omitempty"`
+}
+
+type CommentAdder interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	AddComment(ctx context.Context, taskID int64, author string, text string) (int64, error)
+}
+
+// Add comment
+// @Summary      Add comment
+// @Description  Adds a comment to a task, workspace tasks only take comments from members and personal tasks from their owner and assignees
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID and comment text"
+// @Success      200  {object}	Response "comment ID"
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/comment [post]
+func New(log *slog.Logger, adder CommentAdder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.addComment.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		task, err := adder.GetTask(r.Context(), req.TaskID)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to add comment"))
+			return
+		}
+
+		if task.WorkspaceID != nil {
+			_, err := adder.GetWorkspace(r.Context(), *task.WorkspaceID, user)
+			if errors.Is(err, storage.ErrNotMember) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to add comment"))
+				return
+			}
+		} else if task.Owner != user && !slices.Contains(task.Assignees, user) {
+			// Personal tasks take comments from their owner and assignees.
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		id, err := adder.AddComment(r.Context(), task.ID, user, req.Text)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to add comment", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to add comment"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "comment added", slog.Int64("id", id), slog.Int64("task_id", task.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			ID:       id,
+		})
+	}
+}
@@ -1,16 +1,19 @@
 package delete
 
 import (
+	"context"
 	"daytask/internal/lib/api/response"
 	"log/slog"
 	"net/http"
 
-	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
 	"daytask/internal/lib/logger/sl"
-	_ "daytask/internal/storage"
-	_ "errors"
+	"daytask/internal/storage"
+	"errors"
 
 )
 
@@ -24,18 +27,20 @@ type Response struct{
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TASKDeleter
 type TASKDeleter interface{
-	DeleteTask(id int64) (error)
+	DeleteTask(ctx context.Context, id int64) error
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
 }
 
 // Delete task
 // @Summary      Delete task
-// @Description  Delete task
+// @Description  Moves a task to the trash, from where it can be restored until the retention period passes. Only the owner and assignees of a personal task may delete it.
 // @Tags         task
 // @Accept       json
 // @Produce      json
 // @Param        id   body      int  true  "task ID"
 // @Success      200  
 // @Failure      400  
+// @Failure      403  
 // @Failure      404  
 // @Failure      500  
 // @Router       /task [delete]
@@ -43,7 +48,7 @@ func New(log *slog.Logger, taskDeleter TASKDeleter) http.HandlerFunc{
 	return func(w http.ResponseWriter, r *http.Request){
 		const op = "handlers.task.delete"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -52,29 +57,43 @@ func New(log *slog.Logger, taskDeleter TASKDeleter) http.HandlerFunc{
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil{
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to decode request"))
 			return
 		}
 		
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("request", req))
 		
 		if err := validator.New().Struct(req); err != nil{
 			validateErr := err.(validator.ValidationErrors)
-			log.Error("invalid request", sl.Err(err))
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
 			render.JSON(w, r, response.ValidationError(validateErr))
 			return
 		}
 
-		err = taskDeleter.DeleteTask(req.ID)
+		// Deleting a task that does not exist is not an error, see
+		// DeleteTask.
+		task, err := taskDeleter.GetTask(r.Context(), req.ID)
+		if err != nil && !errors.Is(err, storage.ErrTaskNotFound) {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete task"))
+			return
+		}
+		if err == nil && task.WorkspaceID == nil && !access.CanChange(task, auth.User(r.Context())) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		err = taskDeleter.DeleteTask(r.Context(), req.ID)
 		
 		if err != nil {
-			log.Error("failed to delete task", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to delete task", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to delete task"))
 			return
 		}
 
-		log.Info("task deleted")
+		log.InfoContext(r.Context(), "task deleted")
 
 		render.JSON(w, r, Response{ 
 			Response: response.OK(),
@@ -0,0 +1,131 @@
+package diff
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID   int64 `json:"id" validate:"required"`
+	From int   `json:"from" validate:"required,min=1"`
+	// To defaults to the latest revision.
+	To int `json:"to,omitempty" validate:"omitempty,min=1"`
+}
+
+type Response struct {
+	response.Response
+	From    int              `json:"from,omitempty"`
+	To      int              `json:"to,omitempty"`
+	Changes []storage.Change `json:"changes"`
+}
+
+type RevisionGetter interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	GetRevision(ctx context.Context, taskID int64, rev int) (storage.Revision, error)
+}
+
+// Diff task revisions
+// @Summary      Diff task revisions
+// @Description  Lists the fields that changed between two revisions of a task. Workspace tasks are only shown to members, personal tasks to their owner.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID and revisions"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/revisions/diff [get]
+func New(log *slog.Logger, getter RevisionGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.diff.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		task, err := getter.GetTask(r.Context(), req.ID)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to diff revisions"))
+			return
+		}
+
+		if task.WorkspaceID != nil {
+			_, err := getter.GetWorkspace(r.Context(), *task.WorkspaceID, auth.User(r.Context()))
+			if errors.Is(err, storage.ErrNotMember) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to diff revisions"))
+				return
+			}
+		} else if task.Owner != auth.User(r.Context()) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		var revs [2]storage.Revision
+		for i, rev := range []int{req.From, req.To} {
+			revs[i], err = getter.GetRevision(r.Context(), task.ID, rev)
+			if errors.Is(err, storage.ErrRevisionNotFound) {
+				render.JSON(w, r, response.Error("revision not found"))
+				return
+			}
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to get revision", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to diff revisions"))
+				return
+			}
+		}
+
+		changes := storage.Diff(revs[0].Task, revs[1].Task)
+		if changes == nil {
+			changes = []storage.Change{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			From:     revs[0].Rev,
+			To:       revs[1].Rev,
+			Changes:  changes,
+		})
+	}
+}
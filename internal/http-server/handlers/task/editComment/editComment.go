@@ -0,0 +1,85 @@
+package editComment
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID   int64  `json:"id" validate:"required"`
+	Text string `json:"text" validate:"required,max=4000"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type CommentEditor interface {
+	EditComment(ctx context.Context, id int64, author string, text string) error
+}
+
+// Edit comment
+// @Summary      Edit comment
+// @Description  Replaces the text of a comment, only its author may
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "comment ID and new text"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /task/comment [patch]
+func New(log *slog.Logger, editor CommentEditor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.editComment.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err = editor.EditComment(r.Context(), req.ID, auth.User(r.Context()), req.Text)
+		if errors.Is(err, storage.ErrCommentNotFound) {
+			render.JSON(w, r, response.Error("comment not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to edit comment", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to edit comment"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "comment edited", slog.Int64("id", req.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package getAllTasks
 
 import (
+	"context"
 	"daytask/internal/lib/api/response"
 	"daytask/internal/lib/logger/sl"
 	"daytask/internal/storage"
@@ -23,7 +24,7 @@ type Response struct{
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TASKGetterAll
 type TASKGetterAll interface {
-	GetAllTasks(taskOwner string) ([]storage.Task, error)
+	GetAllTasks(ctx context.Context, taskOwner string) ([]storage.Task, error)
 }
 // Get all tasks
 // @Summary      Get all tasks
@@ -41,7 +42,7 @@ func New(log *slog.Logger, taskGetterAll TASKGetterAll) http.HandlerFunc{
 	return func(w http.ResponseWriter, r *http.Request){
 		const op = "handlers.task.GetAllTasks.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -50,23 +51,23 @@ func New(log *slog.Logger, taskGetterAll TASKGetterAll) http.HandlerFunc{
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil{
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to decode request"))
 			return
 		}
 		
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("request", req))
 		
-		tasks, err := taskGetterAll.GetAllTasks(req.Owner)
+		tasks, err := taskGetterAll.GetAllTasks(r.Context(), req.Owner)
 
 
 		if err != nil {
-			log.Error("failed to get all tasks", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to get all tasks", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to get all tasks"))
 			return
 		}
 
-		log.Info("all task get", slog.Any("quantity", len(tasks)))
+		log.InfoContext(r.Context(), "all task get", slog.Any("quantity", len(tasks)))
 
 		render.JSON(w, r, Response{ 
 			Response: response.OK(),
@@ -1,6 +1,9 @@
 package getTask
 
 import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
 	"daytask/internal/lib/api/response"
 	"log/slog"
 	"net/http"
@@ -9,7 +12,7 @@ import (
 	"daytask/internal/storage"
 	"errors"
 
-	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 )
@@ -17,6 +20,8 @@ import (
 type Request struct {
 	Owner string `json:"owner"`
 	Date  string `json:"date" validate:"required,datetime=2006-01-02"`
+	// Include lists what to attach to every task: "comments", "activity" or both.
+	Include []string `json:"include,omitempty" validate:"dive,oneof=comments activity"`
 }
 
 type Response struct {
@@ -27,17 +32,21 @@ type Response struct {
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TASKGetter
 type TASKGetter interface {
-	GetTaskForDay(taskOwner string, taskDate string) ([]storage.Task, error)
+	GetTaskForDay(ctx context.Context, taskOwner string, taskDate string) ([]storage.Task, error)
+	GetComments(ctx context.Context, taskIDs ...int64) (map[int64][]storage.Comment, error)
+	GetActivity(ctx context.Context, taskIDs ...int64) (map[int64][]storage.Activity, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
 }
 
 // Get the day's tasks
 // @Summary      Get tasks
-// @Description  Get the day's tasks
+// @Description  Get the day's tasks. Comments and activity are only included for the tasks the caller owns, is assigned to or shares a workspace with.
 // @Tags         task
 // @Accept       json
 // @Produce      json
 // @Param        owner   body      string  true  "owner's login"
 // @Param        date   body      string  true  "date"
+// @Param        include   body      []string  false  "comments and/or activity"
 // @Success      200  {object} Response "Quantity and Tasks array"
 // @Failure      400 
 // @Failure      404 
@@ -47,7 +56,7 @@ func New(log *slog.Logger, taskGetter TASKGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.task.gettask"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -56,34 +65,40 @@ func New(log *slog.Logger, taskGetter TASKGetter) http.HandlerFunc {
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to decode request"))
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("request", req))
 
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
-			log.Error("invalid request", sl.Err(err))
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
 			render.JSON(w, r, response.ValidationError(validateErr))
 			return
 		}
 
-		tasks, err := taskGetter.GetTaskForDay(req.Owner, req.Date)
+		tasks, err := taskGetter.GetTaskForDay(r.Context(), req.Owner, req.Date)
 		if errors.Is(err, storage.ErrIncorrectDate) {
-			log.Info("incorrect date", slog.String("date", req.Date))
+			log.InfoContext(r.Context(), "incorrect date", slog.String("date", req.Date))
 			render.JSON(w, r, response.Error("incorrect date"))
 			return
 		}
 
 		if err != nil {
-			log.Error("failed to get task", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to get task"))
 			return
 		}
 
-		log.Info("task get", slog.Any("quantity", len(tasks)))
+		if err := include(r.Context(), taskGetter, tasks, auth.User(r.Context()), req.Include); err != nil {
+			log.ErrorContext(r.Context(), "failed to get task details", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "task get", slog.Any("quantity", len(tasks)))
 
 		render.JSON(w, r, Response{
 			Response: response.OK(),
@@ -92,3 +107,47 @@ func New(log *slog.Logger, taskGetter TASKGetter) http.HandlerFunc {
 		})
 	}
 }
+
+// include attaches the requested comments and activity in place to the
+// tasks user may see, see access.CanSee.
+func include(ctx context.Context, taskGetter TASKGetter, tasks []storage.Task, user string, what []string) error {
+	if len(tasks) == 0 || len(what) == 0 {
+		return nil
+	}
+
+	visible, err := access.Visible(ctx, taskGetter, tasks, user)
+	if err != nil {
+		return err
+	}
+	if len(visible) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(visible))
+	for i, t := range visible {
+		ids[i] = t.ID
+	}
+
+	for _, w := range what {
+		switch w {
+		case "comments":
+			comments, err := taskGetter.GetComments(ctx, ids...)
+			if err != nil {
+				return err
+			}
+			for i := range tasks {
+				tasks[i].Comments = comments[tasks[i].ID]
+			}
+		case "activity":
+			activity, err := taskGetter.GetActivity(ctx, ids...)
+			if err != nil {
+				return err
+			}
+			for i := range tasks {
+				tasks[i].Activity = activity[tasks[i].ID]
+			}
+		}
+	}
+
+	return nil
+}
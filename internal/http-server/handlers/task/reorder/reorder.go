@@ -0,0 +1,85 @@
+package reorder
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Date string  `json:"date" validate:"required,datetime=2006-01-02"`
+	IDs  []int64 `json:"ids" validate:"required,min=1"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type DayReorderer interface {
+	ReorderDay(ctx context.Context, owner string, date string, ids []int64) error
+}
+
+// Reorder day
+// @Summary      Reorder day
+// @Description  Sets the order GET /task/day lists the caller's tasks of a day in. ids must list every task of the day exactly once, first to last; tasks added later come last until the day is ordered again.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "date and task IDs in their new order"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /task/reorder [post]
+func New(log *slog.Logger, reorderer DayReorderer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.reorder.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err = reorderer.ReorderDay(r.Context(), auth.User(r.Context()), req.Date, req.IDs)
+		if errors.Is(err, storage.ErrOrderMismatch) {
+			log.InfoContext(r.Context(), "order does not match the day", slog.String("date", req.Date))
+			render.JSON(w, r, response.Error("ids must list every task of the day once"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to reorder day", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to reorder day"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "day reordered", slog.String("date", req.Date), slog.Int("tasks", len(req.IDs)))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package restore
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID int64 `json:"id" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type TaskRestorer interface {
+	RestoreTask(ctx context.Context, id int64, username string) error
+}
+
+// Restore task
+// @Summary      Restore task
+// @Description  Takes a task out of the caller's trash
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      404
+// @Failure      500
+// @Router       /task/restore [post]
+func New(log *slog.Logger, restorer TaskRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.restore.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err = restorer.RestoreTask(r.Context(), req.ID, auth.User(r.Context()))
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not in trash"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to restore task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to restore task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "task restored", slog.Int64("id", req.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package revert
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/workspace"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID  int64 `json:"id" validate:"required"`
+	Rev int   `json:"rev" validate:"required,min=1"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type TaskReverter interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	RevertTask(ctx context.Context, taskID int64, rev int) error
+}
+
+// Revert task
+// @Summary      Revert task
+// @Description  Sets a task back to an earlier revision, itself saved as a new revision so the revert can be undone too. Personal tasks may only be reverted by their owner, workspace tasks by their owner and the workspace owners.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID and revision"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/revert [post]
+func New(log *slog.Logger, reverter TaskReverter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.revert.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		task, err := reverter.GetTask(r.Context(), req.ID)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to revert task"))
+			return
+		}
+
+		if task.WorkspaceID != nil {
+			ws, err := reverter.GetWorkspace(r.Context(), *task.WorkspaceID, user)
+			if err != nil && !errors.Is(err, storage.ErrNotMember) {
+				log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to revert task"))
+				return
+			}
+			if err != nil || !workspace.CanEdit(task, user, ws.Role) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+		} else if task.Owner != user {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		err = reverter.RevertTask(r.Context(), task.ID, req.Rev)
+		if errors.Is(err, storage.ErrRevisionNotFound) {
+			render.JSON(w, r, response.Error("revision not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to revert task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to revert task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "task reverted", slog.Int64("id", task.ID), slog.Int("rev", req.Rev))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
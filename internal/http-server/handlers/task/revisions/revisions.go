@@ -0,0 +1,116 @@
+package revisions
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID int64 `json:"id" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+	Revisions []storage.Revision `json:"revisions"`
+}
+
+type RevisionLister interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	ListRevisions(ctx context.Context, taskID int64) ([]storage.Revision, error)
+}
+
+// List task revisions
+// @Summary      List task revisions
+// @Description  Lists the kept versions of a task, newest first. Workspace tasks are only shown to members, personal tasks to their owner.
+// @Tags         task
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /task/revisions [get]
+func New(log *slog.Logger, lister RevisionLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.revisions.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		task, err := lister.GetTask(r.Context(), req.ID)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list revisions"))
+			return
+		}
+
+		if task.WorkspaceID != nil {
+			_, err := lister.GetWorkspace(r.Context(), *task.WorkspaceID, auth.User(r.Context()))
+			if errors.Is(err, storage.ErrNotMember) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+			if err != nil {
+				log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+				render.JSON(w, r, response.Error("failed to list revisions"))
+				return
+			}
+		} else if task.Owner != auth.User(r.Context()) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		list, err := lister.ListRevisions(r.Context(), task.ID)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list revisions", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list revisions"))
+			return
+		}
+
+		if list == nil {
+			list = []storage.Revision{}
+		}
+
+		render.JSON(w, r, Response{
+			Response:  response.OK(),
+			Revisions: list,
+		})
+	}
+}
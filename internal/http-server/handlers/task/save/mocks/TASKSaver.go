@@ -2,30 +2,34 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // TASKSaver is an autogenerated mock type for the TASKSaver type
 type TASKSaver struct {
 	mock.Mock
 }
 
-// SaveTask provides a mock function with given fields: taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType
-func (_m *TASKSaver) SaveTask(taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error) {
-	ret := _m.Called(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
+// SaveTask provides a mock function with given fields: ctx, taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType
+func (_m *TASKSaver) SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error) {
+	ret := _m.Called(ctx, taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, string, string, string, string) (int64, error)); ok {
-		return rf(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string, string) (int64, error)); ok {
+		return rf(ctx, taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, string, string, string, string) int64); ok {
-		r0 = rf(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, string, string, string) int64); ok {
+		r0 = rf(ctx, taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, string, string, string, string) error); ok {
-		r1 = rf(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, string, string, string) error); ok {
+		r1 = rf(ctx, taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
 	} else {
 		r1 = ret.Error(1)
 	}
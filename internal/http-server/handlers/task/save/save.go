@@ -1,6 +1,7 @@
 package save
 
 import (
+	"context"
 	"daytask/internal/lib/api/response"
 	"daytask/internal/lib/logger/sl"
 	"daytask/internal/storage"
@@ -29,7 +30,7 @@ type Response struct{
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TASKSaver
 type TASKSaver interface {
-	SaveTask(taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error)
+	SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error)
 }
 // Save task
 // @Summary      Save task
@@ -47,7 +48,7 @@ func New(log *slog.Logger, taskSaver TASKSaver) http.HandlerFunc{
 	return func(w http.ResponseWriter, r *http.Request){
 		const op = "handlers.task.save.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
@@ -59,34 +60,34 @@ func New(log *slog.Logger, taskSaver TASKSaver) http.HandlerFunc{
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil{
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to decode request"))
 			return
 		}
 		
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("request", req))
 		
 		if err := validator.New().Struct(req); err != nil{
 			validateErr := err.(validator.ValidationErrors)
-			log.Error("invalid request", sl.Err(err))
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
 			render.JSON(w, r, response.ValidationError(validateErr))
 			return
 		}
 
-		id, err := taskSaver.SaveTask(req.Title, req.Description, req.Owner, req.Date, req.Status, req.Type)
+		id, err := taskSaver.SaveTask(r.Context(), req.Title, req.Description, req.Owner, req.Date, req.Status, req.Type)
 		if errors.Is(err, storage.ErrIncorrectDate){
-			log.Info("incorrect date", slog.String("date", req.Date))
+			log.InfoContext(r.Context(), "incorrect date", slog.String("date", req.Date))
 			render.JSON(w, r,  response.Error("incorrect date"))
 			return
 		}
 
 		if err != nil {
-			log.Error("failed to save task", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to save task", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to save task"))
 			return
 		}
 
-		log.Info("task added", slog.Int64("id", id))
+		log.InfoContext(r.Context(), "task added", slog.Int64("id", id))
 
 		render.JSON(w, r, Response{ 
 			Response: response.OK(),
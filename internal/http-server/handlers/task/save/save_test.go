@@ -65,7 +65,7 @@ func TestSaveHandler(t *testing.T) {
 			taskSaverMock := mocks.NewTASKSaver(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				taskSaverMock.On("SaveTask",mock.AnythingOfType("string"), mock.AnythingOfType("string"), tc.date).
+				taskSaverMock.On("SaveTask", mock.Anything, tc.title, mock.AnythingOfType("string"), tc.owner, tc.date, "unstarted", "ordinary").
 					Return(int64(1), tc.mockError).
 					Once()
 			}
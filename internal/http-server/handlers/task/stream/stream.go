@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/events"
+	"daytask/internal/lib/logger/sl"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// EventReset tells the client it missed events that are no longer retained
+// and has to reload its tasks.
+const EventReset = "reset"
+
+type Subscriber interface {
+	Subscribe(lastID uint64) ([]events.Event, *events.Subscription, bool)
+}
+
+// Task stream
+// @Summary      Task stream
+// @Description  Server-Sent Events stream of the changes to the tasks the caller owns or is assigned to. Every event is named after its type (task.created, task.updated, task.completed or task.deleted) and carries its ID, which a reconnecting client sends back as Last-Event-ID, or the last_event_id query parameter, to get the events it missed. A reset event means those are no longer available and the client has to reload.
+// @Tags         task
+// @Produce      text/event-stream
+// @Param        Last-Event-ID  header  int  false  "ID of the last event received"
+// @Param        last_event_id  query   int  false  "ID of the last event received, when the header cannot be set"
+// @Success      200  {object}	events.Event
+// @Failure      400
+// @Router       /tasks/stream [get]
+func New(log *slog.Logger, sub Subscriber, heartbeat time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.stream.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var lastID uint64
+		if s := r.Header.Get("Last-Event-ID"); s != "" || r.URL.Query().Has("last_event_id") {
+			if s == "" {
+				s = r.URL.Query().Get("last_event_id")
+			}
+
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				log.ErrorContext(r.Context(), "invalid last event ID", slog.String("last_event_id", s))
+				render.JSON(w, r, response.Error("invalid last event ID"))
+				return
+			}
+			lastID = id
+		}
+
+		user := auth.User(r.Context())
+
+		replay, subscription, ok := sub.Subscribe(lastID)
+		defer subscription.Close()
+
+		// The stream outlives the write timeout of ordinary requests.
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if !ok {
+			fmt.Fprintf(w, "event: %s\ndata: {}\n\n", EventReset)
+		}
+		for _, e := range replay {
+			if err := write(w, user, e); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			log.ErrorContext(r.Context(), "streaming not supported", sl.Err(err))
+			return
+		}
+
+		log.DebugContext(r.Context(), "stream started", slog.Uint64("last_event_id", lastID))
+
+		var tick <-chan time.Time
+		if heartbeat > 0 {
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, open := <-subscription.C():
+				if !open {
+					// Too far behind or shutting down, the client resumes
+					// from its last event.
+					return
+				}
+				if err := write(w, user, e); err != nil {
+					return
+				}
+			case <-tick:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// write sends e if it is about a task user owns or is assigned to.
+func write(w http.ResponseWriter, user string, e events.Event) error {
+	if e.Task.Owner != user && !slices.Contains(e.Task.Assignees, user) {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+
+	return err
+}
@@ -0,0 +1,58 @@
+package trash
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Tasks []storage.Task `json:"tasks"`
+}
+
+type TrashLister interface {
+	ListTrash(ctx context.Context, username string) ([]storage.Task, error)
+}
+
+// List trash
+// @Summary      List trash
+// @Description  Deleted tasks the caller may restore: its own and those of the workspaces it owns, most recently deleted first. They are deleted for good once the retention period passes.
+// @Tags         task
+// @Produce      json
+// @Success      200  {object}	Response
+// @Failure      500
+// @Router       /task/trash [get]
+func New(log *slog.Logger, lister TrashLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.trash.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		tasks, err := lister.ListTrash(r.Context(), auth.User(r.Context()))
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list trash", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list trash"))
+			return
+		}
+
+		if tasks == nil {
+			tasks = []storage.Task{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Tasks:    tasks,
+		})
+	}
+}
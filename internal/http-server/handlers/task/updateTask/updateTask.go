@@ -1,18 +1,36 @@
 package updateTask
 
 import (
+	"bytes"
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
 	"daytask/internal/lib/api/response"
 	"daytask/internal/lib/logger/sl"
 	"daytask/internal/storage"
+	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
 
+	jsonpatch "github.com/evanphx/json-patch/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 )
 
+// Patch content types, see RFC 7396 and RFC 6902.
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+// maxPatchSize limits the body of a patch request.
+const maxPatchSize = 1 << 20
+
 type Request struct {
 	ID     int64  `json:"id"`
 	Title  string `json:"title"`
@@ -23,24 +41,43 @@ type Request struct {
 	Type   string `json:"type,omitempty"`
 }
 
+// Document is the part of a task that patches apply to. The result of a patch
+// must still be a valid Document, it may not add other members.
+type Document struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Owner       string `json:"owner"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	Status      string `json:"status"`
+	Type        string `json:"type"`
+}
+
 type Response struct {
 	response.Response
+	// Task is the patched task, it is only returned for patch requests.
+	Task *storage.Task `json:"task,omitempty"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TASKUpdater
 type TASKUpdater interface {
-	UpdateTask(taskID int64, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) error
+	UpdateTask(ctx context.Context, taskID int64, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) error
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	PatchTask(ctx context.Context, taskID int64, patch storage.TaskPatch) (storage.Task, error)
 }
 
 // Update task
 // @Summary      Update task
-// @Description  Update task
+// @Description  With application/json the body replaces every field of the task, omitted status and type fall back to their defaults and an omitted owner keeps the task with its owner. With application/merge-patch+json (RFC 7396) or application/json-patch+json (RFC 6902) the body is a patch of the Document of the task given by the id query parameter, only the fields it changes are written. Only the owner and assignees of a personal task may update it, and only admins may change its owner.
 // @Tags         task
 // @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
 // @Produce      json
-// @Param        task   body      Request  true  "updated task"
-// @Success      200
+// @Param        task   body      Request  true  "updated task, or a patch of Document"
+// @Param        id     query     int      false "task ID, required by patches"
+// @Success      200  {object}	Response
 // @Failure      400 
+// @Failure      403 
 // @Failure      404 
 // @Failure      500
 // @Router       /task [patch]
@@ -48,11 +85,16 @@ func New(log *slog.Logger, taskUpdater TASKUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.task.Update.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
+		if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == MergePatch || ct == JSONPatch {
+			patch(w, r, log, taskUpdater, ct)
+			return
+		}
+
 		var req = Request{
 			Status: "unstarted",
 			Type:   "ordinary",
@@ -60,37 +102,206 @@ func New(log *slog.Logger, taskUpdater TASKUpdater) http.HandlerFunc {
 
 		err := render.DecodeJSON(r.Body, &req)
 		if err != nil {
-			log.Error("failed to decode request body", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to decode request"))
 			return
 		}
 
-		log.Info("request body decoded", slog.Any("request", req))
+		log.InfoContext(r.Context(), "request body decoded", slog.Any("request", req))
 
 		if err := validator.New().Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
-			log.Error("invalid request", sl.Err(err))
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
 			render.JSON(w, r, response.ValidationError(validateErr))
 			return
 		}
 
-		err = taskUpdater.UpdateTask(req.ID, req.Title, req.Description, req.Owner, req.Date, req.Status, req.Type)
+		task, ok := changeable(w, r, log, taskUpdater, req.ID)
+		if !ok {
+			return
+		}
+		// An omitted owner keeps the task with its owner.
+		if req.Owner == "" {
+			req.Owner = task.Owner
+		}
+		if req.Owner != task.Owner && !access.CanChangeOwner(auth.Role(r.Context())) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("only admins may change the owner"))
+			return
+		}
+
+		err = taskUpdater.UpdateTask(r.Context(), req.ID, req.Title, req.Description, req.Owner, req.Date, req.Status, req.Type)
 		if errors.Is(err, storage.ErrIncorrectDate) {
-			log.Info("incorrect date", slog.String("date", req.Date))
+			log.InfoContext(r.Context(), "incorrect date", slog.String("date", req.Date))
 			render.JSON(w, r, response.Error("incorrect date"))
 			return
 		}
 
 		if err != nil {
-			log.Error("failed to update task", sl.Err(err))
+			log.ErrorContext(r.Context(), "failed to update task", sl.Err(err))
 			render.JSON(w, r, response.Error("failed to update task"))
 			return
 		}
 
-		log.Info("task update", slog.Int64("id", req.ID))
+		log.InfoContext(r.Context(), "task update", slog.Int64("id", req.ID))
 
 		render.JSON(w, r, Response{
 			Response: response.OK(),
 		})
 	}
 }
+
+// patch applies a patch of content type ct to the Document of a task and
+// stores the fields it changed.
+func patch(w http.ResponseWriter, r *http.Request, log *slog.Logger, taskUpdater TASKUpdater, ct string) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id <= 0 {
+		render.JSON(w, r, response.Error("field id is a required query parameter"))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to read request body", sl.Err(err))
+		render.JSON(w, r, response.Error("failed to decode request"))
+		return
+	}
+
+	task, ok := changeable(w, r, log, taskUpdater, id)
+	if !ok {
+		return
+	}
+
+	changes, err := ApplyPatch(DocumentOf(task), ct, body)
+	var validateErr validator.ValidationErrors
+	if errors.As(err, &validateErr) {
+		log.InfoContext(r.Context(), "patched task is invalid", sl.Err(err))
+		render.JSON(w, r, response.ValidationError(validateErr))
+		return
+	}
+	if err != nil {
+		log.InfoContext(r.Context(), "patch not applied", sl.Err(err))
+		render.JSON(w, r, response.Error("invalid patch: "+err.Error()))
+		return
+	}
+	if changes.Owner != nil && !access.CanChangeOwner(auth.Role(r.Context())) {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error("only admins may change the owner"))
+		return
+	}
+
+	task, err = taskUpdater.PatchTask(r.Context(), id, changes)
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		render.JSON(w, r, response.Error("task not found"))
+		return
+	}
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to patch task", sl.Err(err))
+		render.JSON(w, r, response.Error("failed to update task"))
+		return
+	}
+
+	log.InfoContext(r.Context(), "task patched", slog.Int64("id", id))
+
+	render.JSON(w, r, Response{
+		Response: response.OK(),
+		Task:     &task,
+	})
+}
+
+// changeable returns the personal task id if the caller may change it, see
+// access.CanChange, and otherwise writes the error response. Workspace tasks
+// are changed through the workspace endpoints.
+func changeable(w http.ResponseWriter, r *http.Request, log *slog.Logger, taskUpdater TASKUpdater, id int64) (storage.Task, bool) {
+	task, err := taskUpdater.GetTask(r.Context(), id)
+	if errors.Is(err, storage.ErrTaskNotFound) || err == nil && task.WorkspaceID != nil {
+		render.JSON(w, r, response.Error("task not found"))
+		return storage.Task{}, false
+	}
+	if err != nil {
+		log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+		render.JSON(w, r, response.Error("failed to update task"))
+		return storage.Task{}, false
+	}
+
+	if !access.CanChange(task, auth.User(r.Context())) {
+		render.Status(r, http.StatusForbidden)
+		render.JSON(w, r, response.Error("forbidden"))
+		return storage.Task{}, false
+	}
+
+	return task, true
+}
+
+// DocumentOf returns the Document patches of task apply to.
+func DocumentOf(task storage.Task) Document {
+	doc := Document{
+		Title:       task.Title,
+		Description: task.Description,
+		Owner:       task.Owner,
+		Date:        task.Date,
+		Status:      task.Status,
+		Type:        task.Type,
+	}
+	// The API returns dates as timestamps but takes them as dates.
+	if len(doc.Date) > len("2006-01-02") {
+		doc.Date = doc.Date[:len("2006-01-02")]
+	}
+
+	return doc
+}
+
+// ApplyPatch applies body, a patch of content type ct, to current and
+// returns the fields it changes. It fails with validator.ValidationErrors if
+// the patched Document is invalid, and with another error if the patch
+// cannot be applied.
+func ApplyPatch(current Document, ct string, body []byte) (storage.TaskPatch, error) {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return storage.TaskPatch{}, err
+	}
+
+	if ct == MergePatch {
+		doc, err = jsonpatch.MergePatch(doc, body)
+	} else {
+		var p jsonpatch.Patch
+		p, err = jsonpatch.DecodePatch(body)
+		if err == nil {
+			doc, err = p.Apply(doc)
+		}
+	}
+	if err != nil {
+		return storage.TaskPatch{}, err
+	}
+
+	var next Document
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&next); err != nil {
+		return storage.TaskPatch{}, err
+	}
+
+	if err := validator.New().Struct(next); err != nil {
+		return storage.TaskPatch{}, err
+	}
+
+	var changes storage.TaskPatch
+	for _, f := range []struct {
+		dst      **string
+		old, new string
+	}{
+		{&changes.Title, current.Title, next.Title},
+		{&changes.Description, current.Description, next.Description},
+		{&changes.Owner, current.Owner, next.Owner},
+		{&changes.Date, current.Date, next.Date},
+		{&changes.Status, current.Status, next.Status},
+		{&changes.Type, current.Type, next.Type},
+	} {
+		if f.old != f.new {
+			v := f.new
+			*f.dst = &v
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,148 @@
+package updateTask_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/http-server/handlers/task/updateTask"
+	"daytask/internal/http-server/handlers/task/updateTask/mocks"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/storage"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestPatch(t *testing.T) {
+	current := storage.Task{
+		ID:          7,
+		Title:       "t",
+		Description: "keep me",
+		Owner:       "alice",
+		Date:        "2024-01-01T00:00:00Z",
+		Status:      "unstarted",
+		Type:        "work",
+	}
+
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		user        string
+		role        string
+		patch       *storage.TaskPatch
+		status      int
+		respError   string
+	}{
+		{
+			name:        "Merge patch",
+			contentType: updateTask.MergePatch,
+			body:        `{"status":"done"}`,
+			patch:       &storage.TaskPatch{Status: ptr("done")},
+		},
+		{
+			name:        "Merge patch removes",
+			contentType: updateTask.MergePatch,
+			body:        `{"description":null,"title":"new"}`,
+			patch:       &storage.TaskPatch{Title: ptr("new"), Description: ptr("")},
+		},
+		{
+			name:        "JSON patch",
+			contentType: updateTask.JSONPatch,
+			body:        `[{"op":"test","path":"/status","value":"unstarted"},{"op":"replace","path":"/date","value":"2024-02-01"}]`,
+			patch:       &storage.TaskPatch{Date: ptr("2024-02-01")},
+		},
+		{
+			name:        "JSON patch failed test",
+			contentType: updateTask.JSONPatch,
+			body:        `[{"op":"test","path":"/status","value":"done"},{"op":"replace","path":"/title","value":"x"}]`,
+			respError:   "invalid patch",
+		},
+		{
+			name:        "Invalid result",
+			contentType: updateTask.MergePatch,
+			body:        `{"date":"tomorrow"}`,
+			respError:   "field Date is not valid",
+		},
+		{
+			name:        "Unknown field",
+			contentType: updateTask.MergePatch,
+			body:        `{"workspace_id":1}`,
+			respError:   "invalid patch",
+		},
+		{
+			name:        "Not the owner",
+			contentType: updateTask.MergePatch,
+			body:        `{"status":"done"}`,
+			user:        "bob",
+			status:      http.StatusForbidden,
+			respError:   "forbidden",
+		},
+		{
+			name:        "Owner change",
+			contentType: updateTask.MergePatch,
+			body:        `{"owner":"bob"}`,
+			status:      http.StatusForbidden,
+			respError:   "only admins may change the owner",
+		},
+		{
+			name:        "Owner change by an admin",
+			contentType: updateTask.MergePatch,
+			body:        `{"owner":"bob"}`,
+			role:        storage.RoleAdmin,
+			patch:       &storage.TaskPatch{Owner: ptr("bob")},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			updater := mocks.NewTASKUpdater(t)
+			updater.On("GetTask", mock.Anything, int64(7)).Return(current, nil).Once()
+			if tc.patch != nil {
+				updater.On("PatchTask", mock.Anything, int64(7), *tc.patch).Return(current, nil).Once()
+			}
+
+			handler := updateTask.New(slogdiscard.NewDiscardLogger(), updater)
+
+			req := httptest.NewRequest(http.MethodPatch, "/task/?id=7", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			// The caller is alice, the owner, as a member unless the case
+			// says otherwise.
+			user, role, status := "alice", storage.RoleMember, http.StatusOK
+			if tc.user != "" {
+				user = tc.user
+			}
+			if tc.role != "" {
+				role = tc.role
+			}
+			if tc.status != 0 {
+				status = tc.status
+			}
+			req = req.WithContext(auth.WithUser(req.Context(), storage.User{Username: user, Role: role}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			require.Equal(t, status, rr.Code)
+
+			var resp updateTask.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+
+			if tc.respError == "" {
+				require.Empty(t, resp.Error)
+				require.NotNil(t, resp.Task)
+			} else {
+				require.Contains(t, resp.Error, tc.respError)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package ws
+
+import (
+	"context"
+	"daytask/internal/http-server/handlers/task/save"
+	"daytask/internal/http-server/handlers/task/updateTask"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/access"
+	"daytask/internal/http-server/middleware/cors"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/events"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
+)
+
+// Message types sent by the client.
+const (
+	// TypeSubscribe replaces the date range the client follows, it is
+	// answered with a snapshot of the tasks in it.
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	// TypeSave carries a task as taken by POST /task/save, TypeUpdate a
+	// merge patch of a task as taken by PATCH /task/ along with the id of the
+	// task. Both are answered with a result.
+	TypeSave   = "save"
+	TypeUpdate = "update"
+)
+
+// Message types sent by the server.
+const (
+	TypeSnapshot = "snapshot"
+	TypeResult   = "result"
+	// TypeEvent is a change of a task in the subscribed range.
+	TypeEvent = "event"
+	// TypeReset means changes were missed and the client has to subscribe
+	// again to get a new snapshot.
+	TypeReset = "reset"
+)
+
+const (
+	// pingInterval keeps idle connections open and detects dead peers,
+	// which have pongWait to answer.
+	pingInterval = 30 * time.Second
+	pongWait     = 2 * pingInterval
+	writeWait    = 10 * time.Second
+	// maxMessageSize limits the messages read from the client.
+	maxMessageSize = 1 << 20
+)
+
+type Message struct {
+	Type string `json:"type" validate:"required,oneof=subscribe unsubscribe save update"`
+	// ID is chosen by the client and echoed in the reply.
+	ID   string `json:"id,omitempty"`
+	From string `json:"from,omitempty" validate:"required_if=Type subscribe,omitempty,datetime=2006-01-02"`
+	To   string `json:"to,omitempty" validate:"required_if=Type subscribe,omitempty,datetime=2006-01-02"`
+	// Task is a save.Request, or for updates the id of the task and a merge
+	// patch of its updateTask.Document.
+	Task json.RawMessage `json:"task,omitempty" swaggertype:"object"`
+}
+
+type Reply struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	response.Response
+	// TaskID is the ID of a saved task.
+	TaskID int64          `json:"task_id,omitempty"`
+	Tasks  []storage.Task `json:"tasks,omitempty"`
+	Event  *events.Event  `json:"event,omitempty"`
+}
+
+type TaskStore interface {
+	GetTasksInRange(ctx context.Context, username string, from string, to string) ([]storage.Task, error)
+	SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error)
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	PatchTask(ctx context.Context, taskID int64, patch storage.TaskPatch) (storage.Task, error)
+}
+
+type Subscriber interface {
+	Subscribe(lastID uint64) ([]events.Event, *events.Subscription, bool)
+	Done() <-chan struct{}
+}
+
+// Task sync socket
+// @Summary      Task sync socket
+// @Description  WebSocket on which a client subscribes to a date range with {"type":"subscribe","from":"2024-01-01","to":"2024-01-07"}, gets a snapshot of the tasks it owns or is assigned to in it and then an event for every change to them. It may send {"type":"save"} messages whose task is validated like the body of POST /task/save and belongs to the caller unless an admin names another owner, and {"type":"update"} messages whose task holds the id of the task and the fields to change, applied as a merge patch like PATCH /task/ with application/merge-patch+json and with the same checks. Replies echo the id of the message they answer. A reset message means changes were missed and the client has to subscribe again.
+// @Tags         task
+// @Param        request  body  Message  false  "messages sent on the socket"
+// @Success      101  {object}	Reply
+// @Failure      400
+// @Failure      403
+// @Router       /tasks/ws [get]
+func New(log *slog.Logger, store TaskStore, bus Subscriber, origins *cors.Origins) http.HandlerFunc {
+	upgrader := websocket.Upgrader{
+		// Browsers send the page origin, other clients usually none.
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" || origins.Allowed(origin) {
+				return true
+			}
+			u, err := url.Parse(origin)
+			return err == nil && u.Host == r.Host
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.task.ws.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has answered with an error status.
+			log.InfoContext(r.Context(), "failed to upgrade connection", sl.Err(err))
+			return
+		}
+		defer conn.Close()
+
+		// The timeout middleware set the deadlines of ordinary requests.
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetReadLimit(maxMessageSize)
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+
+		s := &session{
+			log:   log,
+			conn:  conn,
+			store: store,
+			user:  auth.User(r.Context()),
+			role:  auth.Role(r.Context()),
+		}
+		s.run(r.Context(), bus)
+	}
+}
+
+// session is one connection, only run writes to conn.
+type session struct {
+	log   *slog.Logger
+	conn  *websocket.Conn
+	store TaskStore
+	user  string
+	role  string
+
+	// from and to are the subscribed range, empty when there is none.
+	from, to string
+	lastID   uint64
+}
+
+func (s *session) run(ctx context.Context, bus Subscriber) {
+	// Read in the background so events are pushed while the client is quiet.
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		for {
+			_, data, err := s.conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	_, sub, _ := bus.Subscribe(0)
+	defer func() { sub.Close() }()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-bus.Done():
+			s.close(websocket.CloseGoingAway, "server shutting down")
+			return
+		case data, open := <-messages:
+			if !open {
+				if err := <-readErr; !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					s.log.DebugContext(ctx, "connection closed", sl.Err(err))
+				}
+				return
+			}
+			err = s.handle(ctx, data)
+		case e, open := <-sub.C():
+			if !open {
+				// Too far behind, resume after the last event seen.
+				var replay []events.Event
+				var ok bool
+				replay, sub, ok = bus.Subscribe(s.lastID)
+				if !ok {
+					err = s.reset()
+					break
+				}
+				for _, e := range replay {
+					if err = s.event(e); err != nil {
+						break
+					}
+				}
+				break
+			}
+			err = s.event(e)
+		case <-ping.C:
+			err = s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		}
+
+		if err != nil {
+			s.log.DebugContext(ctx, "failed to write message", sl.Err(err))
+			return
+		}
+	}
+}
+
+// handle answers a message of the client.
+func (s *session) handle(ctx context.Context, data []byte) error {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return s.write(Reply{Type: TypeResult, Response: response.Error("failed to decode message")})
+	}
+
+	if err := validator.New().Struct(msg); err != nil {
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.ValidationError(err.(validator.ValidationErrors))})
+	}
+
+	switch msg.Type {
+	case TypeSubscribe:
+		if msg.To < msg.From {
+			return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("field to is before from")})
+		}
+
+		tasks, err := s.store.GetTasksInRange(ctx, s.user, msg.From, msg.To)
+		if err != nil {
+			s.log.ErrorContext(ctx, "failed to get tasks", sl.Err(err))
+			return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to get tasks")})
+		}
+		s.from, s.to = msg.From, msg.To
+
+		return s.write(Reply{Type: TypeSnapshot, ID: msg.ID, Response: response.OK(), Tasks: tasks})
+	case TypeUnsubscribe:
+		s.from, s.to = "", ""
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.OK()})
+	}
+
+	// Mutations, read-only users get the same answer as from the REST API.
+	if s.role != storage.RoleAdmin && s.role != storage.RoleMember {
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("forbidden")})
+	}
+
+	if msg.Type == TypeUpdate {
+		return s.write(s.update(ctx, msg))
+	}
+
+	// The task is the caller's unless an admin saves it for someone else.
+	req := save.Request{Owner: s.user, Status: "unstarted", Type: "ordinary"}
+	if reply, ok := decode(msg, &req); !ok {
+		return s.write(reply)
+	}
+	if req.Owner != s.user && !access.CanChangeOwner(s.role) {
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("only admins may save tasks of another owner")})
+	}
+	id, err := s.store.SaveTask(ctx, req.Title, req.Description, req.Owner, req.Date, req.Status, req.Type)
+	if errors.Is(err, storage.ErrIncorrectDate) {
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("incorrect date")})
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to save task", sl.Err(err))
+		return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to save task")})
+	}
+
+	s.log.InfoContext(ctx, "task saved", slog.Int64("id", id))
+
+	return s.write(Reply{Type: TypeResult, ID: msg.ID, Response: response.OK(), TaskID: id})
+}
+
+// update applies the task of msg as a merge patch, as PATCH /task/ does with
+// application/merge-patch+json, to the task its id member names. Only the
+// fields it holds are written, the others keep their value, and only the
+// owner and assignees of the task may send it.
+func (s *session) update(ctx context.Context, msg Message) Reply {
+	var fields map[string]json.RawMessage
+	if len(msg.Task) == 0 {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("field task is a required field")}
+	}
+	if err := json.Unmarshal(msg.Task, &fields); err != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to decode task")}
+	}
+
+	var id int64
+	if err := json.Unmarshal(fields["id"], &id); err != nil || id <= 0 {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("field id is a required field")}
+	}
+	delete(fields, "id")
+
+	patch, err := json.Marshal(fields)
+	if err != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to decode task")}
+	}
+
+	task, err := s.store.GetTask(ctx, id)
+	// Workspace tasks are changed through the workspace endpoints.
+	if errors.Is(err, storage.ErrTaskNotFound) || err == nil && task.WorkspaceID != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("task not found")}
+	}
+	if err != nil {
+		s.log.ErrorContext(ctx, "failed to get task", sl.Err(err))
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to update task")}
+	}
+	if !access.CanChange(task, s.user) {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("forbidden")}
+	}
+
+	changes, err := updateTask.ApplyPatch(updateTask.DocumentOf(task), updateTask.MergePatch, patch)
+	var validateErr validator.ValidationErrors
+	if errors.As(err, &validateErr) {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.ValidationError(validateErr)}
+	}
+	if err != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("invalid patch: " + err.Error())}
+	}
+	if changes.Owner != nil && !access.CanChangeOwner(s.role) {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("only admins may change the owner")}
+	}
+
+	if _, err := s.store.PatchTask(ctx, id, changes); errors.Is(err, storage.ErrTaskNotFound) {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("task not found")}
+	} else if err != nil {
+		s.log.ErrorContext(ctx, "failed to update task", sl.Err(err))
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to update task")}
+	}
+
+	s.log.InfoContext(ctx, "task updated", slog.Int64("id", id))
+
+	return Reply{Type: TypeResult, ID: msg.ID, Response: response.OK(), TaskID: id}
+}
+
+// decode reads the task of msg into req and validates it, returning the
+// reply to send if it is not valid.
+func decode(msg Message, req any) (Reply, bool) {
+	if len(msg.Task) == 0 {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("field task is a required field")}, false
+	}
+
+	if err := json.Unmarshal(msg.Task, req); err != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.Error("failed to decode task")}, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		return Reply{Type: TypeResult, ID: msg.ID, Response: response.ValidationError(err.(validator.ValidationErrors))}, false
+	}
+
+	return Reply{}, true
+}
+
+// event sends e if it is about a task of the user in the subscribed range,
+// or moved out of it.
+func (s *session) event(e events.Event) error {
+	s.lastID = e.ID
+
+	if s.from == "" || e.Task.Owner != s.user && !slices.Contains(e.Task.Assignees, s.user) {
+		return nil
+	}
+
+	inRange := func(date string) bool {
+		return date >= s.from && date <= s.to
+	}
+
+	moved := slices.ContainsFunc(e.Changes, func(c storage.Change) bool {
+		return c.Field == "date" && inRange(c.From)
+	})
+	if !inRange(e.Task.Date) && !moved {
+		return nil
+	}
+
+	return s.write(Reply{Type: TypeEvent, Response: response.OK(), Event: &e})
+}
+
+// reset tells the client it missed changes and drops its subscription.
+func (s *session) reset() error {
+	s.from, s.to = "", ""
+
+	return s.write(Reply{Type: TypeReset, Response: response.OK()})
+}
+
+func (s *session) write(reply Reply) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+
+	return s.conn.WriteJSON(reply)
+}
+
+func (s *session) close(code int, text string) {
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(writeWait))
+}
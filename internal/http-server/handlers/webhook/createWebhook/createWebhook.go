@@ -0,0 +1,111 @@
+package createWebhook
+
+import (
+	"context"
+	"crypto/rand"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/webhook"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	URL    string   `json:"url" validate:"required,http_url"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=task.created task.updated task.completed task.deleted"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id,omitempty"`
+	// Secret signs the deliveries, it is only ever returned here.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookCreator interface {
+	CreateWebhook(ctx context.Context, owner string, url string, secret string, events []string) (int64, error)
+}
+
+// Create webhook
+// @Summary      Create webhook
+// @Description  Registers a URL to receive the events of the caller's tasks. Every delivery is signed with the returned secret, which is not shown again. The URL must resolve to public addresses, unless webhooks.allow_private is set, and redirects are not followed.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "URL and events"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /webhook [post]
+func New(log *slog.Logger, creator WebhookCreator, allowPrivate bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.createWebhook.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err = webhook.CheckURL(r.Context(), req.URL, allowPrivate)
+		if errors.Is(err, webhook.ErrForbiddenTarget) {
+			log.InfoContext(r.Context(), "webhook target not allowed", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, response.Error("url must point to a public address"))
+			return
+		}
+		if err != nil {
+			log.InfoContext(r.Context(), "invalid webhook url", slog.String("url", req.URL), sl.Err(err))
+			render.JSON(w, r, response.Error("cannot resolve url"))
+			return
+		}
+
+		slices.Sort(req.Events)
+		req.Events = slices.Compact(req.Events)
+
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			log.ErrorContext(r.Context(), "failed to generate secret", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to create webhook"))
+			return
+		}
+		secret := hex.EncodeToString(b)
+
+		id, err := creator.CreateWebhook(r.Context(), auth.User(r.Context()), req.URL, secret, req.Events)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to create webhook", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to create webhook"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "webhook created", slog.Int64("id", id))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			ID:       id,
+			Secret:   secret,
+		})
+	}
+}
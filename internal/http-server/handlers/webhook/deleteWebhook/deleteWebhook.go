@@ -0,0 +1,83 @@
+package deleteWebhook
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID int64 `json:"id" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type WebhookDeleter interface {
+	DeleteWebhook(ctx context.Context, id int64, owner string) error
+}
+
+// Delete webhook
+// @Summary      Delete webhook
+// @Description  Deletes one of the caller's webhooks along with its pending deliveries and delivery log
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "webhook ID"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /webhook [delete]
+func New(log *slog.Logger, deleter WebhookDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.deleteWebhook.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		err = deleter.DeleteWebhook(r.Context(), req.ID, auth.User(r.Context()))
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to delete webhook", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete webhook"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "webhook deleted", slog.Int64("id", req.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package deliveries
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultLimit = 50
+
+type Request struct {
+	WebhookID int64  `json:"webhook_id" validate:"required"`
+	Status    string `json:"status,omitempty" validate:"omitempty,oneof=pending delivered failed"`
+	Limit     int    `json:"limit,omitempty" validate:"omitempty,min=1,max=500"`
+	BeforeID  int64  `json:"before_id,omitempty" validate:"omitempty,min=1"`
+}
+
+type Response struct {
+	response.Response
+	Deliveries []storage.Delivery `json:"deliveries,omitempty"`
+}
+
+type DeliveryLister interface {
+	ListDeliveries(ctx context.Context, webhookID int64, owner string, status string, beforeID int64, limit int) ([]storage.Delivery, error)
+}
+
+// Webhook deliveries
+// @Summary      Webhook deliveries
+// @Description  Lists, newest first, the deliveries of one of the caller's webhooks with the outcome of their last attempt. Pass the smallest ID seen as before_id to get the next page.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "webhook ID, status, page size and cursor"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      500
+// @Router       /webhook/deliveries [get]
+func New(log *slog.Logger, lister DeliveryLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.deliveries.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		if req.Limit == 0 {
+			req.Limit = defaultLimit
+		}
+
+		list, err := lister.ListDeliveries(r.Context(), req.WebhookID, auth.User(r.Context()), req.Status, req.BeforeID, req.Limit)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			render.JSON(w, r, response.Error("webhook not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list deliveries", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list deliveries"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:   response.OK(),
+			Deliveries: list,
+		})
+	}
+}
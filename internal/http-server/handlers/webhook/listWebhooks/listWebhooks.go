@@ -0,0 +1,54 @@
+package listWebhooks
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Webhooks []storage.Webhook `json:"webhooks,omitempty"`
+}
+
+type WebhookLister interface {
+	ListWebhooks(ctx context.Context, owner string) ([]storage.Webhook, error)
+}
+
+// List webhooks
+// @Summary      List webhooks
+// @Description  Lists the caller's webhooks, without their secrets
+// @Tags         webhook
+// @Produce      json
+// @Success      200  {object}	Response
+// @Failure      500
+// @Router       /webhook [get]
+func New(log *slog.Logger, lister WebhookLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.listWebhooks.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		list, err := lister.ListWebhooks(r.Context(), auth.User(r.Context()))
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list webhooks", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list webhooks"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Webhooks: list,
+		})
+	}
+}
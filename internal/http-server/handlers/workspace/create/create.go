@@ -0,0 +1,79 @@
+package create
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id"`
+}
+
+type WorkspaceCreator interface {
+	CreateWorkspace(ctx context.Context, name string, owner string) (int64, error)
+}
+
+// Create workspace
+// @Summary      Create workspace
+// @Description  Creates a workspace owned by the current user
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "workspace name"
+// @Success      200  {object}	Response "ID of the workspace"
+// @Failure      400
+// @Failure      500
+// @Router       /workspace [post]
+func New(log *slog.Logger, creator WorkspaceCreator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.create.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		id, err := creator.CreateWorkspace(r.Context(), req.Name, auth.User(r.Context()))
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to create workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to create workspace"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace created", slog.Int64("id", id))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			ID:       id,
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package day
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	ViewMy   = "my"
+	ViewTeam = "team"
+)
+
+type Request struct {
+	WorkspaceID int64  `json:"workspace_id" validate:"required"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	// View is "my" for the tasks assigned to the current user or "team",
+	// the default, for every task of the workspace.
+	View string `json:"view,omitempty" validate:"omitempty,oneof=my team"`
+}
+
+type Response struct {
+	response.Response
+	Quantity int            `json:"quantity,omitempty"`
+	Tasks    []storage.Task `json:"tasks,omitempty"`
+}
+
+type DayGetter interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	GetWorkspaceDay(ctx context.Context, workspaceID int64, date string, assignee string) ([]storage.Task, error)
+}
+
+// Get the workspace day
+// @Summary      Get workspace tasks
+// @Description  The day's tasks of a workspace, either the ones assigned to the current user or the whole team's
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "workspace, date and view"
+// @Success      200  {object} Response "Quantity and Tasks array"
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /workspace/day [get]
+func New(log *slog.Logger, getter DayGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.day.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		_, err = getter.GetWorkspace(r.Context(), req.WorkspaceID, user)
+		if errors.Is(err, storage.ErrNotMember) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("not a workspace member"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get tasks"))
+			return
+		}
+
+		assignee := ""
+		if req.View == ViewMy {
+			assignee = user
+		}
+
+		tasks, err := getter.GetWorkspaceDay(r.Context(), req.WorkspaceID, req.Date, assignee)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get tasks", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to get tasks"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace tasks get", slog.Int("quantity", len(tasks)))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Quantity: len(tasks),
+			Tasks:    tasks,
+		})
+	}
+}
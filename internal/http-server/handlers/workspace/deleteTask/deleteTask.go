@@ -0,0 +1,108 @@
+package deleteTask
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/workspace"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	ID int64 `json:"id" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type TaskDeleter interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	DeleteWorkspaceTask(ctx context.Context, id int64) error
+}
+
+// Delete workspace task
+// @Summary      Delete workspace task
+// @Description  Moves a workspace task to the trash, only its owner and the workspace owners may
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /workspace/task [delete]
+func New(log *slog.Logger, deleter TaskDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.deleteTask.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		task, err := deleter.GetTask(r.Context(), req.ID)
+		if errors.Is(err, storage.ErrTaskNotFound) || err == nil && task.WorkspaceID == nil {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete task"))
+			return
+		}
+
+		ws, err := deleter.GetWorkspace(r.Context(), *task.WorkspaceID, user)
+		if err != nil && !errors.Is(err, storage.ErrNotMember) {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete task"))
+			return
+		}
+		if err != nil || !workspace.CanEdit(task, user, ws.Role) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		if err := deleter.DeleteWorkspaceTask(r.Context(), task.ID); err != nil {
+			log.ErrorContext(r.Context(), "failed to delete task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to delete task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace task deleted", slog.Int64("id", task.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package list
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	response.Response
+	Workspaces []storage.Workspace `json:"workspaces"`
+}
+
+type WorkspaceLister interface {
+	ListWorkspaces(ctx context.Context, username string) ([]storage.Workspace, error)
+}
+
+// List workspaces
+// @Summary      List workspaces
+// @Description  Workspaces the current user is a member of, with its role in each
+// @Tags         workspace
+// @Produce      json
+// @Success      200  {object}	Response
+// @Failure      500
+// @Router       /workspace [get]
+func New(log *slog.Logger, lister WorkspaceLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		list, err := lister.ListWorkspaces(r.Context(), auth.User(r.Context()))
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list workspaces", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list workspaces"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:   response.OK(),
+			Workspaces: list,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package members
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	WorkspaceID int64 `json:"workspace_id" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+	Members []storage.WorkspaceMember `json:"members"`
+}
+
+type MemberLister interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	ListWorkspaceMembers(ctx context.Context, workspaceID int64) ([]storage.WorkspaceMember, error)
+}
+
+// List workspace members
+// @Summary      List workspace members
+// @Description  Members of a workspace the current user belongs to
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "workspace"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /workspace/members [get]
+func New(log *slog.Logger, lister MemberLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.members.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		_, err = lister.GetWorkspace(r.Context(), req.WorkspaceID, auth.User(r.Context()))
+		if errors.Is(err, storage.ErrNotMember) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("not a workspace member"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list members"))
+			return
+		}
+
+		members, err := lister.ListWorkspaceMembers(r.Context(), req.WorkspaceID)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to list members", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to list members"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			Members:  members,
+		})
+	}
+}
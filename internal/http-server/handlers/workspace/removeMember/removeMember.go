@@ -0,0 +1,107 @@
+package removeMember
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	WorkspaceID int64  `json:"workspace_id" validate:"required"`
+	Username    string `json:"username" validate:"required"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type MemberRemover interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	RemoveWorkspaceMember(ctx context.Context, workspaceID int64, username string) error
+}
+
+// Remove workspace member
+// @Summary      Remove workspace member
+// @Description  Removes a member from a workspace and its task assignments, only workspace owners may
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "workspace and username"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /workspace/members [delete]
+func New(log *slog.Logger, remover MemberRemover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.removeMember.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		ws, err := remover.GetWorkspace(r.Context(), req.WorkspaceID, auth.User(r.Context()))
+		if err != nil && !errors.Is(err, storage.ErrNotMember) {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to remove member"))
+			return
+		}
+		if err != nil || ws.Role != storage.WorkspaceRoleOwner {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		if req.Username == ws.Owner {
+			render.JSON(w, r, response.Error("cannot remove the workspace creator"))
+			return
+		}
+
+		err = remover.RemoveWorkspaceMember(r.Context(), req.WorkspaceID, req.Username)
+		if errors.Is(err, storage.ErrNotMember) {
+			render.JSON(w, r, response.Error("not a workspace member"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to remove member", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to remove member"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace member removed",
+			slog.Int64("workspace_id", req.WorkspaceID),
+			slog.String("username", req.Username),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
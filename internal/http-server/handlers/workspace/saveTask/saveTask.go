@@ -0,0 +1,120 @@
+package saveTask
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	WorkspaceID int64    `json:"workspace_id" validate:"required"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Date        string   `json:"date" validate:"required,datetime=2006-01-02"`
+	Status      string   `json:"status,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Assignees   []string `json:"assignees,omitempty"`
+}
+
+type Response struct {
+	response.Response
+	ID int64 `json:"id"`
+}
+
+type TaskSaver interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	SaveWorkspaceTask(ctx context.Context, workspaceID int64, task storage.Task, assignees []string) (int64, error)
+}
+
+// Save workspace task
+// @Summary      Save workspace task
+// @Description  Adds a task owned by the current user to a workspace, assigned to some of its members
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task"
+// @Success      200  {object}	Response "ID of the task"
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /workspace/task [post]
+func New(log *slog.Logger, saver TaskSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.saveTask.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		_, err = saver.GetWorkspace(r.Context(), req.WorkspaceID, user)
+		if errors.Is(err, storage.ErrNotMember) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("not a workspace member"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to save task"))
+			return
+		}
+
+		task := storage.Task{
+			Title:       req.Title,
+			Description: req.Description,
+			Owner:       user,
+			Date:        req.Date,
+			Status:      req.Status,
+			Type:        req.Type,
+		}
+
+		id, err := saver.SaveWorkspaceTask(r.Context(), req.WorkspaceID, task, req.Assignees)
+		if errors.Is(err, storage.ErrNotMember) {
+			log.InfoContext(r.Context(), "assignee is not a member", sl.Err(err))
+			render.JSON(w, r, response.Error("assignees must be workspace members"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to save task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to save task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace task saved",
+			slog.Int64("workspace_id", req.WorkspaceID),
+			slog.Int64("id", id),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+			ID:       id,
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package setMember
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	WorkspaceID int64  `json:"workspace_id" validate:"required"`
+	Username    string `json:"username" validate:"required"`
+	Role        string `json:"role" validate:"required,oneof=owner member"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type MemberSetter interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	SetWorkspaceMember(ctx context.Context, workspaceID int64, username string, role string) error
+}
+
+// Set workspace member
+// @Summary      Set workspace member
+// @Description  Adds a member to a workspace or changes its role, only workspace owners may
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "workspace, username and role"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      500
+// @Router       /workspace/members [post]
+func New(log *slog.Logger, setter MemberSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.setMember.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		ws, err := setter.GetWorkspace(r.Context(), req.WorkspaceID, auth.User(r.Context()))
+		if err != nil && !errors.Is(err, storage.ErrNotMember) {
+			log.ErrorContext(r.Context(), "failed to get workspace", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to set member"))
+			return
+		}
+		if err != nil || ws.Role != storage.WorkspaceRoleOwner {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		// The creator stays an owner so a workspace always has one.
+		if req.Username == ws.Owner {
+			render.JSON(w, r, response.Error("cannot change the workspace creator"))
+			return
+		}
+
+		if err := setter.SetWorkspaceMember(r.Context(), req.WorkspaceID, req.Username, req.Role); err != nil {
+			log.ErrorContext(r.Context(), "failed to set member", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to set member"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace member set",
+			slog.Int64("workspace_id", req.WorkspaceID),
+			slog.String("username", req.Username),
+			slog.String("role", req.Role),
+		)
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
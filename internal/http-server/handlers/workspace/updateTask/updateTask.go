@@ -0,0 +1,177 @@
+package updateTask
+
+import (
+	"context"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/workspace"
+	"daytask/internal/storage"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// Request changes only the fields that are set. Assignees may only change
+// the status.
+type Request struct {
+	ID          int64     `json:"id" validate:"required"`
+	Title       *string   `json:"title,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Date        *string   `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Status      *string   `json:"status,omitempty"`
+	Type        *string   `json:"type,omitempty"`
+	Assignees   *[]string `json:"assignees,omitempty"`
+}
+
+type Response struct {
+	response.Response
+}
+
+type TaskUpdater interface {
+	GetTask(ctx context.Context, id int64) (storage.Task, error)
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+	UpdateWorkspaceTask(ctx context.Context, task storage.Task) error
+	UpdateTaskStatus(ctx context.Context, id int64, status string) error
+	SetTaskAssignees(ctx context.Context, taskID int64, workspaceID int64, assignees []string) error
+}
+
+// Update workspace task
+// @Summary      Update workspace task
+// @Description  Changes a workspace task. Its owner and the workspace owners may change everything, assignees only the status
+// @Tags         workspace
+// @Accept       json
+// @Produce      json
+// @Param        request  body  Request  true  "task ID and the fields to change"
+// @Success      200  {object}	Response
+// @Failure      400
+// @Failure      403
+// @Failure      404
+// @Failure      500
+// @Router       /workspace/task [patch]
+func New(log *slog.Logger, updater TaskUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.workspace.updateTask.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to decode request body", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.ErrorContext(r.Context(), "invalid request", sl.Err(err))
+			render.JSON(w, r, response.ValidationError(validateErr))
+			return
+		}
+
+		user := auth.User(r.Context())
+
+		task, ws, err := load(r.Context(), updater, req.ID, user)
+		if errors.Is(err, storage.ErrTaskNotFound) {
+			render.JSON(w, r, response.Error("task not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrNotMember) {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("not a workspace member"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to get task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to update task"))
+			return
+		}
+
+		statusOnly := req.Title == nil && req.Description == nil && req.Date == nil && req.Type == nil && req.Assignees == nil
+
+		allowed := workspace.CanEdit(task, user, ws.Role)
+		if statusOnly {
+			allowed = workspace.CanUpdateStatus(task, user, ws.Role)
+		}
+		if !allowed {
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, response.Error("forbidden"))
+			return
+		}
+
+		if statusOnly {
+			if req.Status != nil {
+				err = updater.UpdateTaskStatus(r.Context(), task.ID, *req.Status)
+			}
+		} else {
+			err = update(r.Context(), updater, task, req)
+		}
+		if errors.Is(err, storage.ErrNotMember) {
+			log.InfoContext(r.Context(), "assignee is not a member", sl.Err(err))
+			render.JSON(w, r, response.Error("assignees must be workspace members"))
+			return
+		}
+		if err != nil {
+			log.ErrorContext(r.Context(), "failed to update task", sl.Err(err))
+			render.JSON(w, r, response.Error("failed to update task"))
+			return
+		}
+
+		log.InfoContext(r.Context(), "workspace task updated", slog.Int64("id", task.ID))
+
+		render.JSON(w, r, Response{
+			Response: response.OK(),
+		})
+	}
+}
+
+// load returns a workspace task and its workspace as seen by user.
+func load(ctx context.Context, updater TaskUpdater, id int64, user string) (storage.Task, storage.Workspace, error) {
+	task, err := updater.GetTask(ctx, id)
+	if err != nil {
+		return storage.Task{}, storage.Workspace{}, err
+	}
+
+	if task.WorkspaceID == nil {
+		return storage.Task{}, storage.Workspace{}, storage.ErrTaskNotFound
+	}
+
+	ws, err := updater.GetWorkspace(ctx, *task.WorkspaceID, user)
+	if err != nil {
+		return storage.Task{}, storage.Workspace{}, err
+	}
+
+	return task, ws, nil
+}
+
+func update(ctx context.Context, updater TaskUpdater, task storage.Task, req Request) error {
+	set := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	set(&task.Title, req.Title)
+	set(&task.Description, req.Description)
+	set(&task.Date, req.Date)
+	set(&task.Status, req.Status)
+	set(&task.Type, req.Type)
+
+	if err := updater.UpdateWorkspaceTask(ctx, task); err != nil {
+		return err
+	}
+
+	if req.Assignees != nil {
+		return updater.SetTaskAssignees(ctx, task.ID, *task.WorkspaceID, *req.Assignees)
+	}
+
+	return nil
+}
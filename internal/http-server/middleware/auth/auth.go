@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"daytask/internal/lib/api/response"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/lib/login"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type ctxKey struct{}
+
+// Credentials is a set of Basic auth accounts that can be replaced while the
+// server is running.
+type Credentials struct {
+	users atomic.Pointer[map[string]string]
+}
+
+func NewCredentials(users map[string]string) *Credentials {
+	c := &Credentials{}
+	c.Set(users)
+
+	return c
+}
+
+// Set replaces all accounts, requests already authenticated are not affected.
+func (c *Credentials) Set(users map[string]string) {
+	copied := make(map[string]string, len(users))
+	for user, password := range users {
+		copied[user] = password
+	}
+
+	c.users.Store(&copied)
+}
+
+func (c *Credentials) Valid(user, password string) bool {
+	expected, ok := (*c.users.Load())[user]
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
+
+// Authenticate makes c an Authenticator without any lockout, every user is
+// a member.
+func (c *Credentials) Authenticate(_ context.Context, user, password, _ string) (storage.User, error) {
+	if !c.Valid(user, password) {
+		return storage.User{}, storage.ErrWrongPassword
+	}
+
+	return storage.User{Username: user, Role: storage.RoleMember}, nil
+}
+
+// Authenticator checks the password of a login attempt from ip, see
+// login.Guard.
+type Authenticator interface {
+	Authenticate(ctx context.Context, user, password, ip string) (storage.User, error)
+}
+
+// New is chi's middleware.BasicAuth over an Authenticator. Logins locked out
+// after too many failures are answered with 429 Too Many Requests and a
+// Retry-After header, disabled accounts with 403 Forbidden. The authenticated
+// user is stored in the request context, see User and Role, and is the
+// storage.Actor of the changes made by the request.
+func New(log *slog.Logger, realm string, a Authenticator) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/auth"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok {
+				unauthorized(w, realm)
+				return
+			}
+
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
+
+			account, err := a.Authenticate(r.Context(), user, password, ip)
+
+			var locked *login.LockedError
+			switch {
+			case err == nil:
+			case errors.As(err, &locked):
+				retry := math.Ceil(time.Until(locked.Until).Seconds())
+				w.Header().Set("Retry-After", strconv.Itoa(int(max(retry, 1))))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("too many failed logins"))
+				return
+			case errors.Is(err, storage.ErrWrongPassword):
+				unauthorized(w, realm)
+				return
+			case errors.Is(err, storage.ErrUserDisabled):
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("account disabled"))
+				return
+			default:
+				log.ErrorContext(r.Context(), "failed to authenticate",
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					sl.Err(err),
+				)
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("internal error"))
+				return
+			}
+
+			ctx := WithUser(r.Context(), account)
+			ctx = storage.WithRemoteAddr(ctx, r.RemoteAddr)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func unauthorized(w http.ResponseWriter, realm string) {
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// RequireRole answers 403 Forbidden to users whose role is not one of roles.
+// It must run after New.
+func RequireRole(roles ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			if !slices.Contains(roles, Role(r.Context())) {
+				render.Status(r, http.StatusForbidden)
+				render.JSON(w, r, response.Error("forbidden"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// WithUser returns ctx authenticated as account, which is also the
+// storage.Actor of the changes made with it. Servers other than the HTTP one
+// use it to share User and Role.
+func WithUser(ctx context.Context, account storage.User) context.Context {
+	ctx = context.WithValue(ctx, ctxKey{}, account)
+
+	return storage.WithActor(ctx, account.Username)
+}
+
+// User returns the username authenticated by New, or "" if there is none.
+func User(ctx context.Context) string {
+	account, _ := ctx.Value(ctxKey{}).(storage.User)
+	return account.Username
+}
+
+// Role returns the role of the user authenticated by New, or "" if there is
+// none.
+func Role(ctx context.Context) string {
+	account, _ := ctx.Value(ctxKey{}).(storage.User)
+	return account.Role
+}
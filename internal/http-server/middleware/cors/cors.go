@@ -0,0 +1,48 @@
+package cors
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-chi/cors"
+)
+
+// Origins is the set of allowed origins, it can be replaced while the server
+// is running.
+type Origins struct {
+	allowed atomic.Pointer[[]string]
+}
+
+func NewOrigins(allowed []string) *Origins {
+	o := &Origins{}
+	o.Set(allowed)
+
+	return o
+}
+
+func (o *Origins) Set(allowed []string) {
+	copied := append([]string(nil), allowed...)
+	o.allowed.Store(&copied)
+}
+
+func (o *Origins) Allowed(origin string) bool {
+	for _, a := range *o.allowed.Load() {
+		if a == "*" || a == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// New handles CORS preflight and response headers for the current Origins.
+func New(o *Origins) func(next http.Handler) http.Handler {
+	return cors.Handler(cors.Options{
+		AllowOriginFunc: func(_ *http.Request, origin string) bool {
+			return o.Allowed(origin)
+		},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Request-Id"},
+		MaxAge:         300,
+	})
+}
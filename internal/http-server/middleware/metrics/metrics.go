@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"daytask/internal/lib/metrics"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const unmatchedRoute = "unmatched"
+
+// New records request counts, latency and in-flight requests per chi route
+// pattern. It must be mounted on the root router so the pattern can be
+// resolved before the request is routed.
+func New(m *metrics.HTTP) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			route := routePattern(r)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			done := m.Start(route, r.Method)
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				done(status)
+			}()
+
+			next.ServeHTTP(ww, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// routePattern resolves the pattern r will be routed to, e.g. "/task/day",
+// keeping the label set bounded regardless of the requested path.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return unmatchedRoute
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, r.URL.Path) {
+		return unmatchedRoute
+	}
+
+	return tctx.RoutePattern()
+}
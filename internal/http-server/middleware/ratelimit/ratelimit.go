@@ -0,0 +1,244 @@
+package ratelimit
+
+import (
+	"context"
+	"daytask/internal/config"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/api/response"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/time/rate"
+)
+
+// idleTTL is how long an unused bucket is kept. A bucket idle for longer has
+// refilled completely, so dropping it does not change any decision.
+const idleTTL = 10 * time.Minute
+
+type class int
+
+const (
+	read class = iota
+	write
+)
+
+type key struct {
+	scope string // "user" or "ip"
+	class class
+	id    string
+}
+
+type bucket struct {
+	lim      *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps the token buckets of every user and remote IP.
+type Limiter struct {
+	mu      sync.Mutex
+	cfg     config.RateLimit
+	buckets map[key]*bucket
+	now     func() time.Time
+}
+
+func NewLimiter(cfg config.RateLimit) *Limiter {
+	return &Limiter{
+		cfg:     cfg,
+		buckets: make(map[key]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Set replaces the limits. Existing buckets are dropped when the limits
+// change, so every client starts over with a full budget.
+func (l *Limiter) Set(cfg config.RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if cfg != l.cfg {
+		l.cfg = cfg
+		l.buckets = make(map[key]*bucket)
+	}
+}
+
+// Run evicts idle buckets until ctx is cancelled.
+func (l *Limiter) Run(ctx context.Context) {
+	ticker := time.NewTicker(idleTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.evict()
+		}
+	}
+}
+
+func (l *Limiter) evict() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := l.now().Add(-idleTTL)
+	for k, b := range l.buckets {
+		if b.lastSeen.Before(cutoff) {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// decision is the outcome for the most restrictive bucket of a request.
+type decision struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+// allow takes a token from the IP bucket and, for authenticated requests,
+// the user bucket of the request's class. Tokens are only taken if every
+// bucket has one, so rejected requests do not drain the others.
+func (l *Limiter) allow(ip, user string, c class) (decision, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.cfg.Enabled {
+		return decision{}, false
+	}
+
+	type check struct {
+		lc config.Limit
+		b  *bucket
+		r  *rate.Reservation
+	}
+
+	now := l.now()
+	var checks []check
+	allowed := true
+
+	for _, s := range []struct {
+		scope    string
+		scopeCfg config.RateLimitScope
+		id       string
+	}{
+		{"ip", l.cfg.IP, ip},
+		{"user", l.cfg.User, user},
+	} {
+		lc := s.scopeCfg.Read
+		if c == write {
+			lc = s.scopeCfg.Write
+		}
+		if lc.Rate == 0 || s.id == "" {
+			continue
+		}
+
+		k := key{scope: s.scope, class: c, id: s.id}
+		b, ok := l.buckets[k]
+		if !ok {
+			b = &bucket{lim: rate.NewLimiter(rate.Limit(lc.Rate), lc.Burst)}
+			l.buckets[k] = b
+		}
+		b.lastSeen = now
+
+		r := b.lim.ReserveN(now, 1)
+		allowed = allowed && r.OK() && r.DelayFrom(now) == 0
+		checks = append(checks, check{lc: lc, b: b, r: r})
+	}
+
+	if !allowed {
+		for _, ch := range checks {
+			ch.r.CancelAt(now)
+		}
+	}
+
+	result := decision{allowed: true, remaining: math.MaxInt}
+	for _, ch := range checks {
+		tokens := ch.b.lim.TokensAt(now)
+		d := decision{allowed: allowed, limit: ch.lc.Burst}
+		d.remaining = int(math.Max(0, math.Floor(tokens)))
+		d.reset = seconds((float64(ch.lc.Burst) - tokens) / ch.lc.Rate)
+
+		// The bucket short of a token decides a rejection.
+		if !allowed {
+			if tokens >= 1 {
+				continue
+			}
+			d.retryAfter = seconds((1 - tokens) / ch.lc.Rate)
+		}
+
+		if result.allowed != d.allowed || d.remaining < result.remaining {
+			result = d
+		}
+	}
+
+	return result, len(checks) > 0
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(math.Max(0, s))) * time.Second
+}
+
+// New rejects requests over budget with 429 Too Many Requests and reports
+// the budget of every limited request in the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers. It must run after the
+// auth middleware to limit per user.
+func New(log *slog.Logger, l *Limiter) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		log := log.With(
+			slog.String("component", "middleware/ratelimit"),
+		)
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			c := write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				c = read
+			}
+
+			ip := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+				ip = host
+			}
+			user := auth.User(r.Context())
+
+			d, limited := l.allow(ip, user, c)
+			if !limited {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(d.limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(d.remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(int(d.reset.Seconds())))
+
+			if !d.allowed {
+				log.WarnContext(r.Context(), "rate limit exceeded",
+					slog.String("ip", ip),
+					slog.String("user", user),
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+				)
+
+				h.Set("Retry-After", strconv.Itoa(int(d.retryAfter.Seconds())))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, response.Error("rate limit exceeded"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
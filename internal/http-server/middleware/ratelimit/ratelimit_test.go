@@ -0,0 +1,111 @@
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/http-server/middleware/ratelimit"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+)
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter(config.RateLimit{
+		Enabled: true,
+		User: config.RateLimitScope{
+			Read:  config.Limit{Rate: 100, Burst: 100},
+			Write: config.Limit{Rate: 0.5, Burst: 2},
+		},
+		IP: config.RateLimitScope{
+			Read:  config.Limit{Rate: 100, Burst: 3},
+			Write: config.Limit{Rate: 100, Burst: 100},
+		},
+	})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	creds := auth.NewCredentials(map[string]string{"alice": "a", "bob": "b"})
+	handler := auth.New(slogdiscard.NewDiscardLogger(), "test", creds)(ratelimit.New(slogdiscard.NewDiscardLogger(), limiter)(ok))
+
+	do := func(method, user, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/task/save", nil)
+		req.SetBasicAuth(user, user[:1])
+		req.RemoteAddr = ip + ":1234"
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// Writes are limited per user, whatever the IP.
+	rr := do(http.MethodPost, "alice", "10.0.0.1")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "alice", "10.0.0.2").Code)
+
+	rr = do(http.MethodPost, "alice", "10.0.0.3")
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "2", rr.Header().Get("Retry-After"))
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+	// Another user has a budget of their own, and reads are a separate one.
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "bob", "10.0.0.1").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "alice", "10.0.0.3").Code)
+
+	// Reads are limited per IP across users.
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "bob", "10.0.0.3").Code)
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "bob", "10.0.0.3").Code)
+	require.Equal(t, http.StatusTooManyRequests, do(http.MethodGet, "alice", "10.0.0.3").Code)
+
+	// Reloaded limits start every client over.
+	limiter.Set(config.RateLimit{})
+	rr = do(http.MethodPost, "alice", "10.0.0.3")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitRejectedKeepsBudget(t *testing.T) {
+	limiter := ratelimit.NewLimiter(config.RateLimit{
+		Enabled: true,
+		User: config.RateLimitScope{
+			Write: config.Limit{Rate: 0.01, Burst: 1},
+		},
+		IP: config.RateLimitScope{
+			Write: config.Limit{Rate: 0.01, Burst: 3},
+		},
+	})
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	creds := auth.NewCredentials(map[string]string{"alice": "a", "bob": "b"})
+	handler := auth.New(slogdiscard.NewDiscardLogger(), "test", creds)(ratelimit.New(slogdiscard.NewDiscardLogger(), limiter)(ok))
+
+	do := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/task/save", nil)
+		req.SetBasicAuth(user, user[:1])
+		req.RemoteAddr = "10.0.0.1:1234"
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	require.Equal(t, http.StatusOK, do("alice").Code)
+
+	// Requests rejected by the user bucket leave the IP bucket alone.
+	for i := 0; i < 3; i++ {
+		rr := do("alice")
+		require.Equal(t, http.StatusTooManyRequests, rr.Code)
+		require.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	}
+
+	rr := do("bob")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+}
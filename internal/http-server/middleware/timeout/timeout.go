@@ -0,0 +1,51 @@
+package timeout
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts are the per-request read and write deadlines, they can be changed
+// while the server is running and apply to requests started afterwards.
+type Timeouts struct {
+	read  atomic.Int64
+	write atomic.Int64
+}
+
+func NewTimeouts(read, write time.Duration) *Timeouts {
+	t := &Timeouts{}
+	t.Set(read, write)
+
+	return t
+}
+
+func (t *Timeouts) Set(read, write time.Duration) {
+	t.read.Store(int64(read))
+	t.write.Store(int64(write))
+}
+
+// New sets the read and write deadlines of every request to the current
+// Timeouts. It replaces http.Server's ReadTimeout and WriteTimeout, which
+// cannot be changed once the server is serving. Zero disables a deadline.
+func New(t *Timeouts) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			rc := http.NewResponseController(w)
+			now := time.Now()
+
+			// Errors mean the underlying connection does not support
+			// deadlines, e.g. in tests with httptest.ResponseRecorder.
+			if d := time.Duration(t.read.Load()); d > 0 {
+				_ = rc.SetReadDeadline(now.Add(d))
+			}
+			if d := time.Duration(t.write.Load()); d > 0 {
+				_ = rc.SetWriteDeadline(now.Add(d))
+			}
+
+			next.ServeHTTP(w, r)
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
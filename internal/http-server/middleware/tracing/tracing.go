@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "daytask/internal/http-server"
+
+// New starts a server span for every request, continuing the trace of the
+// caller when a traceparent header is present. It must run after
+// middleware.RequestID so the span carries the request ID.
+func New() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		tracer := otel.Tracer(tracerName)
+		propagator := otel.GetTextMapPropagator()
+
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+					semconv.ClientAddress(r.RemoteAddr),
+					semconv.UserAgentOriginal(r.UserAgent()),
+					attribute.String("request_id", middleware.GetReqID(r.Context())),
+				),
+			)
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+
+				// The route is only known once chi has routed the request.
+				if rctx := chi.RouteContext(r.Context()); rctx != nil {
+					if pattern := rctx.RoutePattern(); pattern != "" {
+						span.SetName(r.Method + " " + pattern)
+						span.SetAttributes(semconv.HTTPRoute(pattern))
+					}
+				}
+			}()
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
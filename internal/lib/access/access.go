@@ -0,0 +1,69 @@
+// Package access holds the rules deciding who may see and change a task,
+// shared by the HTTP, WebSocket, gRPC and GraphQL servers.
+package access
+
+import (
+	"context"
+	"daytask/internal/storage"
+	"errors"
+	"slices"
+)
+
+// WorkspaceGetter looks up the membership of a user in a workspace, failing
+// with storage.ErrNotMember for non-members.
+type WorkspaceGetter interface {
+	GetWorkspace(ctx context.Context, id int64, username string) (storage.Workspace, error)
+}
+
+// CanSee reports whether user may see t: a workspace task if user is a
+// member of the workspace, a personal task if user owns it or is assigned to
+// it.
+func CanSee(ctx context.Context, workspaces WorkspaceGetter, t storage.Task, user string) (bool, error) {
+	if t.WorkspaceID == nil {
+		return CanChange(t, user), nil
+	}
+
+	_, err := workspaces.GetWorkspace(ctx, *t.WorkspaceID, user)
+	if errors.Is(err, storage.ErrNotMember) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Visible returns the tasks of tasks user may see, see CanSee.
+func Visible(ctx context.Context, workspaces WorkspaceGetter, tasks []storage.Task, user string) ([]storage.Task, error) {
+	member := make(map[int64]bool)
+
+	var out []storage.Task
+	for _, t := range tasks {
+		ok := CanChange(t, user)
+		if t.WorkspaceID != nil {
+			var known bool
+			if ok, known = member[*t.WorkspaceID]; !known {
+				var err error
+				if ok, err = CanSee(ctx, workspaces, t, user); err != nil {
+					return nil, err
+				}
+				member[*t.WorkspaceID] = ok
+			}
+		}
+		if ok {
+			out = append(out, t)
+		}
+	}
+
+	return out, nil
+}
+
+// CanChange reports whether user may change or trash the personal task t:
+// its owner and assignees may.
+func CanChange(t storage.Task, user string) bool {
+	return t.Owner == user || slices.Contains(t.Assignees, user)
+}
+
+// CanChangeOwner reports whether a user with role may hand a personal task
+// to another owner: only admins may.
+func CanChangeOwner(role string) bool {
+	return role == storage.RoleAdmin
+}
@@ -0,0 +1,61 @@
+package access_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/lib/access"
+	"daytask/internal/storage"
+)
+
+// members is a workspace store where every workspace has the same members.
+type members []string
+
+func (m members) GetWorkspace(_ context.Context, id int64, username string) (storage.Workspace, error) {
+	for _, u := range m {
+		if u == username {
+			return storage.Workspace{ID: id}, nil
+		}
+	}
+
+	return storage.Workspace{}, storage.ErrNotMember
+}
+
+func TestAccess(t *testing.T) {
+	ctx := context.Background()
+	ws := int64(1)
+	personal := storage.Task{ID: 1, Owner: "alice", Assignees: []string{"bob"}}
+	shared := storage.Task{ID: 2, Owner: "alice", WorkspaceID: &ws}
+	workspaces := members{"alice", "carol"}
+
+	tests := []struct {
+		user             string
+		personal, shared bool
+		visible          int
+	}{
+		{"alice", true, true, 2},
+		{"bob", true, false, 1},
+		{"carol", false, true, 1},
+		{"dave", false, false, 0},
+	}
+
+	for _, tt := range tests {
+		ok, err := access.CanSee(ctx, workspaces, personal, tt.user)
+		require.NoError(t, err)
+		require.Equal(t, tt.personal, ok, tt.user)
+		require.Equal(t, tt.personal, access.CanChange(personal, tt.user), tt.user)
+
+		ok, err = access.CanSee(ctx, workspaces, shared, tt.user)
+		require.NoError(t, err)
+		require.Equal(t, tt.shared, ok, tt.user)
+
+		visible, err := access.Visible(ctx, workspaces, []storage.Task{personal, shared}, tt.user)
+		require.NoError(t, err)
+		require.Len(t, visible, tt.visible, tt.user)
+	}
+
+	require.True(t, access.CanChangeOwner(storage.RoleAdmin))
+	require.False(t, access.CanChangeOwner(storage.RoleMember))
+}
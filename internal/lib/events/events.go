@@ -0,0 +1,170 @@
+// Package events is an in-process bus of task changes. Storage publishes
+// every committed change and live clients subscribe to it, resuming after
+// the last event they saw as long as it is still in the retention buffer.
+package events
+
+import (
+	"daytask/internal/storage"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of events a subscriber may lag behind
+// before it is dropped and has to resume.
+const subscriberBuffer = 64
+
+// Event is a change of Task, Type is one of the storage.EventTask* events.
+// Updates carry the fields that changed. IDs increase by one with every
+// event and restart with the process.
+type Event struct {
+	ID      uint64           `json:"id"`
+	Type    string           `json:"type"`
+	At      time.Time        `json:"at"`
+	Task    storage.Task     `json:"task"`
+	Changes []storage.Change `json:"changes,omitempty"`
+}
+
+// Bus fans published events out to the subscribers and keeps up to size of
+// them for at most retention so subscribers can resume. A nil *Bus drops
+// every event.
+type Bus struct {
+	size      int
+	retention time.Duration
+
+	mu     sync.Mutex
+	lastID uint64
+	buf    []Event
+	subs   map[*Subscription]struct{}
+	closed bool
+	done   chan struct{}
+}
+
+func NewBus(size int, retention time.Duration) *Bus {
+	return &Bus{size: size, retention: retention, subs: make(map[*Subscription]struct{}), done: make(chan struct{})}
+}
+
+// Publish sends a change of task to every subscriber.
+func (b *Bus) Publish(typ string, task storage.Task, changes []storage.Change) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	e := Event{ID: b.lastID, Type: typ, At: time.Now(), Task: task, Changes: changes}
+
+	b.buf = append(b.expire(e.At), e)
+	if len(b.buf) > b.size {
+		b.buf = b.buf[len(b.buf)-b.size:]
+	}
+
+	for s := range b.subs {
+		select {
+		case s.c <- e:
+		default:
+			// Too slow, it will resume from its last event.
+			b.drop(s)
+		}
+	}
+}
+
+// Subscribe returns the events published after lastID, which are still
+// retained, and a subscription to the ones to come. A lastID of zero
+// subscribes to new events only. ok is false when events after lastID
+// were dropped from the buffer or lastID is from before a restart, so the
+// subscriber missed changes and has to reload.
+func (b *Bus) Subscribe(lastID uint64) (replay []Event, sub *Subscription, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub = &Subscription{bus: b, c: make(chan Event, subscriberBuffer)}
+	if b.closed {
+		close(sub.c)
+		return nil, sub, true
+	}
+	b.subs[sub] = struct{}{}
+
+	if lastID == 0 {
+		return nil, sub, true
+	}
+	if lastID > b.lastID {
+		return nil, sub, false
+	}
+
+	b.buf = b.expire(time.Now())
+	if lastID == b.lastID {
+		return nil, sub, true
+	}
+	if len(b.buf) == 0 || b.buf[0].ID > lastID+1 {
+		return nil, sub, false
+	}
+
+	replay = append(replay, b.buf[lastID+1-b.buf[0].ID:]...)
+
+	return replay, sub, true
+}
+
+// Close ends every subscription, to be called when the server shuts down.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	close(b.done)
+	for s := range b.subs {
+		b.drop(s)
+	}
+}
+
+// Done is closed once the bus is closed, telling subscribers whose channel
+// was closed not to resume.
+func (b *Bus) Done() <-chan struct{} {
+	return b.done
+}
+
+// expire returns the buffer without the events published before now minus
+// the retention.
+func (b *Bus) expire(now time.Time) []Event {
+	i := 0
+	for i < len(b.buf) && now.Sub(b.buf[i].At) > b.retention {
+		i++
+	}
+
+	return b.buf[i:]
+}
+
+func (b *Bus) drop(s *Subscription) {
+	if _, ok := b.subs[s]; ok {
+		delete(b.subs, s)
+		close(s.c)
+	}
+}
+
+// Subscription receives the events published after it was made.
+type Subscription struct {
+	bus *Bus
+	c   chan Event
+}
+
+// C is closed when the subscriber falls too far behind or the bus is closed.
+func (s *Subscription) C() <-chan Event {
+	return s.c
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	s.bus.drop(s)
+}
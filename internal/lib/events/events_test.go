@@ -0,0 +1,93 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/lib/events"
+	"daytask/internal/storage"
+)
+
+func ids(list []events.Event) []uint64 {
+	var out []uint64
+	for _, e := range list {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestBusResume(t *testing.T) {
+	b := events.NewBus(3, time.Hour)
+
+	_, live, ok := b.Subscribe(0)
+	require.True(t, ok)
+	defer live.Close()
+
+	for i := 0; i < 5; i++ {
+		b.Publish(storage.EventTaskCreated, storage.Task{ID: int64(i)}, nil)
+	}
+	require.Equal(t, uint64(1), (<-live.C()).ID)
+
+	// Events 3 to 5 are retained.
+	replay, sub, ok := b.Subscribe(2)
+	require.True(t, ok)
+	require.Equal(t, []uint64{3, 4, 5}, ids(replay))
+	sub.Close()
+
+	replay, sub, ok = b.Subscribe(5)
+	require.True(t, ok)
+	require.Empty(t, replay)
+	sub.Close()
+
+	// Event 2 was dropped.
+	_, sub, ok = b.Subscribe(1)
+	require.False(t, ok)
+	sub.Close()
+
+	// From before a restart.
+	_, sub, ok = b.Subscribe(42)
+	require.False(t, ok)
+	sub.Close()
+}
+
+func TestBusRetention(t *testing.T) {
+	b := events.NewBus(10, 20*time.Millisecond)
+	b.Publish(storage.EventTaskCreated, storage.Task{}, nil)
+	b.Publish(storage.EventTaskUpdated, storage.Task{}, nil)
+
+	time.Sleep(40 * time.Millisecond)
+
+	_, sub, ok := b.Subscribe(1)
+	require.False(t, ok)
+	sub.Close()
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	b := events.NewBus(1000, time.Hour)
+
+	_, sub, _ := b.Subscribe(0)
+	for i := 0; i < 1000; i++ {
+		b.Publish(storage.EventTaskCreated, storage.Task{}, nil)
+	}
+
+	n := 0
+	for range sub.C() {
+		n++
+	}
+	require.Less(t, n, 1000)
+
+	// Closing after the bus dropped it is fine.
+	sub.Close()
+
+	_, sub, _ = b.Subscribe(0)
+	b.Close()
+	_, open := <-sub.C()
+	require.False(t, open)
+}
+
+func TestNilBus(t *testing.T) {
+	var b *events.Bus
+	b.Publish(storage.EventTaskCreated, storage.Task{}, nil)
+}
@@ -0,0 +1,369 @@
+package login
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// purgeInterval is how often counters that expired are deleted.
+const purgeInterval = time.Hour
+
+// verifiedTTL is how long a checked password of a stored account is
+// remembered, sparing a bcrypt comparison on every Basic-auth request.
+const verifiedTTL = time.Minute
+
+// LockedError is returned by Authenticate while the username or the remote IP
+// is locked out. It matches storage.ErrLoginLocked.
+type LockedError struct {
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s until %s", storage.ErrLoginLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *LockedError) Unwrap() error {
+	return storage.ErrLoginLocked
+}
+
+// Store keeps the accounts and the failed login counters.
+type Store interface {
+	UserByName(ctx context.Context, username string) (storage.User, error)
+	GetLoginFailures(ctx context.Context, keys ...storage.LoginKey) ([]storage.LoginFailures, error)
+	RecordLoginFailure(ctx context.Context, key storage.LoginKey, now, since time.Time, limit int, lockedUntil time.Time) (storage.LoginFailures, error)
+	ResetLoginFailures(ctx context.Context, keys ...storage.LoginKey) (int64, error)
+	PurgeLoginFailures(ctx context.Context, failedBefore, lockedBefore time.Time) (int64, error)
+}
+
+// StaticUsers are the accounts from the config file, checked before the
+// ones in the Store.
+type StaticUsers interface {
+	Valid(user, password string) bool
+}
+
+// Guard checks passwords and counts the failures per username and remote IP.
+// Every failure delays the response, and too many lock the username or IP
+// out for a while, see config.Lockout.
+type Guard struct {
+	log    *slog.Logger
+	store  Store
+	static StaticUsers
+
+	mu    sync.Mutex
+	cfg   config.Lockout
+	roles map[string]string
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration)
+
+	// verified holds the passwords found right per username, as HMACs under
+	// key, never in the clear.
+	key        []byte
+	verifiedMu sync.Mutex
+	verified   map[string]verifiedPassword
+}
+
+// verifiedPassword is a password found to match hash until expires.
+type verifiedPassword struct {
+	hash    string
+	sum     []byte
+	expires time.Time
+}
+
+// NewGuard returns a Guard checking static before the accounts in store.
+// roles gives the role of static users, members by default.
+func NewGuard(log *slog.Logger, store Store, static StaticUsers, cfg config.Lockout, roles map[string]string) *Guard {
+	g := &Guard{
+		log:    log.With(slog.String("component", "login")),
+		store:  store,
+		static: static,
+		now:    time.Now,
+		sleep:  sleep,
+
+		key:      make([]byte, sha256.Size),
+		verified: make(map[string]verifiedPassword),
+	}
+	if _, err := rand.Read(g.key); err != nil {
+		panic(err)
+	}
+	g.Set(cfg, roles)
+
+	return g
+}
+
+// Set replaces the lockout settings and the roles of static users. Existing
+// counters are kept and judged by the new settings.
+func (g *Guard) Set(cfg config.Lockout, roles map[string]string) {
+	copied := make(map[string]string, len(roles))
+	for user, role := range roles {
+		copied[user] = role
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.cfg = cfg
+	g.roles = copied
+}
+
+func (g *Guard) config() config.Lockout {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.cfg
+}
+
+func (g *Guard) staticRole(username string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if role, ok := g.roles[username]; ok {
+		return role
+	}
+
+	return storage.RoleMember
+}
+
+// Authenticate returns the account of username if password is right. It
+// fails with storage.ErrWrongPassword for a wrong password or an unknown
+// username, with a *LockedError while either username or ip is locked out,
+// in which case password is not checked at all, and with
+// storage.ErrUserDisabled for a disabled account.
+func (g *Guard) Authenticate(ctx context.Context, username, password, ip string) (storage.User, error) {
+	const op = "lib.login.Authenticate"
+
+	cfg := g.config()
+
+	keys := []storage.LoginKey{{Kind: storage.LoginKeyUser, Value: username}}
+	if ip != "" {
+		keys = append(keys, storage.LoginKey{Kind: storage.LoginKeyIP, Value: ip})
+	}
+
+	var failures []storage.LoginFailures
+	if cfg.Enabled {
+		var err error
+		failures, err = g.store.GetLoginFailures(ctx, keys...)
+		if err != nil {
+			return storage.User{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		now := g.now()
+		for _, f := range failures {
+			if now.Before(f.LockedUntil) {
+				return storage.User{}, fmt.Errorf("%s: %w", op, &LockedError{Until: f.LockedUntil})
+			}
+		}
+	}
+
+	user, err := g.verify(ctx, username, password)
+	if errors.Is(err, storage.ErrWrongPassword) && cfg.Enabled {
+		g.fail(ctx, cfg, keys, ip)
+	}
+	if err != nil {
+		return storage.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Only the username starts over: a valid account must not clear the
+	// counter of an address that is guessing other usernames.
+	for _, f := range failures {
+		if f.Kind != storage.LoginKeyUser {
+			continue
+		}
+		if _, err := g.store.ResetLoginFailures(ctx, f.LoginKey); err != nil {
+			g.log.ErrorContext(ctx, "failed to reset login failures", slog.String("user", username), sl.Err(err))
+		}
+	}
+
+	return user, nil
+}
+
+// dummyHash is compared against when the username is unknown, so that
+// unknown and existing usernames take the same time to reject.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("daytask"), bcrypt.DefaultCost)
+
+func (g *Guard) verify(ctx context.Context, username, password string) (storage.User, error) {
+	if g.static.Valid(username, password) {
+		return storage.User{Username: username, Role: g.staticRole(username)}, nil
+	}
+
+	user, err := g.store.UserByName(ctx, username)
+	if errors.Is(err, storage.ErrLoginNotFound) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return storage.User{}, storage.ErrWrongPassword
+	}
+	if err != nil {
+		return storage.User{}, err
+	}
+
+	if !g.isVerified(username, user.PasswordHash, password) {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+			return storage.User{}, storage.ErrWrongPassword
+		}
+		g.remember(username, user.PasswordHash, password)
+	}
+
+	// Checked after the password so that guessing does not reveal which
+	// accounts are disabled.
+	if user.Disabled {
+		return storage.User{}, storage.ErrUserDisabled
+	}
+
+	return user, nil
+}
+
+// isVerified reports whether password was found to match hash lately. A new
+// password hash of the account forgets the old password at once.
+func (g *Guard) isVerified(username, hash, password string) bool {
+	g.verifiedMu.Lock()
+	v, ok := g.verified[username]
+	g.verifiedMu.Unlock()
+
+	return ok && v.hash == hash && g.now().Before(v.expires) && hmac.Equal(v.sum, g.sum(password))
+}
+
+func (g *Guard) remember(username, hash, password string) {
+	v := verifiedPassword{hash: hash, sum: g.sum(password), expires: g.now().Add(verifiedTTL)}
+
+	g.verifiedMu.Lock()
+	defer g.verifiedMu.Unlock()
+
+	g.verified[username] = v
+}
+
+func (g *Guard) sum(password string) []byte {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte(password))
+
+	return mac.Sum(nil)
+}
+
+// fail counts a failed login for every key and holds the response back for
+// longer the more failures there are.
+func (g *Guard) fail(ctx context.Context, cfg config.Lockout, keys []storage.LoginKey, ip string) {
+	now := g.now()
+	failures := 0
+
+	for _, key := range keys {
+		limit := cfg.MaxFailures
+		if key.Kind == storage.LoginKeyIP {
+			limit = cfg.MaxIPFailures
+		}
+
+		f, err := g.store.RecordLoginFailure(ctx, key, now, now.Add(-cfg.Window), limit, now.Add(cfg.Duration))
+		if err != nil {
+			g.log.ErrorContext(ctx, "failed to record login failure", slog.Any("key", key), sl.Err(err))
+			continue
+		}
+
+		// Counters only grow while unlocked, so reaching the limit always
+		// means a new lock.
+		if f.Failures >= limit {
+			g.log.WarnContext(ctx, "login locked out",
+				slog.String("kind", key.Kind),
+				slog.String("value", key.Value),
+				slog.Time("until", f.LockedUntil),
+			)
+		}
+
+		failures = max(failures, f.Failures)
+	}
+
+	g.log.InfoContext(ctx, "login failed",
+		slog.String("user", keys[0].Value),
+		slog.String("ip", ip),
+		slog.Int("failures", failures),
+	)
+
+	g.sleep(ctx, Delay(cfg, failures))
+}
+
+// Delay returns how long the response to the nth consecutive failure is held
+// back.
+func Delay(cfg config.Lockout, failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+
+	d := cfg.BaseDelay
+	for i := 1; i < failures && d < cfg.MaxDelay; i++ {
+		d *= 2
+	}
+
+	return min(d, cfg.MaxDelay)
+}
+
+func sleep(ctx context.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+}
+
+// Run deletes expired counters until ctx is cancelled.
+func (g *Guard) Run(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.purge(ctx)
+		}
+	}
+}
+
+func (g *Guard) purge(ctx context.Context) {
+	cfg := g.config()
+	now := g.now()
+
+	g.verifiedMu.Lock()
+	for username, v := range g.verified {
+		if !now.Before(v.expires) {
+			delete(g.verified, username)
+		}
+	}
+	g.verifiedMu.Unlock()
+
+	// A counter is useless once its last failure left the window and its
+	// lock has expired.
+	n, err := g.store.PurgeLoginFailures(ctx, now.Add(-cfg.Window), now)
+	if err != nil {
+		g.log.ErrorContext(ctx, "failed to purge login failures", sl.Err(err))
+		return
+	}
+
+	if n > 0 {
+		g.log.DebugContext(ctx, "purged login failures", slog.Int64("count", n))
+	}
+}
+
+// HashPassword returns the hash of password to store with an account.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
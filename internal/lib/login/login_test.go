@@ -0,0 +1,214 @@
+package login_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/http-server/middleware/auth"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/lib/login"
+	"daytask/internal/storage"
+	"daytask/internal/storage/sqlite"
+)
+
+func newGuard(t *testing.T, cfg config.Lockout) (*login.Guard, *sqlite.Storage) {
+	t.Helper()
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), config.SQLite{MaxOpenConns: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	static := auth.NewCredentials(map[string]string{"alice": "secret"})
+
+	return login.NewGuard(slogdiscard.NewDiscardLogger(), s, static, cfg, map[string]string{"alice": storage.RoleAdmin}), s
+}
+
+var lockout = config.Lockout{
+	Enabled:       true,
+	MaxFailures:   3,
+	MaxIPFailures: 5,
+	Window:        time.Hour,
+	Duration:      time.Hour,
+	BaseDelay:     time.Millisecond,
+	MaxDelay:      2 * time.Millisecond,
+}
+
+func TestGuardLocksUsername(t *testing.T) {
+	ctx := context.Background()
+	g, s := newGuard(t, lockout)
+
+	for i := 0; i < lockout.MaxFailures; i++ {
+		_, err := g.Authenticate(ctx, "alice", "guess", "10.0.0.1")
+		require.ErrorIs(t, err, storage.ErrWrongPassword)
+	}
+
+	// Locked from every address, even with the right password.
+	_, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.2")
+	require.ErrorIs(t, err, storage.ErrLoginLocked)
+
+	var locked *login.LockedError
+	require.ErrorAs(t, err, &locked)
+	require.WithinDuration(t, time.Now().Add(lockout.Duration), locked.Until, time.Minute)
+
+	n, err := s.ResetLoginFailures(ctx, storage.LoginKey{Kind: storage.LoginKeyUser, Value: "alice"})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	user, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.2")
+	require.NoError(t, err)
+	require.Equal(t, "alice", user.Username)
+}
+
+func TestGuardLocksIP(t *testing.T) {
+	ctx := context.Background()
+	g, _ := newGuard(t, lockout)
+
+	// One guess per username stays under the username limit.
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		_, err := g.Authenticate(ctx, name, "guess", "10.0.0.1")
+		require.ErrorIs(t, err, storage.ErrWrongPassword)
+	}
+
+	_, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrLoginLocked)
+
+	_, err = g.Authenticate(ctx, "alice", "secret", "10.0.0.2")
+	require.NoError(t, err)
+}
+
+func TestGuardSuccessResetsUsername(t *testing.T) {
+	ctx := context.Background()
+	g, s := newGuard(t, lockout)
+
+	for i := 0; i < lockout.MaxFailures-1; i++ {
+		_, err := g.Authenticate(ctx, "alice", "guess", "10.0.0.1")
+		require.ErrorIs(t, err, storage.ErrWrongPassword)
+	}
+
+	_, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.NoError(t, err)
+
+	list, err := s.ListLoginFailures(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, storage.LoginKeyIP, list[0].Kind)
+	require.Equal(t, lockout.MaxFailures-1, list[0].Failures)
+}
+
+func TestGuardLockExpires(t *testing.T) {
+	ctx := context.Background()
+	cfg := lockout
+	cfg.Duration = 50 * time.Millisecond
+	g, _ := newGuard(t, cfg)
+
+	for i := 0; i < cfg.MaxFailures; i++ {
+		g.Authenticate(ctx, "alice", "guess", "10.0.0.1")
+	}
+
+	_, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrLoginLocked)
+
+	time.Sleep(2 * cfg.Duration)
+
+	_, err = g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestGuardStoredUsers(t *testing.T) {
+	ctx := context.Background()
+	g, s := newGuard(t, lockout)
+
+	hash, err := login.HashPassword("hunter2")
+	require.NoError(t, err)
+	_, err = s.CreateUser(ctx, "bob", hash, storage.RoleReadOnly)
+	require.NoError(t, err)
+
+	user, err := g.Authenticate(ctx, "bob", "hunter2", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "bob", user.Username)
+	require.Equal(t, storage.RoleReadOnly, user.Role)
+	require.NotZero(t, user.ID)
+
+	_, err = g.Authenticate(ctx, "bob", "hunter3", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrWrongPassword)
+
+	_, err = g.Authenticate(ctx, "nobody", "hunter2", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrWrongPassword)
+
+	require.NoError(t, s.SetUserDisabled(ctx, "bob", true))
+	_, err = g.Authenticate(ctx, "bob", "hunter2", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrUserDisabled)
+}
+
+func TestGuardPasswordChange(t *testing.T) {
+	ctx := context.Background()
+	g, s := newGuard(t, lockout)
+
+	hash, err := login.HashPassword("hunter2")
+	require.NoError(t, err)
+	_, err = s.CreateUser(ctx, "bob", hash, storage.RoleMember)
+	require.NoError(t, err)
+
+	// The second login is answered from the remembered password.
+	for i := 0; i < 2; i++ {
+		_, err = g.Authenticate(ctx, "bob", "hunter2", "10.0.0.1")
+		require.NoError(t, err)
+	}
+	_, err = g.Authenticate(ctx, "bob", "hunter3", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrWrongPassword)
+
+	// A new password forgets the old one right away.
+	hash, err = login.HashPassword("hunter3")
+	require.NoError(t, err)
+	require.NoError(t, s.SetUserPassword(ctx, "bob", hash))
+
+	_, err = g.Authenticate(ctx, "bob", "hunter2", "10.0.0.1")
+	require.ErrorIs(t, err, storage.ErrWrongPassword)
+	_, err = g.Authenticate(ctx, "bob", "hunter3", "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestGuardStaticRoles(t *testing.T) {
+	ctx := context.Background()
+	g, _ := newGuard(t, lockout)
+
+	user, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, storage.RoleAdmin, user.Role)
+
+	g.Set(lockout, nil)
+
+	user, err = g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, storage.RoleMember, user.Role)
+}
+
+func TestGuardLockoutDisabled(t *testing.T) {
+	ctx := context.Background()
+	g, s := newGuard(t, config.Lockout{})
+
+	for i := 0; i < 10; i++ {
+		_, err := g.Authenticate(ctx, "alice", "guess", "10.0.0.1")
+		require.ErrorIs(t, err, storage.ErrWrongPassword)
+	}
+
+	_, err := g.Authenticate(ctx, "alice", "secret", "10.0.0.1")
+	require.NoError(t, err)
+
+	list, err := s.ListLoginFailures(ctx)
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func TestDelay(t *testing.T) {
+	cfg := config.Lockout{BaseDelay: 250 * time.Millisecond, MaxDelay: time.Second}
+
+	for failures, want := range []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, time.Second, time.Second} {
+		require.Equal(t, want, login.Delay(cfg, failures), "failures %d", failures)
+	}
+}
@@ -0,0 +1,201 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "daytask"
+
+// NewRegistry returns a registry with the Go runtime and process collectors
+// already registered.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return reg
+}
+
+// HTTP holds the request metrics recorded by the metrics middleware.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
+
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	m := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route pattern, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route pattern, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "code"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests currently being served by route pattern.",
+		}, []string{"route"}),
+	}
+
+	reg.MustRegister(m.requests, m.duration, m.inFlight)
+
+	return m
+}
+
+// Start marks a request to route as in flight. The returned func must be
+// called with the response status once the request is served.
+func (m *HTTP) Start(route, method string) func(status int) {
+	start := time.Now()
+	inFlight := m.inFlight.WithLabelValues(route)
+	inFlight.Inc()
+
+	return func(status int) {
+		inFlight.Dec()
+
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(route, method, code).Inc()
+		m.duration.WithLabelValues(route, method, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Storage holds the metrics recorded by the storage layer. A nil *Storage
+// records nothing, so the storage can be used uninstrumented.
+type Storage struct {
+	duration     *prometheus.HistogramVec
+	errors       *prometheus.CounterVec
+	tasksCreated prometheus.Counter
+}
+
+func NewStorage(reg prometheus.Registerer) *Storage {
+	m := &Storage{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "query_duration_seconds",
+			Help:      "Storage operation latency by op.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"op"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "errors_total",
+			Help:      "Number of failed storage operations by op.",
+		}, []string{"op"}),
+		tasksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_created_total",
+			Help:      "Number of tasks created.",
+		}),
+	}
+
+	reg.MustRegister(m.duration, m.errors, m.tasksCreated)
+
+	return m
+}
+
+func (m *Storage) ObserveQuery(op string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	m.duration.WithLabelValues(op).Observe(d.Seconds())
+	if err != nil {
+		m.errors.WithLabelValues(op).Inc()
+	}
+}
+
+func (m *Storage) TaskCreated() {
+	if m == nil {
+		return
+	}
+
+	m.tasksCreated.Inc()
+}
+
+// StorageSource is what the scrape-time storage collector reads from.
+type StorageSource interface {
+	Stats() sql.DBStats
+	CountTasksByStatus(ctx context.Context, date string) (map[string]int, error)
+}
+
+// storageCollector reports the connection pool state and the number of tasks
+// planned for the current day by status, so "created today" and "completed
+// today" are read straight from the database on every scrape.
+type storageCollector struct {
+	src     StorageSource
+	timeout time.Duration
+
+	openConns *prometheus.Desc
+	inUse     *prometheus.Desc
+	idle      *prometheus.Desc
+	waitCount *prometheus.Desc
+	waitTime  *prometheus.Desc
+	tasksDay  *prometheus.Desc
+}
+
+func NewStorageCollector(src StorageSource) prometheus.Collector {
+	return &storageCollector{
+		src:     src,
+		timeout: 2 * time.Second,
+		openConns: prometheus.NewDesc(namespace+"_storage_open_connections",
+			"Number of established connections, both in use and idle.", nil, nil),
+		inUse: prometheus.NewDesc(namespace+"_storage_in_use_connections",
+			"Number of connections currently in use.", nil, nil),
+		idle: prometheus.NewDesc(namespace+"_storage_idle_connections",
+			"Number of idle connections.", nil, nil),
+		waitCount: prometheus.NewDesc(namespace+"_storage_wait_count_total",
+			"Number of connections waited for.", nil, nil),
+		waitTime: prometheus.NewDesc(namespace+"_storage_wait_duration_seconds_total",
+			"Total time blocked waiting for a new connection.", nil, nil),
+		tasksDay: prometheus.NewDesc(namespace+"_tasks_today",
+			"Number of tasks planned for the current day by status.", []string{"status"}, nil),
+	}
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.openConns
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitTime
+	ch <- c.tasksDay
+}
+
+func (c *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.src.Stats()
+	ch <- prometheus.MustNewConstMetric(c.openConns, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitTime, prometheus.CounterValue, stats.WaitDuration.Seconds())
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	counts, err := c.src.CountTasksByStatus(ctx, time.Now().Format(time.DateOnly))
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.tasksDay, err)
+		return
+	}
+
+	for status, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.tasksDay, prometheus.GaugeValue, float64(n), status)
+	}
+}
@@ -0,0 +1,138 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/sl"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce coalesces the events of a certificate rotation, which usually
+// rewrites the certificate and the key one after the other.
+const debounce = 250 * time.Millisecond
+
+// New builds the server TLS config. The certificate is served by certs so it
+// can be rotated without a restart; HTTP/2 is negotiated by net/http when
+// the config is used with http.Server.ServeTLS.
+func New(cfg config.TLS, certs *CertReloader) (*tls.Config, error) {
+	const op = "lib.tlsconfig.New"
+
+	tlsCfg := &tls.Config{
+		GetCertificate: certs.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if cfg.MinVersion == "1.3" {
+		tlsCfg.MinVersion = tls.VersionTLS13
+	}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found in %s", op, cfg.ClientCAFile)
+		}
+
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsCfg, nil
+}
+
+// CertReloader holds the server certificate and reloads it from disk when
+// the certificate or key file changes. A pair that fails to load is logged
+// and the previous certificate keeps being served.
+type CertReloader struct {
+	log      *slog.Logger
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func NewCertReloader(log *slog.Logger, certFile, keyFile string) (*CertReloader, error) {
+	c := &CertReloader{
+		log:      log.With(slog.String("component", "tlsconfig/reloader"), slog.String("cert_file", certFile)),
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *CertReloader) Reload() error {
+	const op = "lib.tlsconfig.CertReloader.Reload"
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	c.cert.Store(&cert)
+
+	return nil
+}
+
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.cert.Load(), nil
+}
+
+// Run watches the certificate and key until ctx is cancelled. The parent
+// directories are watched so atomic replacements, including the symlink
+// swaps of Kubernetes secret volumes, are picked up.
+func (c *CertReloader) Run(ctx context.Context) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		c.log.Error("cannot watch certificate, it will not be reloaded", sl.Err(err))
+		return
+	}
+	defer fsw.Close()
+
+	dirs := map[string]bool{filepath.Dir(c.certFile): true, filepath.Dir(c.keyFile): true}
+	for dir := range dirs {
+		if err := fsw.Add(dir); err != nil {
+			c.log.Error("cannot watch certificate, it will not be reloaded", sl.Err(err))
+			return
+		}
+	}
+
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-fsw.Events:
+			timer.Reset(debounce)
+		case <-timer.C:
+			if err := c.Reload(); err != nil {
+				c.log.Error("certificate reload failed, keeping the current one", sl.Err(err))
+				continue
+			}
+			c.log.Info("certificate reloaded")
+		case err := <-fsw.Errors:
+			c.log.Warn("certificate watch error", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/lib/tlsconfig"
+	"daytask/internal/lib/tlsconfig/tlstest"
+)
+
+func TestCertReloaderRun(t *testing.T) {
+	dir := t.TempDir()
+	first := tlstest.NewServer(t, dir, "server", nil)
+
+	certs, err := tlsconfig.NewCertReloader(slogdiscard.NewDiscardLogger(), first.CertFile, first.KeyFile)
+	require.NoError(t, err)
+
+	served := func() string {
+		cert, err := certs.GetCertificate(&tls.ClientHelloInfo{})
+		require.NoError(t, err)
+		return string(cert.Certificate[0])
+	}
+	require.Equal(t, string(first.Cert.Raw), served())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		certs.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	second := tlstest.NewServer(t, dir, "server", nil)
+
+	require.Eventually(t, func() bool {
+		return served() == string(second.Cert.Raw)
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestCertReloaderKeepsCertOnBadPair(t *testing.T) {
+	dir := t.TempDir()
+	first := tlstest.NewServer(t, dir, "server", nil)
+	other := tlstest.NewServer(t, t.TempDir(), "other", nil)
+
+	_, err := tlsconfig.NewCertReloader(slogdiscard.NewDiscardLogger(), first.CertFile, other.KeyFile)
+	require.Error(t, err)
+
+	certs, err := tlsconfig.NewCertReloader(slogdiscard.NewDiscardLogger(), first.CertFile, first.KeyFile)
+	require.NoError(t, err)
+
+	// Only the certificate was rotated, so it no longer matches the key.
+	pem, err := os.ReadFile(other.CertFile)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(first.CertFile, pem, 0o600))
+
+	require.Error(t, certs.Reload())
+
+	cert, err := certs.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+	require.Equal(t, first.Cert.Raw, cert.Certificate[0])
+}
+
+func TestNewClientCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, dir, "ca")
+	server := tlstest.NewServer(t, dir, "server", ca)
+
+	certs, err := tlsconfig.NewCertReloader(slogdiscard.NewDiscardLogger(), server.CertFile, server.KeyFile)
+	require.NoError(t, err)
+
+	cfg, err := tlsconfig.New(config.TLS{MinVersion: "1.3", ClientCAFile: ca.CertFile}, certs)
+	require.NoError(t, err)
+	require.Equal(t, uint16(tls.VersionTLS13), cfg.MinVersion)
+	require.Equal(t, tls.RequireAndVerifyClientCert, cfg.ClientAuth)
+
+	_, err = tlsconfig.New(config.TLS{ClientCAFile: server.KeyFile}, certs)
+	require.Error(t, err)
+}
@@ -0,0 +1,149 @@
+// Package tlstest generates throwaway certificates for tests.
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Cert is a certificate and its key, written as PEM files.
+type Cert struct {
+	Cert     *x509.Certificate
+	Key      *ecdsa.PrivateKey
+	CertFile string
+	KeyFile  string
+}
+
+// TLSCertificate returns c for use as a tls.Config client certificate.
+func (c *Cert) TLSCertificate(t testing.TB) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+// Pool returns a pool trusting c.
+func (c *Cert) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(c.Cert)
+
+	return pool
+}
+
+// NewCA writes a self-signed CA certificate to dir.
+func NewCA(t testing.TB, dir, name string) *Cert {
+	t.Helper()
+
+	tmpl := template(name)
+	tmpl.IsCA = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	tmpl.BasicConstraintsValid = true
+
+	return write(t, dir, name, tmpl, nil)
+}
+
+// NewServer writes a certificate for localhost and 127.0.0.1 to dir, signed
+// by ca or self-signed when ca is nil.
+func NewServer(t testing.TB, dir, name string, ca *Cert) *Cert {
+	t.Helper()
+
+	tmpl := template(name)
+	tmpl.DNSNames = []string{"localhost"}
+	tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	if ca == nil {
+		// Self-signed leaves must be marked as CAs to be trusted via a pool.
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+
+	return write(t, dir, name, tmpl, ca)
+}
+
+// NewClient writes a client certificate signed by ca to dir.
+func NewClient(t testing.TB, dir, name string, ca *Cert) *Cert {
+	t.Helper()
+
+	tmpl := template(name)
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return write(t, dir, name, tmpl, ca)
+}
+
+func template(name string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 62))
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+func write(t testing.TB, dir, name string, tmpl *x509.Certificate, ca *Cert) *Cert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent, signer := tmpl, key
+	if ca != nil {
+		parent, signer = ca.Cert, ca.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Cert{
+		Cert:     cert,
+		Key:      key,
+		CertFile: filepath.Join(dir, name+".crt"),
+		KeyFile:  filepath.Join(dir, name+".key"),
+	}
+
+	writePEM(t, c.CertFile, "CERTIFICATE", der)
+	writePEM(t, c.KeyFile, "EC PRIVATE KEY", keyDER)
+
+	return c
+}
+
+func writePEM(t testing.TB, path, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,66 @@
+package trash
+
+import (
+	"context"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"log/slog"
+	"time"
+)
+
+// Actor is recorded in the audit log for the tasks the Purger deletes.
+const Actor = "trash-purge"
+
+// Store deletes for good the tasks trashed before deletedBefore.
+type Store interface {
+	PurgeTrash(ctx context.Context, deletedBefore time.Time) (int64, error)
+}
+
+// Purger empties the trash of the tasks deleted longer than the retention
+// period ago.
+type Purger struct {
+	log   *slog.Logger
+	store Store
+	cfg   config.Trash
+}
+
+func NewPurger(log *slog.Logger, store Store, cfg config.Trash) *Purger {
+	return &Purger{log: log, store: store, cfg: cfg}
+}
+
+// Run purges the trash once at start and then every PurgeInterval until ctx
+// is done. A zero interval or retention disables purging.
+func (p *Purger) Run(ctx context.Context) {
+	if p.cfg.PurgeInterval <= 0 || p.cfg.Retention <= 0 {
+		return
+	}
+
+	ctx = storage.WithActor(ctx, Actor)
+
+	ticker := time.NewTicker(p.cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deletes the tasks trashed more than the retention period ago.
+func (p *Purger) Purge(ctx context.Context) {
+	n, err := p.store.PurgeTrash(ctx, time.Now().Add(-p.cfg.Retention))
+	if err != nil {
+		p.log.ErrorContext(ctx, "failed to purge trash", sl.Err(err))
+		return
+	}
+
+	if n > 0 {
+		p.log.InfoContext(ctx, "purged trash", slog.Int64("count", n))
+	}
+}
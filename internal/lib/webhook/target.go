@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"context"
+	"daytask/internal/config"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+// ErrForbiddenTarget is returned for webhook URLs that are not public
+// addresses.
+var ErrForbiddenTarget = errors.New("webhook target is not a public address")
+
+// nonPublic are the ranges besides those net.IP classifies that must not be
+// reached either: "this network" and carrier-grade NAT.
+var nonPublic = []*net.IPNet{
+	mustCIDR("0.0.0.0/8"),
+	mustCIDR("100.64.0.0/10"),
+}
+
+func mustCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// public reports whether ip is neither loopback, link-local, private,
+// multicast nor unspecified.
+func public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, n := range nonPublic {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckURL fails with ErrForbiddenTarget if the host of rawURL is or
+// resolves to an address that is not public, unless allowPrivate is set.
+// The dispatcher checks again when it connects, as the host may resolve to
+// other addresses by then.
+func CheckURL(ctx context.Context, rawURL string, allowPrivate bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !public(ip) {
+			return fmt.Errorf("%s: %w", host, ErrForbiddenTarget)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !public(addr.IP) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr.IP, ErrForbiddenTarget)
+		}
+	}
+
+	return nil
+}
+
+// dialControl refuses connections to addresses that are not public, after
+// the host was resolved.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return fmt.Errorf("%s: %w", host, ErrForbiddenTarget)
+	}
+
+	return nil
+}
+
+// newClient returns the client deliveries are sent with. It never follows
+// redirects, which could lead to any address, and unless cfg.AllowPrivate is
+// set it only connects to public addresses.
+func newClient(cfg config.Webhooks) *http.Client {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = dialControl
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// The proxy would be dialed instead of the target.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,189 @@
+// Package webhook sends the task events queued in storage to the registered
+// webhooks.
+//
+// Every delivery is a POST of the event as JSON with these headers:
+//
+//	X-Daytask-Event:     the event, e.g. task.created
+//	X-Daytask-Delivery:  the delivery ID, the same for every retry
+//	X-Daytask-Timestamp: the Unix time of the attempt in seconds
+//	X-Daytask-Signature: sha256= followed by the hex HMAC-SHA256 of the
+//	                     timestamp, a dot and the body, keyed with the
+//	                     webhook secret
+//
+// Receivers should check the signature with Verify and reject old
+// timestamps. Any 2xx response acknowledges the delivery.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/sl"
+	"daytask/internal/storage"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Daytask-Event"
+	HeaderDelivery  = "X-Daytask-Delivery"
+	HeaderTimestamp = "X-Daytask-Timestamp"
+	HeaderSignature = "X-Daytask-Signature"
+)
+
+// batchSize is the number of due deliveries sent per poll.
+const batchSize = 100
+
+// Store holds the queued deliveries.
+type Store interface {
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]storage.Delivery, error)
+	RecordDeliveryAttempt(ctx context.Context, id int64, status string, code int, errMsg string, at time.Time, next time.Time) error
+}
+
+// Dispatcher sends the due deliveries and schedules the retries of the
+// failed ones.
+type Dispatcher struct {
+	log    *slog.Logger
+	store  Store
+	cfg    config.Webhooks
+	client *http.Client
+}
+
+func NewDispatcher(log *slog.Logger, store Store, cfg config.Webhooks) *Dispatcher {
+	return &Dispatcher{log: log, store: store, cfg: cfg, client: newClient(cfg)}
+}
+
+// Run sends the due deliveries every PollInterval until ctx is done. It does
+// nothing when webhooks are disabled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if !d.cfg.Enabled || d.cfg.PollInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.Dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the deliveries due now, until none is left or ctx is done.
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		list, err := d.store.DueDeliveries(ctx, time.Now(), batchSize)
+		if err != nil {
+			d.log.ErrorContext(ctx, "failed to get due webhook deliveries", sl.Err(err))
+			return
+		}
+
+		for _, delivery := range list {
+			d.send(ctx, delivery)
+		}
+
+		if len(list) < batchSize {
+			return
+		}
+	}
+}
+
+// send makes one attempt at delivery and records its outcome.
+func (d *Dispatcher) send(ctx context.Context, delivery storage.Delivery) {
+	log := d.log.With(
+		slog.Int64("delivery_id", delivery.ID),
+		slog.Int64("webhook_id", delivery.WebhookID),
+		slog.String("event", delivery.Event),
+	)
+
+	now := time.Now()
+	code, err := d.post(ctx, delivery, now)
+
+	status, errMsg, next := storage.DeliveryDelivered, "", time.Time{}
+	if err != nil {
+		attempts := delivery.Attempts + 1
+		errMsg = err.Error()
+		if attempts >= d.cfg.MaxAttempts {
+			status = storage.DeliveryFailed
+			log.WarnContext(ctx, "webhook delivery failed", slog.Int("attempts", attempts), sl.Err(err))
+		} else {
+			status, next = storage.DeliveryPending, now.Add(Delay(d.cfg, attempts))
+			log.DebugContext(ctx, "webhook delivery will be retried", slog.Time("next_attempt", next), sl.Err(err))
+		}
+	}
+
+	if err := d.store.RecordDeliveryAttempt(ctx, delivery.ID, status, code, errMsg, now, next); err != nil {
+		log.ErrorContext(ctx, "failed to record webhook delivery", sl.Err(err))
+	}
+}
+
+// post sends delivery signed at now and returns the response status code.
+func (d *Dispatcher) post(ctx context.Context, delivery storage.Delivery, now time.Time) (int, error) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "daytask-webhook")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, ts, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Delay returns how long to wait before retrying a delivery that failed
+// attempts times: BaseDelay doubled with every further failure up to
+// MaxDelay.
+func Delay(cfg config.Webhooks, attempts int) time.Duration {
+	delay := cfg.BaseDelay
+	for i := 1; i < attempts && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, cfg.MaxDelay)
+}
+
+// Sign returns the X-Daytask-Signature of body sent at timestamp.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the X-Daytask-Signature of body sent
+// at timestamp.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -0,0 +1,235 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/lib/logger/handlers/slogdiscard"
+	"daytask/internal/lib/webhook"
+	"daytask/internal/storage"
+	"daytask/internal/storage/sqlite"
+)
+
+var cfg = config.Webhooks{
+	Enabled:      true,
+	PollInterval: time.Millisecond,
+	Timeout:      time.Second,
+	MaxAttempts:  3,
+	BaseDelay:    time.Millisecond,
+	MaxDelay:     time.Millisecond,
+	// The receivers listen on the loopback interface.
+	AllowPrivate: true,
+}
+
+// receiver records the deliveries it gets, answering with status.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	received []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.received = append(rc.received, r)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(rc.status)
+}
+
+func newStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), config.SQLite{MaxOpenConns: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestDispatchSigned(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+	rc := &receiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	id, err := s.CreateWebhook(ctx, "alice", srv.URL, "s3cret", []string{storage.EventTaskCreated, storage.EventTaskCompleted})
+	require.NoError(t, err)
+
+	taskID, err := s.SaveTask(ctx, "title", "", "alice", "2024-05-01", "todo", "work")
+	require.NoError(t, err)
+	// Not subscribed to task.updated, but to task.completed.
+	_, err = s.PatchTask(ctx, taskID, storage.TaskPatch{Status: ptr(storage.StatusDone)})
+	require.NoError(t, err)
+	// Someone else's task.
+	_, err = s.SaveTask(ctx, "title", "", "bob", "2024-05-01", "todo", "work")
+	require.NoError(t, err)
+
+	webhook.NewDispatcher(slogdiscard.NewDiscardLogger(), s, cfg).Dispatch(ctx)
+
+	require.Len(t, rc.received, 2)
+	for i, event := range []string{storage.EventTaskCreated, storage.EventTaskCompleted} {
+		r, body := rc.received[i], rc.bodies[i]
+		require.Equal(t, event, r.Header.Get(webhook.HeaderEvent))
+		require.True(t, webhook.Verify("s3cret", r.Header.Get(webhook.HeaderTimestamp), body, r.Header.Get(webhook.HeaderSignature)))
+		require.False(t, webhook.Verify("other", r.Header.Get(webhook.HeaderTimestamp), body, r.Header.Get(webhook.HeaderSignature)))
+
+		var payload struct {
+			Event string       `json:"event"`
+			Task  storage.Task `json:"task"`
+		}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, event, payload.Event)
+		require.Equal(t, taskID, payload.Task.ID)
+	}
+
+	list, err := s.ListDeliveries(ctx, id, "alice", storage.DeliveryDelivered, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, http.StatusNoContent, list[0].ResponseCode)
+	require.Equal(t, 1, list[0].Attempts)
+	require.False(t, list[0].DeliveredAt.IsZero())
+
+	_, err = s.ListDeliveries(ctx, id, "bob", "", 0, 10)
+	require.ErrorIs(t, err, storage.ErrWebhookNotFound)
+}
+
+func TestDispatchRetries(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+	rc := &receiver{status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	id, err := s.CreateWebhook(ctx, "alice", srv.URL, "s3cret", []string{storage.EventTaskDeleted})
+	require.NoError(t, err)
+
+	taskID, err := s.SaveTask(ctx, "title", "", "alice", "2024-05-01", "todo", "work")
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteTask(ctx, taskID))
+
+	d := webhook.NewDispatcher(slogdiscard.NewDiscardLogger(), s, cfg)
+	d.Dispatch(ctx)
+
+	list, err := s.ListDeliveries(ctx, id, "alice", "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, storage.DeliveryPending, list[0].Status)
+	require.Equal(t, 1, list[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, list[0].ResponseCode)
+	require.NotEmpty(t, list[0].LastError)
+	require.False(t, list[0].NextAttempt.IsZero())
+
+	for i := 1; i < cfg.MaxAttempts; i++ {
+		time.Sleep(2 * cfg.MaxDelay)
+		d.Dispatch(ctx)
+	}
+
+	list, err = s.ListDeliveries(ctx, id, "alice", "", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, storage.DeliveryFailed, list[0].Status)
+	require.Equal(t, cfg.MaxAttempts, list[0].Attempts)
+	require.Len(t, rc.received, cfg.MaxAttempts)
+
+	// Every retry is the same delivery.
+	for _, r := range rc.received {
+		require.Equal(t, rc.received[0].Header.Get(webhook.HeaderDelivery), r.Header.Get(webhook.HeaderDelivery))
+	}
+}
+
+func TestDispatchPublicOnly(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+	rc := &receiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	id, err := s.CreateWebhook(ctx, "alice", srv.URL, "s3cret", []string{storage.EventTaskCreated})
+	require.NoError(t, err)
+	_, err = s.SaveTask(ctx, "title", "", "alice", "2024-05-01", "todo", "work")
+	require.NoError(t, err)
+
+	public := cfg
+	public.AllowPrivate = false
+	webhook.NewDispatcher(slogdiscard.NewDiscardLogger(), s, public).Dispatch(ctx)
+
+	require.Empty(t, rc.received)
+	list, err := s.ListDeliveries(ctx, id, "alice", "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Zero(t, list[0].ResponseCode)
+	require.Contains(t, list[0].LastError, webhook.ErrForbiddenTarget.Error())
+}
+
+func TestDispatchNoRedirects(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+	rc := &receiver{status: http.StatusNoContent}
+	target := httptest.NewServer(rc)
+	defer target.Close()
+	srv := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	id, err := s.CreateWebhook(ctx, "alice", srv.URL, "s3cret", []string{storage.EventTaskCreated})
+	require.NoError(t, err)
+	_, err = s.SaveTask(ctx, "title", "", "alice", "2024-05-01", "todo", "work")
+	require.NoError(t, err)
+
+	webhook.NewDispatcher(slogdiscard.NewDiscardLogger(), s, cfg).Dispatch(ctx)
+
+	require.Empty(t, rc.received)
+	list, err := s.ListDeliveries(ctx, id, "alice", "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, storage.DeliveryPending, list[0].Status)
+	require.Equal(t, http.StatusTemporaryRedirect, list[0].ResponseCode)
+}
+
+func TestCheckURL(t *testing.T) {
+	ctx := context.Background()
+
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"https://192.168.1.1/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+	} {
+		require.ErrorIs(t, webhook.CheckURL(ctx, u, false), webhook.ErrForbiddenTarget, u)
+		require.NoError(t, webhook.CheckURL(ctx, u, true), u)
+	}
+
+	require.NoError(t, webhook.CheckURL(ctx, "https://93.184.216.34/hook", false))
+	require.NoError(t, webhook.CheckURL(ctx, "https://[2606:2800:220:1::1]/hook", false))
+}
+
+func TestDelay(t *testing.T) {
+	cfg := config.Webhooks{BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	for attempts, want := range []time.Duration{10 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute} {
+		require.Equal(t, want, webhook.Delay(cfg, attempts), "attempts %d", attempts)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,21 @@
+// Package workspace holds the rules deciding what a member may do with the
+// tasks of a workspace.
+package workspace
+
+import (
+	"daytask/internal/storage"
+	"slices"
+)
+
+// CanEdit reports whether user, a member of the task's workspace with role,
+// may change every field of t, reassign it or delete it: only the owner of
+// the task and the owners of the workspace may.
+func CanEdit(t storage.Task, user string, role string) bool {
+	return t.Owner == user || role == storage.WorkspaceRoleOwner
+}
+
+// CanUpdateStatus reports whether user may change the status of t, which
+// assignees may as well.
+func CanUpdateStatus(t storage.Task, user string, role string) bool {
+	return CanEdit(t, user, role) || slices.Contains(t.Assignees, user)
+}
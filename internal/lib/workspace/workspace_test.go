@@ -0,0 +1,29 @@
+package workspace_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/lib/workspace"
+	"daytask/internal/storage"
+)
+
+func TestPermissions(t *testing.T) {
+	task := storage.Task{Owner: "alice", Assignees: []string{"bob"}}
+
+	tests := []struct {
+		user, role         string
+		edit, updateStatus bool
+	}{
+		{"alice", storage.WorkspaceRoleMember, true, true},
+		{"carol", storage.WorkspaceRoleOwner, true, true},
+		{"bob", storage.WorkspaceRoleMember, false, true},
+		{"dave", storage.WorkspaceRoleMember, false, false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.edit, workspace.CanEdit(task, tt.user, tt.role), tt.user)
+		require.Equal(t, tt.updateStatus, workspace.CanUpdateStatus(task, tt.user, tt.role), tt.user)
+	}
+}
@@ -0,0 +1,354 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// changeTask applies change to the task id in a transaction, writes only the
+// fields it changed, records them in the activity log, attributed to
+// storage.Actor, and saves the result as a new revision, which it returns.
+// It fails with storage.ErrTaskNotFound if the task does not exist, is in the
+// trash or is not a workspace task when inWorkspace is set, or the other way
+// round.
+func (s *Storage) changeTask(ctx context.Context, id int64, inWorkspace bool, change func(t *storage.Task)) (storage.Task, error) {
+	var after storage.Task
+	err := s.inTx(ctx, func(tx *sql.Tx) (err error) {
+		after, err = s.changeTaskTx(ctx, tx, id, inWorkspace, change)
+		return err
+	})
+	if err != nil {
+		return storage.Task{}, err
+	}
+
+	return after, nil
+}
+
+// changeTaskTx is changeTask in tx, for changes that depend on other reads
+// of the same transaction.
+func (s *Storage) changeTaskTx(ctx context.Context, tx *sql.Tx, id int64, inWorkspace bool, change func(t *storage.Task)) (storage.Task, error) {
+	before, err := loadTask(ctx, tx, id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && ((before.WorkspaceID != nil) != inWorkspace || before.DeletedAt != nil) {
+		return storage.Task{}, storage.ErrTaskNotFound
+	}
+	if err != nil {
+		return storage.Task{}, err
+	}
+
+	after := before
+	change(&after)
+
+	changes := storage.Diff(before, after)
+	if len(changes) == 0 {
+		return after, nil
+	}
+
+	// The field names of storage.Diff are the column names.
+	set := make([]string, len(changes))
+	args := make([]any, 0, len(changes)+1)
+	for i, c := range changes {
+		set[i] = c.Field + " = ?"
+		args = append(args, c.To)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE daytask SET "+strings.Join(set, ", ")+" WHERE id = ?", append(args, id)...)
+	if err != nil {
+		return storage.Task{}, err
+	}
+
+	// Read back the new server_rev.
+	after, err = loadTask(ctx, tx, id)
+	if err != nil {
+		return storage.Task{}, err
+	}
+
+	if err := recordChanges(ctx, tx, id, changes); err != nil {
+		return storage.Task{}, err
+	}
+
+	if err := recordRevisions(ctx, tx, before, after); err != nil {
+		return storage.Task{}, err
+	}
+
+	if err := s.emitChange(ctx, tx, before, after); err != nil {
+		return storage.Task{}, err
+	}
+
+	return after, audit(ctx, tx, storage.AuditUpdate, auditTask, id, before, after)
+}
+
+func recordChanges(ctx context.Context, tx *sql.Tx, taskID int64, changes []storage.Change) error {
+	now := time.Now().UnixMilli()
+	actor := storage.Actor(ctx)
+
+	for _, c := range changes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO task_activity(task_id, actor, field, old_value, new_value, created_at)
+			VALUES(?, ?, ?, ?, ?, ?)`, taskID, actor, c.Field, c.From, c.To, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddComment adds a comment by author to a task, or fails with
+// storage.ErrTaskNotFound.
+func (s *Storage) AddComment(ctx context.Context, taskID int64, author string, text string) (_ int64, err error) {
+	const op = "storage.sqlite.AddComment"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var id int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now().UnixMilli()
+		res, err := tx.ExecContext(ctx, `INSERT INTO comments(task_id, author, text, created_at, updated_at)
+			SELECT id, ?, ?, ?, ? FROM daytask WHERE id = ? AND deleted_at IS NULL`, author, text, now, now, taskID)
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrTaskNotFound
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		after, err := loadComment(ctx, tx, id, author)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditCreate, auditComment, id, nil, after)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// EditComment replaces the text of a comment written by author, or fails with
+// storage.ErrCommentNotFound.
+func (s *Storage) EditComment(ctx context.Context, id int64, author string, text string) (err error) {
+	const op = "storage.sqlite.EditComment"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadComment(ctx, tx, id, author)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE comments SET text = ?, updated_at = ? WHERE id = ?",
+			text, time.Now().UnixMilli(), id)
+		if err != nil {
+			return err
+		}
+
+		after, err := loadComment(ctx, tx, id, author)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditComment, id, before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteComment deletes a comment written by author, or fails with
+// storage.ErrCommentNotFound.
+func (s *Storage) DeleteComment(ctx context.Context, id int64, author string) (err error) {
+	const op = "storage.sqlite.DeleteComment"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadComment(ctx, tx, id, author)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM comments WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditDelete, auditComment, id, before, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// loadComment reads the comment id written by author in tx, or fails with
+// storage.ErrCommentNotFound.
+func loadComment(ctx context.Context, tx *sql.Tx, id int64, author string) (storage.Comment, error) {
+	var (
+		c                storage.Comment
+		created, updated int64
+	)
+	err := tx.QueryRowContext(ctx, "SELECT id, task_id, author, text, created_at, updated_at FROM comments WHERE id = ? AND author = ?",
+		id, author).Scan(&c.ID, &c.TaskID, &c.Author, &c.Text, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Comment{}, storage.ErrCommentNotFound
+	}
+	if err != nil {
+		return storage.Comment{}, err
+	}
+
+	c.CreatedAt = time.UnixMilli(created)
+	c.UpdatedAt = time.UnixMilli(updated)
+
+	return c, nil
+}
+
+// GetComments returns the comments of the tasks, oldest first, keyed by task.
+func (s *Storage) GetComments(ctx context.Context, taskIDs ...int64) (_ map[int64][]storage.Comment, err error) {
+	const op = "storage.sqlite.GetComments"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	comments := make(map[int64][]storage.Comment)
+	if len(taskIDs) == 0 {
+		return comments, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT id, task_id, author, text, created_at, updated_at FROM comments
+		WHERE task_id IN (`+placeholders(len(taskIDs))+`) ORDER BY id`, int64Args(taskIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c                storage.Comment
+			created, updated int64
+		)
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Author, &c.Text, &created, &updated); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.CreatedAt = time.UnixMilli(created)
+		c.UpdatedAt = time.UnixMilli(updated)
+		comments[c.TaskID] = append(comments[c.TaskID], c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comments, nil
+}
+
+const activityColumns = "a.id, a.task_id, a.actor, a.field, a.old_value, a.new_value, a.created_at"
+
+// GetActivity returns the activity of the tasks, oldest first, keyed by task.
+func (s *Storage) GetActivity(ctx context.Context, taskIDs ...int64) (_ map[int64][]storage.Activity, err error) {
+	const op = "storage.sqlite.GetActivity"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	activity := make(map[int64][]storage.Activity)
+	if len(taskIDs) == 0 {
+		return activity, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+activityColumns+" FROM task_activity a WHERE a.task_id IN ("+
+		placeholders(len(taskIDs))+") ORDER BY a.id", int64Args(taskIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	list, err := scanActivity(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, a := range list {
+		activity[a.TaskID] = append(activity[a.TaskID], a)
+	}
+
+	return activity, nil
+}
+
+// ActivityFeed returns, newest first, up to limit changes made by username or
+// made to tasks it owns or is assigned to. Only changes older than beforeID
+// are returned unless it is zero.
+func (s *Storage) ActivityFeed(ctx context.Context, username string, beforeID int64, limit int) (_ []storage.Activity, err error) {
+	const op = "storage.sqlite.ActivityFeed"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+activityColumns+` FROM task_activity a
+		JOIN daytask t ON t.id = a.task_id
+		WHERE (a.actor = @user OR t.owner = @user
+			OR EXISTS(SELECT 1 FROM task_assignees WHERE task_id = t.id AND username = @user))
+		AND (@before = 0 OR a.id < @before)
+		ORDER BY a.id DESC LIMIT @limit`,
+		sql.Named("user", username), sql.Named("before", beforeID), sql.Named("limit", limit))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	list, err := scanActivity(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// scanActivity reads every row selected with activityColumns and closes rows.
+func scanActivity(rows *sql.Rows) ([]storage.Activity, error) {
+	defer rows.Close()
+
+	var list []storage.Activity
+	for rows.Next() {
+		var (
+			a  storage.Activity
+			at int64
+		)
+		if err := rows.Scan(&a.ID, &a.TaskID, &a.Actor, &a.Field, &a.From, &a.To, &at); err != nil {
+			return nil, err
+		}
+		a.At = time.UnixMilli(at)
+		list = append(list, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// placeholders returns n comma separated bind parameters for an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64Args(ids []int64) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	return args
+}
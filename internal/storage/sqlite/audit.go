@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Audited entities.
+const (
+	auditTask            = "task"
+	auditUser            = "user"
+	auditLoginFailures   = "login_failures"
+	auditWorkspace       = "workspace"
+	auditWorkspaceMember = "workspace_member"
+	auditComment         = "comment"
+	auditWebhook         = "webhook"
+	auditDayOrder        = "day_order"
+)
+
+// audit appends a change to the audit log in tx, attributed to the actor,
+// request and remote address of ctx. A nil before or after is stored as
+// NULL.
+//
+// Every create, update and delete goes through here except the login
+// failure counters kept by the login guard and their periodic purge, and the
+// webhook deliveries queued and sent in the background, which are
+// bookkeeping rather than changes made by a user.
+func audit(ctx context.Context, tx *sql.Tx, action string, entity string, entityID any, before, after any) error {
+	b, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	a, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO audit_log(at, actor, request_id, remote_addr, action, entity, entity_id, before, after)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().UnixMilli(), storage.Actor(ctx), middleware.GetReqID(ctx), storage.RemoteAddr(ctx),
+		action, entity, fmt.Sprint(entityID), b, a)
+
+	return err
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// AuditLog calls fn for every audit entry matching filter, oldest first,
+// stopping at the first error fn returns.
+func (s *Storage) AuditLog(ctx context.Context, filter storage.AuditFilter, fn func(storage.AuditEntry) error) (err error) {
+	const op = "storage.sqlite.AuditLog"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var (
+		where []string
+		args  []any
+	)
+	for _, c := range []struct {
+		cond string
+		arg  any
+		set  bool
+	}{
+		{"actor = ?", filter.Actor, filter.Actor != ""},
+		{"action = ?", filter.Action, filter.Action != ""},
+		{"entity = ?", filter.Entity, filter.Entity != ""},
+		{"entity_id = ?", filter.EntityID, filter.EntityID != ""},
+		{"at >= ?", filter.Since.UnixMilli(), !filter.Since.IsZero()},
+		{"at < ?", filter.Until.UnixMilli(), !filter.Until.IsZero()},
+		{"id > ?", filter.AfterID, filter.AfterID > 0},
+	} {
+		if c.set {
+			where = append(where, c.cond)
+			args = append(args, c.arg)
+		}
+	}
+
+	query := "SELECT id, at, actor, request_id, remote_addr, action, entity, entity_id, before, after FROM audit_log"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e             storage.AuditEntry
+			at            int64
+			before, after sql.NullString
+		)
+		err := rows.Scan(&e.ID, &at, &e.Actor, &e.RequestID, &e.RemoteAddr, &e.Action, &e.Entity, &e.EntityID, &before, &after)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		e.At = time.UnixMilli(at)
+		if before.Valid {
+			e.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			e.After = json.RawMessage(after.String)
+		}
+
+		if err := fn(e); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+	"daytask/internal/storage/sqlite"
+)
+
+func auditLog(t *testing.T, s *sqlite.Storage, filter storage.AuditFilter) []storage.AuditEntry {
+	t.Helper()
+
+	var entries []storage.AuditEntry
+	require.NoError(t, s.AuditLog(context.Background(), filter, func(e storage.AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	}))
+
+	return entries
+}
+
+func TestAuditLog(t *testing.T) {
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	ctx = storage.WithActor(ctx, "alice")
+	ctx = storage.WithRemoteAddr(ctx, "10.0.0.1:1234")
+
+	id, err := s.SaveTask(ctx, "t", "", "alice", "2024-01-01", "new", "")
+	require.NoError(t, err)
+	require.NoError(t, s.UpdateTask(ctx, id, "t", "", "alice", "2024-01-01", "done", ""))
+	// An update changing nothing is not a mutation.
+	require.NoError(t, s.UpdateTask(ctx, id, "t", "", "alice", "2024-01-01", "done", ""))
+	require.NoError(t, s.DeleteTask(ctx, id))
+
+	_, err = s.CreateUser(ctx, "bob", "hash", storage.RoleMember)
+	require.NoError(t, err)
+
+	entries := auditLog(t, s, storage.AuditFilter{Entity: "task"})
+	require.Len(t, entries, 3)
+
+	for i, action := range []string{storage.AuditCreate, storage.AuditUpdate, storage.AuditDelete} {
+		e := entries[i]
+		require.Equal(t, action, e.Action)
+		require.Equal(t, "alice", e.Actor)
+		require.Equal(t, "req-1", e.RequestID)
+		require.Equal(t, "10.0.0.1:1234", e.RemoteAddr)
+	}
+
+	require.Nil(t, entries[0].Before)
+	require.Contains(t, string(entries[2].After), "deleted_at")
+
+	var before, after storage.Task
+	require.NoError(t, json.Unmarshal(entries[1].Before, &before))
+	require.NoError(t, json.Unmarshal(entries[1].After, &after))
+	require.Equal(t, "new", before.Status)
+	require.Equal(t, "done", after.Status)
+
+	users := auditLog(t, s, storage.AuditFilter{Entity: "user", Action: storage.AuditCreate})
+	require.Len(t, users, 1)
+	require.Equal(t, "bob", users[0].EntityID)
+	require.NotContains(t, string(users[0].After), "hash")
+
+	require.Len(t, auditLog(t, s, storage.AuditFilter{AfterID: entries[1].ID, Limit: 1}), 1)
+}
+
+func TestAuditLogAppendOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.db")
+
+	s, err := sqlite.New(path, config.SQLite{MaxOpenConns: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	_, err = s.SaveTask(context.Background(), "t", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec("UPDATE audit_log SET actor = 'mallory'")
+	require.ErrorContains(t, err, "append-only")
+	_, err = db.Exec("DELETE FROM audit_log")
+	require.ErrorContains(t, err, "append-only")
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/lib/events"
+	"daytask/internal/storage"
+	"encoding/json"
+	"time"
+)
+
+// PublishTo makes the storage publish every committed task change to bus.
+func (s *Storage) PublishTo(bus *events.Bus) {
+	s.bus = bus
+}
+
+// published is a task event waiting for its transaction to commit.
+type published struct {
+	event   string
+	task    storage.Task
+	changes []storage.Change
+}
+
+// emit queues event about task, and the changes that caused it if any, for
+// every webhook of the task owner that subscribed to it, in the same
+// transaction as the change so no event is lost or sent for a change that was
+// rolled back, and publishes it to the bus once tx commits.
+func (s *Storage) emit(ctx context.Context, tx *sql.Tx, event string, task storage.Task, changes []storage.Change) error {
+	now := time.Now()
+
+	payload, err := json.Marshal(struct {
+		Event   string           `json:"event"`
+		At      time.Time        `json:"at"`
+		Task    storage.Task     `json:"task"`
+		Changes []storage.Change `json:"changes,omitempty"`
+	}{event, now, task, changes})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO webhook_deliveries(webhook_id, event, payload, status, next_attempt, created_at)
+		SELECT id, @event, @payload, @status, @now, @now FROM webhooks
+		WHERE owner = @owner AND ',' || events || ',' LIKE '%,' || @event || ',%'`,
+		sql.Named("event", event), sql.Named("payload", string(payload)), sql.Named("status", storage.DeliveryPending),
+		sql.Named("now", now.UnixMilli()), sql.Named("owner", task.Owner))
+	if err != nil {
+		return err
+	}
+
+	if s.bus != nil {
+		s.pendingMu.Lock()
+		s.pending[tx] = append(s.pending[tx], published{event, task, changes})
+		s.pendingMu.Unlock()
+	}
+
+	return nil
+}
+
+// emitChange queues the events of a task changed from before to after.
+func (s *Storage) emitChange(ctx context.Context, tx *sql.Tx, before, after storage.Task) error {
+	changes := storage.Diff(before, after)
+	if err := s.emit(ctx, tx, storage.EventTaskUpdated, after, changes); err != nil {
+		return err
+	}
+
+	if after.Status == storage.StatusDone && before.Status != storage.StatusDone {
+		return s.emit(ctx, tx, storage.EventTaskCompleted, after, changes)
+	}
+
+	return nil
+}
+
+// publish sends the events emitted in tx to the bus if it committed and
+// forgets them either way.
+func (s *Storage) publish(tx *sql.Tx, committed bool) {
+	if s.bus == nil {
+		return
+	}
+
+	s.pendingMu.Lock()
+	list := s.pending[tx]
+	delete(s.pending, tx)
+	s.pendingMu.Unlock()
+
+	if committed {
+		for _, p := range list {
+			s.bus.Publish(p.event, p.task, p.changes)
+		}
+	}
+}
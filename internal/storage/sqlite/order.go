@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"fmt"
+	"slices"
+)
+
+// dayOrder sorts the tasks of a day in the order set by ReorderDay, tasks
+// never ordered last by creation.
+const dayOrder = "ORDER BY position = 0, position, id"
+
+// ReorderDay sets the order GetTaskForDay returns the tasks of owner on date
+// in. ids must list every one of those tasks exactly once, otherwise it fails
+// with storage.ErrOrderMismatch.
+func (s *Storage) ReorderDay(ctx context.Context, owner string, date string, ids []int64) (err error) {
+	const op = "storage.sqlite.ReorderDay"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT id FROM daytask WHERE owner = ? AND date = ? AND deleted_at IS NULL "+dayOrder, owner, date)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		var before []int64
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			before = append(before, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		sorted := slices.Clone(ids)
+		slices.Sort(sorted)
+		current := slices.Clone(before)
+		slices.Sort(current)
+		if !slices.Equal(sorted, current) {
+			return storage.ErrOrderMismatch
+		}
+		if slices.Equal(ids, before) {
+			return nil
+		}
+
+		for i, id := range ids {
+			if _, err := tx.ExecContext(ctx, "UPDATE daytask SET position = ? WHERE id = ?", i+1, id); err != nil {
+				return err
+			}
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditDayOrder, owner+" "+date, before, ids)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
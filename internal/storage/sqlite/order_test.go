@@ -0,0 +1,54 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+)
+
+func TestReorderDay(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	var ids []int64
+	for _, title := range []string{"a", "b", "c"} {
+		id, err := s.SaveTask(ctx, title, "", "alice", "2024-01-01", "", "")
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	day := func() []int64 {
+		tasks, err := s.GetTaskForDay(ctx, "alice", "2024-01-01")
+		require.NoError(t, err)
+
+		var got []int64
+		for _, task := range tasks {
+			got = append(got, task.ID)
+		}
+		return got
+	}
+	require.Equal(t, ids, day())
+
+	require.ErrorIs(t, s.ReorderDay(ctx, "alice", "2024-01-01", []int64{ids[2], ids[0]}), storage.ErrOrderMismatch)
+	require.ErrorIs(t, s.ReorderDay(ctx, "alice", "2024-01-01", []int64{ids[2], ids[0], ids[0]}), storage.ErrOrderMismatch)
+	require.ErrorIs(t, s.ReorderDay(ctx, "bob", "2024-01-01", ids), storage.ErrOrderMismatch)
+
+	require.NoError(t, s.ReorderDay(ctx, "alice", "2024-01-01", []int64{ids[2], ids[0], ids[1]}))
+	require.Equal(t, []int64{ids[2], ids[0], ids[1]}, day())
+
+	// New tasks come after the ordered ones.
+	d, err := s.SaveTask(ctx, "d", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	require.Equal(t, []int64{ids[2], ids[0], ids[1], d}, day())
+
+	// A task moved away and back loses its place.
+	_, err = s.PatchTask(ctx, ids[2], storage.TaskPatch{Date: ptr("2024-01-02")})
+	require.NoError(t, err)
+	_, err = s.PatchTask(ctx, ids[2], storage.TaskPatch{Date: ptr("2024-01-01")})
+	require.NoError(t, err)
+	require.Equal(t, []int64{ids[0], ids[1], ids[2], d}, day())
+}
@@ -0,0 +1,160 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxRevisions is how many revisions of a task are kept, older ones are
+// dropped as new ones are saved.
+const maxRevisions = 50
+
+const revisionColumns = "rev, actor, created_at, task_id, title, description, owner, date, status, type"
+
+// revisionQuery selects the revision @rev of the task @task, the latest one
+// if @rev is 0.
+const revisionQuery = "SELECT " + revisionColumns + ` FROM task_revisions
+	WHERE task_id = @task AND (rev = @rev OR @rev = 0) ORDER BY rev DESC LIMIT 1`
+
+// recordRevision saves task as its next revision and drops the revisions
+// that fell out of the last maxRevisions.
+func recordRevision(ctx context.Context, tx *sql.Tx, task storage.Task) error {
+	var rev int
+	err := tx.QueryRowContext(ctx, `INSERT INTO task_revisions(task_id, rev, title, description, owner, date, status, type, actor, created_at)
+		SELECT ?, COALESCE(MAX(rev), 0) + 1, ?, ?, ?, ?, ?, ?, ?, ? FROM task_revisions WHERE task_id = ?
+		RETURNING rev`,
+		task.ID, task.Title, task.Description, task.Owner, task.Date, task.Status, task.Type,
+		storage.Actor(ctx), time.Now().UnixMilli(), task.ID,
+	).Scan(&rev)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM task_revisions WHERE task_id = ? AND rev <= ?", task.ID, rev-maxRevisions)
+
+	return err
+}
+
+// recordRevisions saves the change of a task from before to after. Tasks
+// created before revisions were kept get before saved as their first one,
+// with no actor as it is not known.
+func recordRevisions(ctx context.Context, tx *sql.Tx, before, after storage.Task) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM task_revisions WHERE task_id = ?)", before.ID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if err := recordRevision(storage.WithActor(ctx, ""), tx, before); err != nil {
+			return err
+		}
+	}
+
+	return recordRevision(ctx, tx, after)
+}
+
+// ListRevisions returns the kept revisions of a task, newest first.
+func (s *Storage) ListRevisions(ctx context.Context, taskID int64) (_ []storage.Revision, err error) {
+	const op = "storage.sqlite.ListRevisions"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+revisionColumns+" FROM task_revisions WHERE task_id = ? ORDER BY rev DESC", taskID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var list []storage.Revision
+	for rows.Next() {
+		r, err := scanRevision(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// GetRevision returns a revision of a task, the latest one if rev is 0, or
+// fails with storage.ErrRevisionNotFound.
+func (s *Storage) GetRevision(ctx context.Context, taskID int64, rev int) (_ storage.Revision, err error) {
+	const op = "storage.sqlite.GetRevision"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	r, err := scanRevision(s.db.QueryRowContext(ctx, revisionQuery, sql.Named("task", taskID), sql.Named("rev", rev)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Revision{}, fmt.Errorf("%s: %w", op, storage.ErrRevisionNotFound)
+	}
+	if err != nil {
+		return storage.Revision{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r, nil
+}
+
+// RevertTask sets the editable fields of a task back to those of revision
+// rev, which is saved as a new revision. It fails with
+// storage.ErrRevisionNotFound or storage.ErrTaskNotFound.
+func (s *Storage) RevertTask(ctx context.Context, taskID int64, rev int) (err error) {
+	const op = "storage.sqlite.RevertTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	// The task and the revision are read in the transaction of the change,
+	// so no other write comes in between.
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		task, err := loadTask(ctx, tx, taskID)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && task.DeletedAt != nil {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		r, err := scanRevision(tx.QueryRowContext(ctx, revisionQuery, sql.Named("task", taskID), sql.Named("rev", rev)))
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrRevisionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeTaskTx(ctx, tx, taskID, task.WorkspaceID != nil, func(t *storage.Task) {
+			t.Title, t.Description, t.Owner, t.Date, t.Status, t.Type = r.Task.Title, r.Task.Description, r.Task.Owner, r.Task.Date, r.Task.Status, r.Task.Type
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanRevision(row interface{ Scan(dest ...any) error }) (storage.Revision, error) {
+	var (
+		r  storage.Revision
+		at int64
+	)
+
+	err := row.Scan(&r.Rev, &r.Actor, &at, &r.Task.ID, &r.Task.Title, &r.Task.Description, &r.Task.Owner,
+		&r.Task.Date, &r.Task.Status, &r.Task.Type)
+	if err != nil {
+		return storage.Revision{}, err
+	}
+	r.At = time.UnixMilli(at)
+
+	return r, nil
+}
@@ -0,0 +1,78 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+)
+
+func TestRevisions(t *testing.T) {
+	ctx := storage.WithActor(context.Background(), "alice")
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	id, err := s.SaveTask(ctx, "draft", "keep me", "alice", "2024-01-01", "new", "work")
+	require.NoError(t, err)
+
+	// A careless client wipes the description and type.
+	require.NoError(t, s.UpdateTask(ctx, id, "final", "", "alice", "2024-01-01", "done", ""))
+	// Saving the same fields again is not a new revision.
+	require.NoError(t, s.UpdateTask(ctx, id, "final", "", "alice", "2024-01-01", "done", ""))
+
+	list, err := s.ListRevisions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, 2, list[0].Rev)
+	require.Equal(t, "alice", list[0].Actor)
+
+	first, err := s.GetRevision(ctx, id, 1)
+	require.NoError(t, err)
+	latest, err := s.GetRevision(ctx, id, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, latest.Rev)
+
+	require.Equal(t, []storage.Change{
+		{Field: "title", From: "draft", To: "final"},
+		{Field: "description", From: "keep me", To: ""},
+		{Field: "status", From: "new", To: "done"},
+		{Field: "type", From: "work", To: ""},
+	}, storage.Diff(first.Task, latest.Task))
+
+	require.NoError(t, s.RevertTask(ctx, id, 1))
+
+	task, err := s.GetTask(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "keep me", task.Description)
+	require.Equal(t, "work", task.Type)
+
+	latest, err = s.GetRevision(ctx, id, 0)
+	require.NoError(t, err)
+	require.Equal(t, 3, latest.Rev)
+
+	require.ErrorIs(t, s.RevertTask(ctx, id, 42), storage.ErrRevisionNotFound)
+}
+
+func TestRevisionsBounded(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	id, err := s.SaveTask(ctx, "t0", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+
+	for i := 1; i <= 60; i++ {
+		require.NoError(t, s.UpdateTask(ctx, id, fmt.Sprintf("t%d", i), "", "alice", "2024-01-01", "", ""))
+	}
+
+	list, err := s.ListRevisions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, list, 50)
+	require.Equal(t, 61, list[0].Rev)
+	require.Equal(t, "t60", list[0].Task.Title)
+
+	_, err = s.GetRevision(ctx, id, 1)
+	require.ErrorIs(t, err, storage.ErrRevisionNotFound)
+}
@@ -1,184 +1,1084 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"daytask/internal/config"
+	"daytask/internal/lib/events"
+	"daytask/internal/lib/metrics"
 	"daytask/internal/storage"
+	"encoding/json"
+	"errors"
 	"fmt"
-	_ "github.com/mattn/go-sqlite3"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("daytask/internal/storage/sqlite")
+
 type Storage struct {
-	db *sql.DB
+	db      *sql.DB
+	metrics *metrics.Storage
+
+	// bus receives the task events emitted in a transaction, which wait in
+	// pending until it commits.
+	bus       *events.Bus
+	pendingMu sync.Mutex
+	pending   map[*sql.Tx][]published
+
+	// Statements are prepared once in New and shared by all callers,
+	// *sql.Stmt is safe for concurrent use and re-prepares itself on
+	// whichever pool connection it ends up running on.
+	saveTask      *sql.Stmt
+	deleteTask    *sql.Stmt
+	getTaskForDay *sql.Stmt
+	getAllTasks   *sql.Stmt
+	createUser    *sql.Stmt
+	getUser       *sql.Stmt
+
+	getLoginFailures *sql.Stmt
 }
 
-func New(storagePath string) (*Storage, error) {
-	const op = "storage.sqlite.New"
+// taskColumns selects a task of the daytask table along with its assignees
+// as a JSON array. Queries must exclude trashed tasks, those with deleted_at
+// set, unless they are after the trash.
+const taskColumns = "id, title, description, owner, date, status, type, workspace_id, parent_id, deleted_at, server_rev, " +
+	"(SELECT json_group_array(username) FROM task_assignees WHERE task_id = daytask.id)"
+
+const userColumns = "id, username, password, role, disabled"
 
-	db, err := sql.Open("sqlite3", storagePath)
+func New(storagePath string, cfg config.SQLite) (*Storage, error) {
+	const op = "storage.sqlite.New"
 
+	db, err := sql.Open("sqlite3", dsn(storagePath, cfg))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	stmt, err := db.Prepare( //TODO: добавить енум не сделано в процессе завершено 
-		`CREATE TABLE IF NOT EXISTS daytask(
-		id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
-		title TEXT NOT NULL,
-		description TEXT,
-		owner TEXT NOT NULL,
-		date DATE NOT NULL,
-		status TEXT, 
-		type TEXT);
-		CREATE INDEX IF NOT EXIST idx_id ON daytask(id);
-		`)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	if err != nil {
+	if err := migrate(db); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("%s: %w", op, err)
-	
 	}
-	_, err = stmt.Exec()
-	
+
+	s := &Storage{db: db, pending: make(map[*sql.Tx][]published)}
+
+	stmts := []struct {
+		dst   **sql.Stmt
+		query string
+	}{
+		{&s.saveTask, "INSERT INTO daytask(title, description, owner, date, status, type, parent_id) VALUES(?, ?, ?, ?, ?, ?, ?)"},
+		// Workspace tasks are changed through the workspace methods, which
+		// callers guard with the workspace permissions.
+		{&s.deleteTask, "UPDATE daytask SET deleted_at = ? WHERE id = ? AND workspace_id IS NULL"},
+		{&s.getTaskForDay, "SELECT " + taskColumns + " FROM daytask WHERE owner = ? AND date = ? AND deleted_at IS NULL " + dayOrder},
+		{&s.getAllTasks, "SELECT " + taskColumns + " FROM daytask WHERE owner = ? AND deleted_at IS NULL"},
+		{&s.createUser, "INSERT INTO users(username, password, role) VALUES(?, ?, ?)"},
+		{&s.getUser, "SELECT " + userColumns + " FROM users WHERE username = ?"},
+		{&s.getLoginFailures, "SELECT failures, last_failure, locked_until FROM login_failures WHERE kind = ? AND value = ?"},
+	}
+
+	for _, st := range stmts {
+		*st.dst, err = db.Prepare(st.query)
+		if err != nil {
+			s.Close()
+			return nil, fmt.Errorf("%s: prepare %q: %w", op, st.query, err)
+		}
+	}
+
+	return s, nil
+}
+
+// dsn appends the go-sqlite3 connection parameters to storagePath. They are
+// applied by the driver to every connection it opens, which matters for
+// per-connection pragmas such as busy_timeout and synchronous.
+func dsn(storagePath string, cfg config.SQLite) string {
+	params := url.Values{}
+	if cfg.JournalMode != "" {
+		params.Set("_journal_mode", cfg.JournalMode)
+	}
+	if cfg.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10))
+	}
+	if cfg.Synchronous != "" {
+		params.Set("_synchronous", cfg.Synchronous)
+	}
+	// Deleting a task or workspace cascades to its assignees and tasks.
+	params.Set("_foreign_keys", "1")
+
+	return storagePath + "?" + params.Encode()
+}
+
+// migrations are applied in order, each one exactly once. The number of
+// applied migrations is tracked in PRAGMA user_version, so new schema changes
+// must be appended and existing entries never edited.
+var migrations = []string{
+	//TODO: добавить енум не сделано в процессе завершено
+	`CREATE TABLE IF NOT EXISTS daytask(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT,
+	owner TEXT NOT NULL,
+	date DATE NOT NULL,
+	status TEXT,
+	type TEXT);
+	CREATE INDEX IF NOT EXISTS idx_owner_date ON daytask(owner, date);
+	CREATE TABLE IF NOT EXISTS users(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL);`,
+	// Times are Unix milliseconds, locked_until is 0 when not locked.
+	`CREATE TABLE IF NOT EXISTS login_failures(
+	kind TEXT NOT NULL,
+	value TEXT NOT NULL,
+	failures INTEGER NOT NULL,
+	last_failure INTEGER NOT NULL,
+	locked_until INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY(kind, value));`,
+	`ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+	ALTER TABLE users ADD COLUMN disabled INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE IF NOT EXISTS workspaces(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	name TEXT NOT NULL,
+	owner TEXT NOT NULL);
+	CREATE TABLE IF NOT EXISTS workspace_members(
+	workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	username TEXT NOT NULL,
+	role TEXT NOT NULL,
+	PRIMARY KEY(workspace_id, username));
+	CREATE INDEX IF NOT EXISTS idx_member_username ON workspace_members(username);
+	ALTER TABLE daytask ADD COLUMN workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+	CREATE INDEX IF NOT EXISTS idx_workspace_date ON daytask(workspace_id, date);
+	CREATE TABLE IF NOT EXISTS task_assignees(
+	task_id INTEGER NOT NULL REFERENCES daytask(id) ON DELETE CASCADE,
+	username TEXT NOT NULL,
+	PRIMARY KEY(task_id, username));
+	CREATE INDEX IF NOT EXISTS idx_assignee_username ON task_assignees(username);`,
+	`CREATE TABLE IF NOT EXISTS comments(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	task_id INTEGER NOT NULL REFERENCES daytask(id) ON DELETE CASCADE,
+	author TEXT NOT NULL,
+	text TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_comments_task ON comments(task_id);
+	CREATE TABLE IF NOT EXISTS task_activity(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	task_id INTEGER NOT NULL REFERENCES daytask(id) ON DELETE CASCADE,
+	actor TEXT NOT NULL,
+	field TEXT NOT NULL,
+	old_value TEXT NOT NULL,
+	new_value TEXT NOT NULL,
+	created_at INTEGER NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_activity_task ON task_activity(task_id);
+	CREATE INDEX IF NOT EXISTS idx_activity_actor ON task_activity(actor);`,
+	// The audit log is append-only, the triggers reject any change to it.
+	`CREATE TABLE IF NOT EXISTS audit_log(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	at INTEGER NOT NULL,
+	actor TEXT NOT NULL,
+	request_id TEXT NOT NULL,
+	remote_addr TEXT NOT NULL,
+	action TEXT NOT NULL,
+	entity TEXT NOT NULL,
+	entity_id TEXT NOT NULL,
+	before TEXT,
+	after TEXT);
+	CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_log(entity, entity_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit_log(actor);
+	CREATE INDEX IF NOT EXISTS idx_audit_at ON audit_log(at);
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;
+	CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit_log is append-only'); END;`,
+	// deleted_at is the Unix millisecond time a task was moved to the
+	// trash, NULL for live tasks.
+	`ALTER TABLE daytask ADD COLUMN deleted_at INTEGER;
+	CREATE INDEX IF NOT EXISTS idx_deleted_at ON daytask(deleted_at);`,
+	`CREATE TABLE IF NOT EXISTS task_revisions(
+	task_id INTEGER NOT NULL REFERENCES daytask(id) ON DELETE CASCADE,
+	rev INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	owner TEXT NOT NULL,
+	date TEXT NOT NULL,
+	status TEXT NOT NULL,
+	type TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY(task_id, rev));`,
+	// webhook_deliveries is the outbox of webhook events and their delivery
+	// log, events is a comma separated list.
+	`CREATE TABLE IF NOT EXISTS webhooks(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	owner TEXT NOT NULL,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	created_at INTEGER NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_webhooks_owner ON webhooks(owner);
+	CREATE TABLE IF NOT EXISTS webhook_deliveries(
+	id INTEGER PRIMARY KEY AUTOINCREMENT NOT NULL,
+	webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT NOT NULL DEFAULT '',
+	response_code INTEGER NOT NULL DEFAULT 0,
+	created_at INTEGER NOT NULL,
+	delivered_at INTEGER NOT NULL DEFAULT 0);
+	CREATE INDEX IF NOT EXISTS idx_deliveries_due ON webhook_deliveries(status, next_attempt);
+	CREATE INDEX IF NOT EXISTS idx_deliveries_webhook ON webhook_deliveries(webhook_id);`,
+	// Every change to a task, its assignees included, takes the next value
+	// of sync_counter as its server_rev. sync_tombstones records, with its
+	// own revision, that a task left the view of a user: it was purged, or
+	// the user stopped owning or being assigned to it. sync_clients maps
+	// the IDs offline clients gave the tasks they created to the task IDs.
+	`CREATE TABLE IF NOT EXISTS sync_counter(
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	rev INTEGER NOT NULL);
+	INSERT INTO sync_counter(id, rev) SELECT 1, COALESCE(MAX(id), 0) FROM daytask;
+	ALTER TABLE daytask ADD COLUMN server_rev INTEGER NOT NULL DEFAULT 0;
+	UPDATE daytask SET server_rev = id;
+	CREATE INDEX IF NOT EXISTS idx_server_rev ON daytask(server_rev);
+	CREATE TABLE IF NOT EXISTS sync_tombstones(
+	task_id INTEGER NOT NULL,
+	username TEXT NOT NULL,
+	server_rev INTEGER NOT NULL);
+	CREATE INDEX IF NOT EXISTS idx_tombstones_user ON sync_tombstones(username, server_rev);
+	CREATE TABLE IF NOT EXISTS sync_clients(
+	username TEXT NOT NULL,
+	client_id TEXT NOT NULL,
+	task_id INTEGER NOT NULL,
+	PRIMARY KEY(username, client_id));
+	CREATE TRIGGER IF NOT EXISTS daytask_rev_insert AFTER INSERT ON daytask BEGIN
+	UPDATE sync_counter SET rev = rev + 1;
+	UPDATE daytask SET server_rev = (SELECT rev FROM sync_counter) WHERE id = NEW.id;
+	END;
+	CREATE TRIGGER IF NOT EXISTS daytask_rev_update AFTER UPDATE ON daytask WHEN NEW.server_rev = OLD.server_rev BEGIN
+	UPDATE sync_counter SET rev = rev + 1;
+	UPDATE daytask SET server_rev = (SELECT rev FROM sync_counter) WHERE id = NEW.id;
+	INSERT INTO sync_tombstones(task_id, username, server_rev)
+	SELECT NEW.id, OLD.owner, rev FROM sync_counter WHERE OLD.owner != NEW.owner;
+	END;
+	CREATE TRIGGER IF NOT EXISTS daytask_rev_delete BEFORE DELETE ON daytask BEGIN
+	UPDATE sync_counter SET rev = rev + 1;
+	INSERT INTO sync_tombstones(task_id, username, server_rev)
+	SELECT OLD.id, OLD.owner, rev FROM sync_counter
+	UNION SELECT OLD.id, username, (SELECT rev FROM sync_counter) FROM task_assignees WHERE task_id = OLD.id;
+	END;
+	CREATE TRIGGER IF NOT EXISTS task_assignees_rev_insert AFTER INSERT ON task_assignees BEGIN
+	UPDATE daytask SET server_rev = server_rev WHERE id = NEW.task_id;
+	END;
+	CREATE TRIGGER IF NOT EXISTS task_assignees_rev_delete AFTER DELETE ON task_assignees BEGIN
+	UPDATE daytask SET server_rev = server_rev WHERE id = OLD.task_id;
+	INSERT INTO sync_tombstones(task_id, username, server_rev)
+	SELECT OLD.task_id, OLD.username, server_rev FROM daytask WHERE id = OLD.task_id;
+	END;`,
+	// position orders the tasks of a day as set by ReorderDay, 0 for tasks
+	// never ordered, which come last. A task moved to another day or owner
+	// loses its place.
+	`ALTER TABLE daytask ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+	CREATE TRIGGER IF NOT EXISTS daytask_position_reset AFTER UPDATE OF date, owner ON daytask
+	WHEN NEW.position != 0 AND (NEW.date != OLD.date OR NEW.owner != OLD.owner) BEGIN
+	UPDATE daytask SET position = 0 WHERE id = NEW.id;
+	END;`,
+	// parent_id is set for subtasks, see SaveSubtask. Purging a task leaves
+	// its subtasks at the top level.
+	`ALTER TABLE daytask ADD COLUMN parent_id INTEGER REFERENCES daytask(id) ON DELETE SET NULL;
+	CREATE INDEX IF NOT EXISTS idx_parent ON daytask(parent_id);`,
+}
+
+func migrate(db *sql.DB) error {
+	const op = "storage.sqlite.migrate"
+
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: migration %d: %w", op, i+1, err)
+		}
+
+		// PRAGMA does not accept bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: migration %d: %w", op, i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("%s: migration %d: %w", op, i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Ready reports whether the database is reachable and its schema is up to
+// date.
+func (s *Storage) Ready(ctx context.Context) error {
+	const op = "storage.sqlite.Ready"
+
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version != len(migrations) {
+		return fmt.Errorf("%s: schema version %d, want %d", op, version, len(migrations))
+	}
+
+	return nil
+}
+
+// Instrument makes s record query durations, errors and created tasks in m.
+// It must be called before s is shared between goroutines.
+func (s *Storage) Instrument(m *metrics.Storage) {
+	s.metrics = m
+}
+
+// track starts a span named after op and returns the func that ends it and
+// records the query metrics, use it as
+//
+//	ctx, end := s.track(ctx, op)
+//	defer end(&err)
+func (s *Storage) track(ctx context.Context, op string) (context.Context, func(err *error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemSqlite),
+	)
+
+	return ctx, func(err *error) {
+		s.metrics.ObserveQuery(op, time.Since(start), *err)
+		if *err != nil {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// inTx runs fn in a transaction, committed if fn succeeds, and then
+// publishes the task events fn emitted.
+func (s *Storage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return err
 	}
 
-	return &Storage{db: db}, nil
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		s.publish(tx, false)
+		return err
+	}
+
+	err = tx.Commit()
+	s.publish(tx, err == nil)
+
+	return err
+}
+
+// Stats returns the connection pool statistics.
+func (s *Storage) Stats() sql.DBStats {
+	return s.db.Stats()
 }
 
-func (s *Storage) SaveTask(taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (int64, error){
+// Close releases the prepared statements and closes the database.
+func (s *Storage) Close() error {
+	const op = "storage.sqlite.Close"
+
+	for _, stmt := range []*sql.Stmt{s.saveTask, s.deleteTask, s.getTaskForDay, s.getAllTasks, s.createUser, s.getUser, s.getLoginFailures} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) SaveTask(ctx context.Context, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (_ int64, err error) {
 	const op = "storage.sqlite.SaveTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
 
-	stmt, err := s.db.Prepare("INSERT INTO daytask(title, description, owner, date, status, type) VALUES(?, ?, ?, ?, ?, ?)")
+	var task storage.Task
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		task, err = s.insertTask(ctx, tx, storage.Task{
+			Title: taskName, Description: taskDescription, Owner: taskOwner, Date: taskDate, Status: taskStatus, Type: taskType,
+		})
+		return err
+	})
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	res, err := stmt.Exec(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType)
-	if err != nil { 
-		return 0, fmt.Errorf("%s: %w", op, err)
+	s.metrics.TaskCreated()
+
+	return task.ID, nil
+}
+
+// insertTask saves a new personal task in tx and returns it as stored.
+func (s *Storage) insertTask(ctx context.Context, tx *sql.Tx, t storage.Task) (storage.Task, error) {
+	res, err := tx.StmtContext(ctx, s.saveTask).ExecContext(ctx, t.Title, t.Description, t.Owner, t.Date, t.Status, t.Type, t.ParentID)
+	if err != nil {
+		return storage.Task{}, err
 	}
 
 	id, err := res.LastInsertId()
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return storage.Task{}, err
+	}
+
+	task, err := loadTask(ctx, tx, id)
+	if err != nil {
+		return storage.Task{}, err
+	}
+
+	if err := recordRevision(ctx, tx, task); err != nil {
+		return storage.Task{}, err
+	}
+
+	if err := s.emit(ctx, tx, storage.EventTaskCreated, task, nil); err != nil {
+		return storage.Task{}, err
 	}
 
-	return id, nil 
+	return task, audit(ctx, tx, storage.AuditCreate, auditTask, id, nil, task)
 }
 
-func (s *Storage) DeleteTask(id int64) (error){
+// DeleteTask moves a personal task to the trash, see RestoreTask and
+// PurgeTrash.
+func (s *Storage) DeleteTask(ctx context.Context, id int64) (err error) {
 	const op = "storage.sqlite.DeleteTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadTask(ctx, tx, id)
+		// Deleting a task that does not exist has never been an error here.
+		if errors.Is(err, sql.ErrNoRows) || err == nil && (before.WorkspaceID != nil || before.DeletedAt != nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	stmt, err := s.db.Prepare("DELETE FROM daytask WHERE id = ?")
+		if _, err := tx.StmtContext(ctx, s.deleteTask).ExecContext(ctx, time.Now().UnixMilli(), id); err != nil {
+			return err
+		}
+
+		return s.trashed(ctx, tx, before)
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec(id)
+	return nil
+}
+
+// GetTask returns the task id, or fails with storage.ErrTaskNotFound if it
+// does not exist or is in the trash.
+func (s *Storage) GetTask(ctx context.Context, id int64) (_ storage.Task, err error) {
+	const op = "storage.sqlite.GetTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	task, err := loadTask(ctx, s.db, id)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && task.DeletedAt != nil {
+		return storage.Task{}, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return storage.Task{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil 
+	return task, nil
 }
 
-func (s *Storage) GetTaskForDay(taskOwner string, taskDate string) ([]storage.Task, error){
+func (s *Storage) GetTaskForDay(ctx context.Context, taskOwner string, taskDate string) (_ []storage.Task, err error) {
 	const op = "storage.sqlite.GetTaskForDay"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.getTaskForDay.QueryContext(ctx, taskOwner, taskDate)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Storage) GetAllTasks(ctx context.Context, taskOwner string) (_ []storage.Task, err error) {
+	const op = "storage.sqlite.GetAllTasks"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.getAllTasks.QueryContext(ctx, taskOwner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+// GetTasksInRange returns the tasks username owns or is assigned to dated
+// from from to to, both included, ordered by date.
+func (s *Storage) GetTasksInRange(ctx context.Context, username string, from string, to string) (_ []storage.Task, err error) {
+	const op = "storage.sqlite.GetTasksInRange"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
 
-	stmt, err := s.db.Prepare("SELECT * FROM daytask WHERE owner = ? AND date = ?")
+	rows, err := s.db.QueryContext(ctx, "SELECT "+taskColumns+` FROM daytask
+		WHERE date BETWEEN @from AND @to AND deleted_at IS NULL
+		AND (owner = @user OR EXISTS(SELECT 1 FROM task_assignees WHERE task_id = daytask.id AND username = @user))
+		ORDER BY date, id`,
+		sql.Named("user", username), sql.Named("from", from), sql.Named("to", to))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
-	rows, err := stmt.Query(taskOwner, taskDate)
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+// UpdateTask replaces every field of a personal task and records the changed
+// ones in the activity log.
+func (s *Storage) UpdateTask(ctx context.Context, taskID int64, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (err error) {
+	const op = "storage.sqlite.UpdateTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	_, err = s.changeTask(ctx, taskID, false, func(t *storage.Task) {
+		t.Title, t.Description, t.Owner, t.Date, t.Status, t.Type = taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType
+	})
+	// Updating a task that does not exist has never been an error here.
+	if err != nil && !errors.Is(err, storage.ErrTaskNotFound) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PatchTask changes only the fields of a personal task that patch holds and
+// returns the result, or fails with storage.ErrTaskNotFound.
+func (s *Storage) PatchTask(ctx context.Context, taskID int64, patch storage.TaskPatch) (_ storage.Task, err error) {
+	const op = "storage.sqlite.PatchTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	task, err := s.changeTask(ctx, taskID, false, patch.Apply)
+	if err != nil {
+		return storage.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// CreateUser adds an account with the given password hash and role, or fails
+// with storage.ErrLoginExists.
+func (s *Storage) CreateUser(ctx context.Context, username string, passwordHash string, role string) (_ int64, err error) {
+	const op = "storage.sqlite.CreateUser"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var id int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.StmtContext(ctx, s.createUser).ExecContext(ctx, username, passwordHash, role)
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		user, err := s.loadUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditCreate, auditUser, username, nil, user)
+	})
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrLoginExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// ListUsers returns every account ordered by username.
+func (s *Storage) ListUsers(ctx context.Context) (_ []storage.User, err error) {
+	const op = "storage.sqlite.ListUsers"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY username")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
-	var tasks []storage.Task
 
+	var users []storage.User
 	for rows.Next() {
-		var task storage.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Owner, &task.Date, &task.Status, &task.Type)
+		var user storage.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Disabled); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return users, nil
+}
+
+// SetUserRole changes the role of username, or fails with
+// storage.ErrLoginNotFound.
+func (s *Storage) SetUserRole(ctx context.Context, username string, role string) (err error) {
+	const op = "storage.sqlite.SetUserRole"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	if err := s.updateUser(ctx, username, "UPDATE users SET role = ? WHERE username = ?", role); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetUserDisabled disables or re-enables username, or fails with
+// storage.ErrLoginNotFound.
+func (s *Storage) SetUserDisabled(ctx context.Context, username string, disabled bool) (err error) {
+	const op = "storage.sqlite.SetUserDisabled"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	if err := s.updateUser(ctx, username, "UPDATE users SET disabled = ? WHERE username = ?", disabled); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetUserPassword replaces the password hash of username, or fails with
+// storage.ErrLoginNotFound. The hash itself is kept out of the audit log.
+func (s *Storage) SetUserPassword(ctx context.Context, username string, passwordHash string) (err error) {
+	const op = "storage.sqlite.SetUserPassword"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	if err := s.updateUser(ctx, username, "UPDATE users SET password = ? WHERE username = ?", passwordHash); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// updateUser runs query, which sets some columns of users, with args
+// followed by username.
+func (s *Storage) updateUser(ctx context.Context, username string, query string, args ...any) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := s.loadUser(ctx, tx, username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrLoginNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, append(args, username)...); err != nil {
+			return err
+		}
+
+		after, err := s.loadUser(ctx, tx, username)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditUser, username, before, after)
+	})
+}
+
+func (s *Storage) loadUser(ctx context.Context, tx *sql.Tx, username string) (storage.User, error) {
+	var user storage.User
+	err := tx.StmtContext(ctx, s.getUser).QueryRowContext(ctx, username).
+		Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Disabled)
+
+	return user, err
+}
+
+// UserByName returns the account named username, or storage.ErrLoginNotFound.
+func (s *Storage) UserByName(ctx context.Context, username string) (_ storage.User, err error) {
+	const op = "storage.sqlite.UserByName"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var user storage.User
+	err = s.getUser.QueryRowContext(ctx, username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.Disabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.User{}, fmt.Errorf("%s: %w", op, storage.ErrLoginNotFound)
+	}
+	if err != nil {
+		return storage.User{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return user, nil
+}
+
+// GetLoginFailures returns the failure counters of those keys that have
+// one.
+func (s *Storage) GetLoginFailures(ctx context.Context, keys ...storage.LoginKey) (_ []storage.LoginFailures, err error) {
+	const op = "storage.sqlite.GetLoginFailures"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var list []storage.LoginFailures
+	for _, key := range keys {
+		f := storage.LoginFailures{LoginKey: key}
+		var last, locked int64
 
+		err := s.getLoginFailures.QueryRowContext(ctx, key.Kind, key.Value).Scan(&f.Failures, &last, &locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		tasks = append(tasks, task)
+
+		f.LastFailure = fromMillis(last)
+		f.LockedUntil = fromMillis(locked)
+		list = append(list, f)
+	}
+
+	return list, nil
+}
+
+// RecordLoginFailure counts a failed login for key at now. Failures older
+// than since are forgotten first, and once the count reaches limit the key
+// is locked until lockedUntil.
+func (s *Storage) RecordLoginFailure(ctx context.Context, key storage.LoginKey, now, since time.Time, limit int, lockedUntil time.Time) (_ storage.LoginFailures, err error) {
+	const op = "storage.sqlite.RecordLoginFailure"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	// A single upsert keeps concurrent failures for the same key from
+	// losing counts. In DO UPDATE the bare column names refer to the
+	// existing row.
+	const query = `INSERT INTO login_failures(kind, value, failures, last_failure, locked_until)
+	VALUES(@kind, @value, 1, @now, CASE WHEN @limit <= 1 THEN @until ELSE 0 END)
+	ON CONFLICT(kind, value) DO UPDATE SET
+		failures = CASE WHEN last_failure < @since THEN 1 ELSE failures + 1 END,
+		locked_until = CASE
+			WHEN (CASE WHEN last_failure < @since THEN 1 ELSE failures + 1 END) >= @limit THEN @until
+			ELSE locked_until END,
+		last_failure = @now
+	RETURNING failures, last_failure, locked_until`
+
+	f := storage.LoginFailures{LoginKey: key}
+	var last, locked int64
+
+	err = s.db.QueryRowContext(ctx, query,
+		sql.Named("kind", key.Kind),
+		sql.Named("value", key.Value),
+		sql.Named("now", now.UnixMilli()),
+		sql.Named("since", since.UnixMilli()),
+		sql.Named("limit", limit),
+		sql.Named("until", lockedUntil.UnixMilli()),
+	).Scan(&f.Failures, &last, &locked)
+	if err != nil {
+		return storage.LoginFailures{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.LastFailure = fromMillis(last)
+	f.LockedUntil = fromMillis(locked)
+
+	return f, nil
+}
+
+// ResetLoginFailures clears the failure counters and locks of keys and
+// returns how many existed.
+func (s *Storage) ResetLoginFailures(ctx context.Context, keys ...storage.LoginKey) (_ int64, err error) {
+	const op = "storage.sqlite.ResetLoginFailures"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var n int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, key := range keys {
+			before := storage.LoginFailures{LoginKey: key}
+			var last, locked int64
+
+			err := tx.StmtContext(ctx, s.getLoginFailures).QueryRowContext(ctx, key.Kind, key.Value).
+				Scan(&before.Failures, &last, &locked)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			before.LastFailure = fromMillis(last)
+			before.LockedUntil = fromMillis(locked)
+
+			if _, err := tx.ExecContext(ctx, "DELETE FROM login_failures WHERE kind = ? AND value = ?", key.Kind, key.Value); err != nil {
+				return err
+			}
+			n++
+
+			if err := audit(ctx, tx, storage.AuditDelete, auditLoginFailures, key.Kind+":"+key.Value, before, nil); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// ListLoginFailures returns every failure counter, most recent first.
+func (s *Storage) ListLoginFailures(ctx context.Context) (_ []storage.LoginFailures, err error) {
+	const op = "storage.sqlite.ListLoginFailures"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT kind, value, failures, last_failure, locked_until FROM login_failures ORDER BY last_failure DESC")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var list []storage.LoginFailures
+	for rows.Next() {
+		var (
+			f            storage.LoginFailures
+			last, locked int64
+		)
+		if err := rows.Scan(&f.Kind, &f.Value, &f.Failures, &last, &locked); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		f.LastFailure = fromMillis(last)
+		f.LockedUntil = fromMillis(locked)
+		list = append(list, f)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	
-	return tasks, nil 
+
+	return list, nil
 }
 
-func (s *Storage) GetAllTasks(taskOwner string) ([]storage.Task, error){
-	const op = "storage.sqlite.GetTaskForDay"
+// PurgeLoginFailures deletes the counters whose last failure was before
+// failedBefore and that are not locked past lockedBefore.
+func (s *Storage) PurgeLoginFailures(ctx context.Context, failedBefore, lockedBefore time.Time) (_ int64, err error) {
+	const op = "storage.sqlite.PurgeLoginFailures"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
 
-	stmt, err := s.db.Prepare("SELECT * FROM daytask WHERE owner = ?")
+	res, err := s.db.ExecContext(ctx,
+		"DELETE FROM login_failures WHERE last_failure < ? AND locked_until < ?", failedBefore.UnixMilli(), lockedBefore.UnixMilli())
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return 0, fmt.Errorf("%s: %w", op, err)
 	}
-	defer stmt.Close()
-	rows, err := stmt.Query(taskOwner)
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// fromMillis converts a stored Unix millisecond time, 0 being the zero time.
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMilli(ms)
+}
+
+// CountTasksByStatus returns the number of tasks planned for date, keyed by
+// status, across all owners.
+func (s *Storage) CountTasksByStatus(ctx context.Context, date string) (_ map[string]int, err error) {
+	const op = "storage.sqlite.CountTasksByStatus"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT COALESCE(status, ''), COUNT(*) FROM daytask WHERE date = ? AND deleted_at IS NULL GROUP BY status", date)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	defer rows.Close()
-	var tasks []storage.Task
 
+	counts := make(map[string]int)
 	for rows.Next() {
-		var task storage.Task
-		err := rows.Scan(&task.ID, &task.Title, &task.Description, &task.Owner, &task.Date, &task.Status, &task.Type)
-
-		if err != nil {
+		var (
+			status string
+			n      int
+		)
+		if err := rows.Scan(&status, &n); err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		tasks = append(tasks, task)
+		counts[status] = n
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	
-	return tasks, nil 
+
+	return counts, nil
 }
 
-func (s *Storage) UpdateTask(taskID int64, taskName string, taskDescription string, taskOwner string, taskDate string, taskStatus string, taskType string) (error){
-	const op = "storage.sqlite.UpdateTask"
+// CountTasksByOwner returns the number of tasks of every owner.
+func (s *Storage) CountTasksByOwner(ctx context.Context) (_ map[string]int, err error) {
+	const op = "storage.sqlite.CountTasksByOwner"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
 
-	stmt, err := s.db.Prepare("UPDATE daytask SET title = ?, description = ?, owner = ?, date = ?, status = ?, type = ? WHERE id = ?")
+	rows, err := s.db.QueryContext(ctx, "SELECT owner, COUNT(*) FROM daytask WHERE deleted_at IS NULL GROUP BY owner")
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	defer rows.Close()
 
-	_, err = stmt.Exec(taskName, taskDescription, taskOwner, taskDate, taskStatus, taskType, taskID)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			owner string
+			n     int
+		)
+		if err := rows.Scan(&owner, &n); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		counts[owner] = n
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return nil 
+	return counts, nil
 }
 
-func (s *Storage) CreateUser(username string, password string) (int64, error){
-	const op = "storage.sqlite.CreateUser"
+// scanTasks reads every row selected with taskColumns and closes rows.
+func scanTasks(rows *sql.Rows) ([]storage.Task, error) {
+	defer rows.Close()
+
+	var tasks []storage.Task
+
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// scanTask reads a single row selected with taskColumns.
+func scanTask(row interface{ Scan(dest ...any) error }) (storage.Task, error) {
+	var (
+		task      storage.Task
+		deletedAt sql.NullInt64
+		assignees string
+	)
 
-	stmt, err := s.db.Prepare("INSERT INTO users(username, password) VALUES(?, ?)")
+	err := row.Scan(&task.ID, &task.Title, &task.Description, &task.Owner, &task.Date, &task.Status, &task.Type,
+		&task.WorkspaceID, &task.ParentID, &deletedAt, &task.ServerRev, &assignees)
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return storage.Task{}, err
 	}
 
-	res, err := stmt.Exec(username, password)
-	if err != nil { 
-		return 0, fmt.Errorf("%s: %w", op, err)
+	if deletedAt.Valid {
+		at := time.UnixMilli(deletedAt.Int64)
+		task.DeletedAt = &at
 	}
 
-	id, err := res.LastInsertId()
+	if err := json.Unmarshal([]byte(assignees), &task.Assignees); err != nil {
+		return storage.Task{}, err
+	}
+	if len(task.Assignees) == 0 {
+		task.Assignees = nil
+	}
+
+	return task, nil
+}
+
+// queryRower is a *sql.DB or a *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// loadTask reads the task id, in the trash or not, with its date as it was
+// written.
+func loadTask(ctx context.Context, q queryRower, id int64) (storage.Task, error) {
+	task, err := scanTask(q.QueryRowContext(ctx, "SELECT "+taskColumns+" FROM daytask WHERE id = ?", id))
 	if err != nil {
-		return 0, fmt.Errorf("%s: %w", op, err)
+		return storage.Task{}, err
+	}
+
+	// The driver reads DATE columns back as timestamps.
+	if d, err := time.Parse(time.RFC3339, task.Date); err == nil {
+		task.Date = d.Format(time.DateOnly)
 	}
 
-	return id, nil 
-}
\ No newline at end of file
+	return task, nil
+}
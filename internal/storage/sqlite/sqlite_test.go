@@ -0,0 +1,117 @@
+package sqlite_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage/sqlite"
+)
+
+var benchConfigs = []struct {
+	name string
+	cfg  config.SQLite
+}{
+	{
+		name: "wal-normal",
+		cfg: config.SQLite{
+			JournalMode:  "WAL",
+			BusyTimeout:  5 * time.Second,
+			Synchronous:  "NORMAL",
+			MaxOpenConns: 8,
+			MaxIdleConns: 8,
+		},
+	},
+	{
+		name: "wal-full",
+		cfg: config.SQLite{
+			JournalMode:  "WAL",
+			BusyTimeout:  5 * time.Second,
+			Synchronous:  "FULL",
+			MaxOpenConns: 8,
+			MaxIdleConns: 8,
+		},
+	},
+	{
+		name: "delete-full",
+		cfg: config.SQLite{
+			JournalMode:  "DELETE",
+			BusyTimeout:  5 * time.Second,
+			Synchronous:  "FULL",
+			MaxOpenConns: 8,
+			MaxIdleConns: 8,
+		},
+	},
+}
+
+func newStorage(tb testing.TB, cfg config.SQLite) *sqlite.Storage {
+	tb.Helper()
+
+	s, err := sqlite.New(filepath.Join(tb.TempDir(), "storage.db"), cfg)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func BenchmarkSaveTaskParallel(b *testing.B) {
+	for _, bc := range benchConfigs {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := context.Background()
+			s := newStorage(b, bc.cfg)
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					_, err := s.SaveTask(ctx, "title", "description", "owner", "2024-02-01", "unstarted", "ordinary")
+					if err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkGetTaskForDayParallel(b *testing.B) {
+	const owners, days, perDay = 10, 30, 5
+
+	for _, bc := range benchConfigs {
+		b.Run(bc.name, func(b *testing.B) {
+			ctx := context.Background()
+			s := newStorage(b, bc.cfg)
+
+			for o := 0; o < owners; o++ {
+				for d := 1; d <= days; d++ {
+					for i := 0; i < perDay; i++ {
+						_, err := s.SaveTask(ctx, "title", "description", fmt.Sprintf("owner%d", o), fmt.Sprintf("2024-02-%02d", d), "unstarted", "ordinary")
+						require.NoError(b, err)
+					}
+				}
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					tasks, err := s.GetTaskForDay(ctx, fmt.Sprintf("owner%d", i%owners), fmt.Sprintf("2024-02-%02d", i%days+1))
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					if len(tasks) != perDay {
+						b.Errorf("got %d tasks, want %d", len(tasks), perDay)
+						return
+					}
+					i++
+				}
+			})
+		})
+	}
+}
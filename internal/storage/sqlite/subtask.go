@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+)
+
+// SaveSubtask saves a task under parentID, which must be a personal task not
+// in the trash, for the owner of the parent.
+func (s *Storage) SaveSubtask(ctx context.Context, parentID int64, taskName string, taskDescription string, taskDate string, taskStatus string, taskType string) (_ int64, err error) {
+	const op = "storage.sqlite.SaveSubtask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var task storage.Task
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		parent, err := loadTask(ctx, tx, parentID)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && (parent.WorkspaceID != nil || parent.DeletedAt != nil) {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		task, err = s.insertTask(ctx, tx, storage.Task{
+			Title: taskName, Description: taskDescription, Owner: parent.Owner, Date: taskDate, Status: taskStatus, Type: taskType,
+			ParentID: &parent.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.metrics.TaskCreated()
+
+	return task.ID, nil
+}
+
+// GetSubtasks returns the subtasks not in the trash of the tasks, oldest
+// first, keyed by parent. Subtasks given to another owner than that of their
+// parent are left out, they are no longer part of it.
+func (s *Storage) GetSubtasks(ctx context.Context, parentIDs ...int64) (_ map[int64][]storage.Task, err error) {
+	const op = "storage.sqlite.GetSubtasks"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	subtasks := make(map[int64][]storage.Task)
+	if len(parentIDs) == 0 {
+		return subtasks, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+taskColumns+" FROM daytask WHERE parent_id IN ("+placeholders(len(parentIDs))+
+		`) AND deleted_at IS NULL AND owner = (SELECT p.owner FROM daytask p WHERE p.id = daytask.parent_id) ORDER BY id`,
+		int64Args(parentIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, t := range tasks {
+		subtasks[*t.ParentID] = append(subtasks[*t.ParentID], t)
+	}
+
+	return subtasks, nil
+}
@@ -0,0 +1,53 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+)
+
+func TestSubtasks(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	parent, err := s.SaveTask(ctx, "parent", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	other, err := s.SaveTask(ctx, "other", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, title := range []string{"a", "b", "c"} {
+		id, err := s.SaveSubtask(ctx, parent, title, "", "2024-01-02", "", "")
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	sub, err := s.GetTask(ctx, ids[0])
+	require.NoError(t, err)
+	require.Equal(t, "alice", sub.Owner)
+	require.Equal(t, &parent, sub.ParentID)
+	require.Equal(t, "2024-01-02", sub.Date)
+
+	_, err = s.SaveSubtask(ctx, ids[2]+1, "missing", "", "2024-01-02", "", "")
+	require.ErrorIs(t, err, storage.ErrTaskNotFound)
+
+	// Trashed subtasks and those given to someone else are left out.
+	require.NoError(t, s.DeleteTask(ctx, ids[1]))
+	_, err = s.PatchTask(ctx, ids[2], storage.TaskPatch{Owner: ptr("bob")})
+	require.NoError(t, err)
+
+	subtasks, err := s.GetSubtasks(ctx, parent, other)
+	require.NoError(t, err)
+	require.Len(t, subtasks[parent], 1)
+	require.Equal(t, "a", subtasks[parent][0].Title)
+	require.Empty(t, subtasks[other])
+
+	// A trashed parent takes no new subtasks.
+	require.NoError(t, s.DeleteTask(ctx, parent))
+	_, err = s.SaveSubtask(ctx, parent, "late", "", "2024-01-02", "", "")
+	require.ErrorIs(t, err, storage.ErrTaskNotFound)
+}
@@ -0,0 +1,205 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// visibleTo limits a query on daytask t to the tasks @user owns or is
+// assigned to.
+const visibleTo = `(t.owner = @user OR EXISTS(SELECT 1 FROM task_assignees WHERE task_id = t.id AND username = @user))`
+
+// SyncChanges returns up to limit changes after revision since to the tasks
+// username owns or is assigned to, tombstones included.
+func (s *Storage) SyncChanges(ctx context.Context, username string, since int64, limit int) (_ storage.SyncDelta, err error) {
+	const op = "storage.sqlite.SyncChanges"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var delta storage.SyncDelta
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		// A task that left the view of the user and came back is reported
+		// as it is now rather than by its tombstone.
+		rows, err := tx.QueryContext(ctx, `SELECT t.server_rev, t.id, t.deleted_at IS NOT NULL FROM daytask t
+			WHERE t.server_rev > @since AND `+visibleTo+`
+			UNION ALL
+			SELECT MAX(d.server_rev), d.task_id, 1 FROM sync_tombstones d
+			WHERE d.username = @user AND d.server_rev > @since
+			AND NOT EXISTS(SELECT 1 FROM daytask t WHERE t.id = d.task_id AND `+visibleTo+`)
+			GROUP BY d.task_id
+			ORDER BY 1 LIMIT @limit`,
+			sql.Named("user", username), sql.Named("since", since), sql.Named("limit", limit+1))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c storage.SyncChange
+			if err := rows.Scan(&c.ServerRev, &c.ID, &c.Deleted); err != nil {
+				return err
+			}
+			delta.Changes = append(delta.Changes, c)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		if len(delta.Changes) > limit {
+			delta.Changes, delta.More = delta.Changes[:limit], true
+			delta.Rev = delta.Changes[limit-1].ServerRev
+		} else if err := tx.QueryRowContext(ctx, "SELECT rev FROM sync_counter").Scan(&delta.Rev); err != nil {
+			return err
+		}
+
+		for i, c := range delta.Changes {
+			if c.Deleted {
+				continue
+			}
+
+			task, err := loadTask(ctx, tx, c.ID)
+			if err != nil {
+				return err
+			}
+			delta.Changes[i].Task = &task
+		}
+
+		return nil
+	})
+	if err != nil {
+		return storage.SyncDelta{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if delta.Changes == nil {
+		delta.Changes = []storage.SyncChange{}
+	}
+
+	return delta, nil
+}
+
+// SyncCreateTask saves a personal task created offline by username. A task
+// already created with the same clientID is returned instead, with created
+// unset, so clients may retry. It fails with storage.ErrTaskNotFound if
+// that task was purged since.
+func (s *Storage) SyncCreateTask(ctx context.Context, username string, clientID string, task storage.Task) (_ storage.Task, created bool, err error) {
+	const op = "storage.sqlite.SyncCreateTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		var id int64
+		err := tx.QueryRowContext(ctx, "SELECT task_id FROM sync_clients WHERE username = ? AND client_id = ?",
+			username, clientID).Scan(&id)
+		if err == nil {
+			task, err = loadTask(ctx, tx, id)
+			if errors.Is(err, sql.ErrNoRows) {
+				return storage.ErrTaskNotFound
+			}
+			return err
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		task, err = s.insertTask(ctx, tx, task)
+		if err != nil {
+			return err
+		}
+		created = true
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO sync_clients(username, client_id, task_id) VALUES(?, ?, ?)",
+			username, clientID, task.ID)
+		return err
+	})
+	if err != nil {
+		return storage.Task{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if created {
+		s.metrics.TaskCreated()
+	}
+
+	return task, created, nil
+}
+
+// SyncUpdateTask changes a personal task with change, which is called in
+// the transaction with the task as it is so it can detect conflicts with
+// what the client saw. A trashed task is returned as it is, without calling
+// change. It fails with storage.ErrTaskNotFound for workspace and missing
+// tasks.
+func (s *Storage) SyncUpdateTask(ctx context.Context, id int64, change func(t *storage.Task)) (_ storage.Task, err error) {
+	const op = "storage.sqlite.SyncUpdateTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	task, err := s.changeTask(ctx, id, false, change)
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		trashed, err := scanTask(s.db.QueryRowContext(ctx, "SELECT "+taskColumns+
+			" FROM daytask WHERE id = ? AND workspace_id IS NULL AND deleted_at IS NOT NULL", id))
+		if err == nil {
+			return trashed, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return storage.Task{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		return storage.Task{}, fmt.Errorf("%s: %w", op, storage.ErrTaskNotFound)
+	}
+	if err != nil {
+		return storage.Task{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, nil
+}
+
+// SyncDeleteTask moves a personal task to the trash if ok, called in the
+// transaction with the task as it is, allows it. It returns the task and
+// whether it is in the trash, which it may already have been. It fails with
+// storage.ErrTaskNotFound for workspace and missing tasks.
+func (s *Storage) SyncDeleteTask(ctx context.Context, id int64, ok func(t storage.Task) bool) (_ storage.Task, deleted bool, err error) {
+	const op = "storage.sqlite.SyncDeleteTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var task storage.Task
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadTask(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && before.WorkspaceID != nil {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		task = before
+		if before.DeletedAt != nil {
+			deleted = true
+			return nil
+		}
+		if !ok(before) {
+			return nil
+		}
+
+		if _, err := tx.StmtContext(ctx, s.deleteTask).ExecContext(ctx, time.Now().UnixMilli(), id); err != nil {
+			return err
+		}
+		deleted = true
+
+		if err := s.trashed(ctx, tx, before); err != nil {
+			return err
+		}
+
+		task, err = loadTask(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return storage.Task{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return task, deleted, nil
+}
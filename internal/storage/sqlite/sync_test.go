@@ -0,0 +1,126 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+)
+
+func TestSyncChanges(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	a, err := s.SaveTask(ctx, "a", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	b, err := s.SaveTask(ctx, "b", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	_, err = s.SaveTask(ctx, "other", "", "bob", "2024-01-01", "", "")
+	require.NoError(t, err)
+
+	delta, err := s.SyncChanges(ctx, "alice", 0, 10)
+	require.NoError(t, err)
+	require.False(t, delta.More)
+	require.Len(t, delta.Changes, 2)
+	require.Equal(t, a, delta.Changes[0].ID)
+	require.Equal(t, "a", delta.Changes[0].Task.Title)
+	require.Equal(t, delta.Changes[0].ServerRev, delta.Changes[0].Task.ServerRev)
+	since := delta.Rev
+
+	delta, err = s.SyncChanges(ctx, "alice", since, 10)
+	require.NoError(t, err)
+	require.Empty(t, delta.Changes)
+	require.Equal(t, since, delta.Rev)
+
+	// An update, a deletion and a task given away.
+	task, err := s.PatchTask(ctx, a, storage.TaskPatch{Title: ptr("a2")})
+	require.NoError(t, err)
+	require.Greater(t, task.ServerRev, since)
+	require.NoError(t, s.DeleteTask(ctx, b))
+	c, err := s.SaveTask(ctx, "c", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	_, err = s.PatchTask(ctx, c, storage.TaskPatch{Owner: ptr("bob")})
+	require.NoError(t, err)
+
+	// Paged.
+	delta, err = s.SyncChanges(ctx, "alice", since, 2)
+	require.NoError(t, err)
+	require.True(t, delta.More)
+	require.Len(t, delta.Changes, 2)
+	require.Equal(t, "a2", delta.Changes[0].Task.Title)
+	require.Equal(t, b, delta.Changes[1].ID)
+	require.True(t, delta.Changes[1].Deleted)
+	require.Nil(t, delta.Changes[1].Task)
+
+	delta, err = s.SyncChanges(ctx, "alice", delta.Rev, 2)
+	require.NoError(t, err)
+	require.False(t, delta.More)
+	require.Len(t, delta.Changes, 1)
+	require.Equal(t, c, delta.Changes[0].ID)
+	require.True(t, delta.Changes[0].Deleted)
+	since = delta.Rev
+
+	// Purging leaves a tombstone.
+	_, err = s.PurgeTrash(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+
+	delta, err = s.SyncChanges(ctx, "alice", since, 10)
+	require.NoError(t, err)
+	require.Len(t, delta.Changes, 1)
+	require.Equal(t, b, delta.Changes[0].ID)
+	require.True(t, delta.Changes[0].Deleted)
+
+	// Getting the task back replaces its tombstone.
+	_, err = s.PatchTask(ctx, c, storage.TaskPatch{Owner: ptr("alice")})
+	require.NoError(t, err)
+
+	delta, err = s.SyncChanges(ctx, "alice", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, delta.Changes, 3)
+	require.Equal(t, c, delta.Changes[2].ID)
+	require.False(t, delta.Changes[2].Deleted)
+}
+
+func TestSyncMutations(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	task := storage.Task{Title: "t", Owner: "alice", Date: "2024-01-01", Status: "unstarted", Type: "ordinary"}
+
+	created, ok, err := s.SyncCreateTask(ctx, "alice", "phone-1", task)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Retried.
+	again, ok, err := s.SyncCreateTask(ctx, "alice", "phone-1", task)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, created.ID, again.ID)
+
+	_, ok, err = s.SyncDeleteTask(ctx, created.ID, func(storage.Task) bool { return false })
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	deleted, ok, err := s.SyncDeleteTask(ctx, created.ID, func(storage.Task) bool { return true })
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NotNil(t, deleted.DeletedAt)
+
+	// Trashed tasks are returned as they are.
+	called := false
+	got, err := s.SyncUpdateTask(ctx, created.ID, func(*storage.Task) { called = true })
+	require.NoError(t, err)
+	require.False(t, called)
+	require.NotNil(t, got.DeletedAt)
+
+	_, err = s.SyncUpdateTask(ctx, 42, func(*storage.Task) {})
+	require.ErrorIs(t, err, storage.ErrTaskNotFound)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// trashed audits moving the task before to the trash, which the caller has
+// just done in tx, and queues its deletion event.
+func (s *Storage) trashed(ctx context.Context, tx *sql.Tx, before storage.Task) error {
+	after, err := loadTask(ctx, tx, before.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.emit(ctx, tx, storage.EventTaskDeleted, after, nil); err != nil {
+		return err
+	}
+
+	return audit(ctx, tx, storage.AuditDelete, auditTask, before.ID, before, after)
+}
+
+// inTrashOf limits a query on daytask to the trashed tasks username may
+// restore: its own and those of the workspaces it owns.
+const inTrashOf = `deleted_at IS NOT NULL AND (owner = @user OR workspace_id IN (
+	SELECT workspace_id FROM workspace_members WHERE username = @user AND role = '` + storage.WorkspaceRoleOwner + `'))`
+
+// ListTrash returns the trashed tasks username may restore, most recently
+// deleted first.
+func (s *Storage) ListTrash(ctx context.Context, username string) (_ []storage.Task, err error) {
+	const op = "storage.sqlite.ListTrash"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+taskColumns+" FROM daytask WHERE "+inTrashOf+" ORDER BY deleted_at DESC",
+		sql.Named("user", username))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
+
+// RestoreTask takes a task out of the trash of username, or fails with
+// storage.ErrTaskNotFound if it is not there.
+func (s *Storage) RestoreTask(ctx context.Context, id int64, username string) (err error) {
+	const op = "storage.sqlite.RestoreTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadTask(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, "UPDATE daytask SET deleted_at = NULL WHERE id = @id AND "+inTrashOf,
+			sql.Named("id", id), sql.Named("user", username))
+		if err != nil {
+			return err
+		}
+
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return storage.ErrTaskNotFound
+		}
+
+		after, err := loadTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.emit(ctx, tx, storage.EventTaskUpdated, after, nil); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditTask, id, before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeTrash deletes for good the tasks moved to the trash before
+// deletedBefore, along with their comments, activity and assignees, and
+// returns how many there were.
+func (s *Storage) PurgeTrash(ctx context.Context, deletedBefore time.Time) (_ int64, err error) {
+	const op = "storage.sqlite.PurgeTrash"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var n int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT "+taskColumns+" FROM daytask WHERE deleted_at < ?", deletedBefore.UnixMilli())
+		if err != nil {
+			return err
+		}
+
+		tasks, err := scanTasks(rows)
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM daytask WHERE id = ?", task.ID); err != nil {
+				return err
+			}
+
+			if err := audit(ctx, tx, storage.AuditDelete, auditTask, task.ID, task, nil); err != nil {
+				return err
+			}
+		}
+		n = int64(len(tasks))
+
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,56 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"daytask/internal/config"
+	"daytask/internal/storage"
+)
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t, config.SQLite{MaxOpenConns: 1})
+
+	id, err := s.SaveTask(ctx, "t", "", "alice", "2024-01-01", "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteTask(ctx, id))
+
+	tasks, err := s.GetTaskForDay(ctx, "alice", "2024-01-01")
+	require.NoError(t, err)
+	require.Empty(t, tasks)
+
+	trash, err := s.ListTrash(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.NotNil(t, trash[0].DeletedAt)
+
+	// Only the owner may restore it.
+	require.ErrorIs(t, s.RestoreTask(ctx, id, "bob"), storage.ErrTaskNotFound)
+	require.NoError(t, s.RestoreTask(ctx, id, "alice"))
+	require.ErrorIs(t, s.RestoreTask(ctx, id, "alice"), storage.ErrTaskNotFound)
+
+	tasks, err = s.GetTaskForDay(ctx, "alice", "2024-01-01")
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.Nil(t, tasks[0].DeletedAt)
+
+	require.NoError(t, s.DeleteTask(ctx, id))
+
+	n, err := s.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	n, err = s.PurgeTrash(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.EqualValues(t, 1, n)
+
+	_, err = s.GetTask(ctx, id)
+	require.ErrorIs(t, err, storage.ErrTaskNotFound)
+	trash, err = s.ListTrash(ctx, "alice")
+	require.NoError(t, err)
+	require.Empty(t, trash)
+}
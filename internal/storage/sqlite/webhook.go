@@ -0,0 +1,237 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CreateWebhook registers url to receive events of the tasks owned by owner.
+func (s *Storage) CreateWebhook(ctx context.Context, owner string, url string, secret string, events []string) (_ int64, err error) {
+	const op = "storage.sqlite.CreateWebhook"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var id int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		now := time.Now()
+		res, err := tx.ExecContext(ctx, "INSERT INTO webhooks(owner, url, secret, events, created_at) VALUES(?, ?, ?, ?, ?)",
+			owner, url, secret, strings.Join(events, ","), now.UnixMilli())
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		after := storage.Webhook{ID: id, Owner: owner, URL: url, Events: events, CreatedAt: time.UnixMilli(now.UnixMilli())}
+		return audit(ctx, tx, storage.AuditCreate, auditWebhook, id, nil, after)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+const webhookColumns = "id, owner, url, secret, events, created_at"
+
+// ListWebhooks returns the webhooks of owner.
+func (s *Storage) ListWebhooks(ctx context.Context, owner string) (_ []storage.Webhook, err error) {
+	const op = "storage.sqlite.ListWebhooks"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE owner = ? ORDER BY id", owner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var list []storage.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// DeleteWebhook deletes a webhook of owner along with its deliveries, or
+// fails with storage.ErrWebhookNotFound.
+func (s *Storage) DeleteWebhook(ctx context.Context, id int64, owner string) (err error) {
+	const op = "storage.sqlite.DeleteWebhook"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadWebhook(ctx, tx, id, owner)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditDelete, auditWebhook, id, before, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns, newest first, up to limit deliveries of a webhook
+// of owner older than beforeID, unless it is zero. status filters them
+// unless it is empty. It fails with storage.ErrWebhookNotFound.
+func (s *Storage) ListDeliveries(ctx context.Context, webhookID int64, owner string, status string, beforeID int64, limit int) (_ []storage.Delivery, err error) {
+	const op = "storage.sqlite.ListDeliveries"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var list []storage.Delivery
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := loadWebhook(ctx, tx, webhookID, owner); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.webhook_id = @webhook AND (@status = '' OR d.status = @status) AND (@before = 0 OR d.id < @before)
+			ORDER BY d.id DESC LIMIT @limit`,
+			sql.Named("webhook", webhookID), sql.Named("status", status), sql.Named("before", beforeID), sql.Named("limit", limit))
+		if err != nil {
+			return err
+		}
+
+		list, err = scanDeliveries(rows)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// DueDeliveries returns up to limit pending deliveries whose next attempt
+// is due at now, oldest first, with the URL and secret of their webhook.
+func (s *Storage) DueDeliveries(ctx context.Context, now time.Time, limit int) (_ []storage.Delivery, err error) {
+	const op = "storage.sqlite.DueDeliveries"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt <= ? ORDER BY d.id LIMIT ?`,
+		storage.DeliveryPending, now.UnixMilli(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	list, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt to send a delivery:
+// its new status, the response code and error, if any, and when to try again
+// if it is still pending.
+func (s *Storage) RecordDeliveryAttempt(ctx context.Context, id int64, status string, code int, errMsg string, at time.Time, next time.Time) (err error) {
+	const op = "storage.sqlite.RecordDeliveryAttempt"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var delivered int64
+	if status == storage.DeliveryDelivered {
+		delivered = at.UnixMilli()
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE webhook_deliveries SET status = ?, attempts = attempts + 1, response_code = ?,
+		last_error = ?, next_attempt = ?, delivered_at = ? WHERE id = ?`,
+		status, code, errMsg, next.UnixMilli(), delivered, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func loadWebhook(ctx context.Context, tx *sql.Tx, id int64, owner string) (storage.Webhook, error) {
+	w, err := scanWebhook(tx.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ? AND owner = ?", id, owner))
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Webhook{}, storage.ErrWebhookNotFound
+	}
+
+	return w, err
+}
+
+func scanWebhook(row interface{ Scan(dest ...any) error }) (storage.Webhook, error) {
+	var (
+		w       storage.Webhook
+		events  string
+		created int64
+	)
+
+	if err := row.Scan(&w.ID, &w.Owner, &w.URL, &w.Secret, &events, &created); err != nil {
+		return storage.Webhook{}, err
+	}
+	w.Events = strings.Split(events, ",")
+	w.CreatedAt = time.UnixMilli(created)
+
+	return w, nil
+}
+
+const deliveryColumns = "d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt, d.last_error, " +
+	"d.response_code, d.created_at, d.delivered_at, w.url, w.secret"
+
+// scanDeliveries reads every row selected with deliveryColumns and closes
+// rows.
+func scanDeliveries(rows *sql.Rows) ([]storage.Delivery, error) {
+	defer rows.Close()
+
+	var list []storage.Delivery
+	for rows.Next() {
+		var (
+			d                        storage.Delivery
+			payload                  string
+			next, created, delivered int64
+		)
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &next, &d.LastError,
+			&d.ResponseCode, &created, &delivered, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		d.Payload = json.RawMessage(payload)
+		if d.Status == storage.DeliveryPending {
+			d.NextAttempt = fromMillis(next)
+		}
+		d.CreatedAt = time.UnixMilli(created)
+		d.DeliveredAt = fromMillis(delivered)
+		list = append(list, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
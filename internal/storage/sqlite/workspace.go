@@ -0,0 +1,420 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"daytask/internal/storage"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateWorkspace adds a workspace with owner as its first member.
+func (s *Storage) CreateWorkspace(ctx context.Context, name string, owner string) (_ int64, err error) {
+	const op = "storage.sqlite.CreateWorkspace"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var id int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "INSERT INTO workspaces(name, owner) VALUES(?, ?)", name, owner)
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO workspace_members(workspace_id, username, role) VALUES(?, ?, ?)",
+			id, owner, storage.WorkspaceRoleOwner)
+		if err != nil {
+			return err
+		}
+
+		ws := storage.Workspace{ID: id, Name: name, Owner: owner}
+		if err := audit(ctx, tx, storage.AuditCreate, auditWorkspace, id, nil, ws); err != nil {
+			return err
+		}
+
+		member := storage.WorkspaceMember{Username: owner, Role: storage.WorkspaceRoleOwner}
+		return audit(ctx, tx, storage.AuditCreate, auditWorkspaceMember, memberID(id, owner), nil, member)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetWorkspace returns the workspace id as seen by username, or fails with
+// storage.ErrNotMember if username is not one of its members.
+func (s *Storage) GetWorkspace(ctx context.Context, id int64, username string) (_ storage.Workspace, err error) {
+	const op = "storage.sqlite.GetWorkspace"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var ws storage.Workspace
+	err = s.db.QueryRowContext(ctx, `SELECT w.id, w.name, w.owner, m.role FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE w.id = ? AND m.username = ?`, id, username,
+	).Scan(&ws.ID, &ws.Name, &ws.Owner, &ws.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.Workspace{}, fmt.Errorf("%s: %w", op, storage.ErrNotMember)
+	}
+	if err != nil {
+		return storage.Workspace{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ws, nil
+}
+
+// ListWorkspaces returns the workspaces username is a member of.
+func (s *Storage) ListWorkspaces(ctx context.Context, username string) (_ []storage.Workspace, err error) {
+	const op = "storage.sqlite.ListWorkspaces"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, `SELECT w.id, w.name, w.owner, m.role FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE m.username = ? ORDER BY w.name`, username)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var list []storage.Workspace
+	for rows.Next() {
+		var ws storage.Workspace
+		if err := rows.Scan(&ws.ID, &ws.Name, &ws.Owner, &ws.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		list = append(list, ws)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return list, nil
+}
+
+// ListWorkspaceMembers returns the members of a workspace.
+func (s *Storage) ListWorkspaceMembers(ctx context.Context, workspaceID int64) (_ []storage.WorkspaceMember, err error) {
+	const op = "storage.sqlite.ListWorkspaceMembers"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT username, role FROM workspace_members WHERE workspace_id = ? ORDER BY username", workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var members []storage.WorkspaceMember
+	for rows.Next() {
+		var m storage.WorkspaceMember
+		if err := rows.Scan(&m.Username, &m.Role); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return members, nil
+}
+
+// SetWorkspaceMember adds username to a workspace or changes its role.
+func (s *Storage) SetWorkspaceMember(ctx context.Context, workspaceID int64, username string, role string) (err error) {
+	const op = "storage.sqlite.SetWorkspaceMember"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadMember(ctx, tx, workspaceID, username)
+		if err != nil && !errors.Is(err, storage.ErrNotMember) {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `INSERT INTO workspace_members(workspace_id, username, role) VALUES(?, ?, ?)
+			ON CONFLICT(workspace_id, username) DO UPDATE SET role = excluded.role`, workspaceID, username, role)
+		if err != nil {
+			return err
+		}
+
+		after := storage.WorkspaceMember{Username: username, Role: role}
+		if before == nil {
+			return audit(ctx, tx, storage.AuditCreate, auditWorkspaceMember, memberID(workspaceID, username), nil, after)
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditWorkspaceMember, memberID(workspaceID, username), before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RemoveWorkspaceMember removes username from a workspace and unassigns it
+// from the workspace tasks, or fails with storage.ErrNotMember.
+func (s *Storage) RemoveWorkspaceMember(ctx context.Context, workspaceID int64, username string) (err error) {
+	const op = "storage.sqlite.RemoveWorkspaceMember"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadMember(ctx, tx, workspaceID, username)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, "DELETE FROM workspace_members WHERE workspace_id = ? AND username = ?",
+			workspaceID, username)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM task_assignees WHERE username = ?
+			AND task_id IN (SELECT id FROM daytask WHERE workspace_id = ?)`, username, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditDelete, auditWorkspaceMember, memberID(workspaceID, username), before, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SaveWorkspaceTask adds a task to a workspace, assigned to assignees. It
+// fails with storage.ErrNotMember if an assignee is not a member.
+func (s *Storage) SaveWorkspaceTask(ctx context.Context, workspaceID int64, task storage.Task, assignees []string) (_ int64, err error) {
+	const op = "storage.sqlite.SaveWorkspaceTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	var id int64
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO daytask(title, description, owner, date, status, type, workspace_id) VALUES(?, ?, ?, ?, ?, ?, ?)",
+			task.Title, task.Description, task.Owner, task.Date, task.Status, task.Type, workspaceID)
+		if err != nil {
+			return err
+		}
+
+		id, err = res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := assign(ctx, tx, id, workspaceID, assignees); err != nil {
+			return err
+		}
+
+		after, err := loadTask(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := recordRevision(ctx, tx, after); err != nil {
+			return err
+		}
+
+		if err := s.emit(ctx, tx, storage.EventTaskCreated, after, nil); err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditCreate, auditTask, id, nil, after)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.metrics.TaskCreated()
+
+	return id, nil
+}
+
+// SetTaskAssignees replaces the assignees of a workspace task. It fails with
+// storage.ErrNotMember if an assignee is not a member of the workspace.
+func (s *Storage) SetTaskAssignees(ctx context.Context, taskID int64, workspaceID int64, assignees []string) (err error) {
+	const op = "storage.sqlite.SetTaskAssignees"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadTask(ctx, tx, taskID)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && before.DeletedAt != nil {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM task_assignees WHERE task_id = ?", taskID); err != nil {
+			return err
+		}
+
+		if err := assign(ctx, tx, taskID, workspaceID, assignees); err != nil {
+			return err
+		}
+
+		after, err := loadTask(ctx, tx, taskID)
+		if err != nil {
+			return err
+		}
+
+		return audit(ctx, tx, storage.AuditUpdate, auditTask, taskID, before, after)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// assign adds assignees to a task, each of them must be a member of the
+// workspace.
+func assign(ctx context.Context, tx *sql.Tx, taskID int64, workspaceID int64, assignees []string) error {
+	for _, username := range assignees {
+		res, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO task_assignees(task_id, username)
+			SELECT ?, username FROM workspace_members WHERE workspace_id = ? AND username = ?`,
+			taskID, workspaceID, username)
+		if err != nil {
+			return err
+		}
+
+		// Zero rows for a member means a duplicate in assignees.
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			var ok bool
+			err := tx.QueryRowContext(ctx,
+				"SELECT EXISTS(SELECT 1 FROM workspace_members WHERE workspace_id = ? AND username = ?)",
+				workspaceID, username).Scan(&ok)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%w: %s", storage.ErrNotMember, username)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UpdateWorkspaceTask stores the title, description, date, status and type of
+// a workspace task and records the changed fields in the activity log.
+func (s *Storage) UpdateWorkspaceTask(ctx context.Context, task storage.Task) (err error) {
+	const op = "storage.sqlite.UpdateWorkspaceTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	_, err = s.changeTask(ctx, task.ID, true, func(t *storage.Task) {
+		t.Title, t.Description, t.Date, t.Status, t.Type = task.Title, task.Description, task.Date, task.Status, task.Type
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateTaskStatus changes only the status of a workspace task.
+func (s *Storage) UpdateTaskStatus(ctx context.Context, id int64, status string) (err error) {
+	const op = "storage.sqlite.UpdateTaskStatus"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	_, err = s.changeTask(ctx, id, true, func(t *storage.Task) {
+		t.Status = status
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteWorkspaceTask moves a workspace task to the trash, see RestoreTask
+// and PurgeTrash.
+func (s *Storage) DeleteWorkspaceTask(ctx context.Context, id int64) (err error) {
+	const op = "storage.sqlite.DeleteWorkspaceTask"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := loadTask(ctx, tx, id)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && (before.WorkspaceID == nil || before.DeletedAt != nil) {
+			return storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE daytask SET deleted_at = ? WHERE id = ?", time.Now().UnixMilli(), id); err != nil {
+			return err
+		}
+
+		return s.trashed(ctx, tx, before)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// loadMember reads the membership of username in a workspace in tx, or fails
+// with storage.ErrNotMember.
+func loadMember(ctx context.Context, tx *sql.Tx, workspaceID int64, username string) (*storage.WorkspaceMember, error) {
+	m := storage.WorkspaceMember{Username: username}
+	err := tx.QueryRowContext(ctx, "SELECT role FROM workspace_members WHERE workspace_id = ? AND username = ?",
+		workspaceID, username).Scan(&m.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrNotMember
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// memberID identifies a workspace membership in the audit log.
+func memberID(workspaceID int64, username string) string {
+	return fmt.Sprintf("%d:%s", workspaceID, username)
+}
+
+// GetWorkspaceDay returns the tasks of a workspace planned for date. When
+// assignee is not empty only the tasks assigned to it are returned.
+func (s *Storage) GetWorkspaceDay(ctx context.Context, workspaceID int64, date string, assignee string) (_ []storage.Task, err error) {
+	const op = "storage.sqlite.GetWorkspaceDay"
+	ctx, end := s.track(ctx, op)
+	defer end(&err)
+
+	rows, err := s.db.QueryContext(ctx, "SELECT "+taskColumns+` FROM daytask
+		WHERE workspace_id = ? AND date = ? AND deleted_at IS NULL AND (? = '' OR EXISTS(
+			SELECT 1 FROM task_assignees WHERE task_id = daytask.id AND username = ?))
+		ORDER BY id`, workspaceID, date, assignee, assignee)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	tasks, err := scanTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tasks, nil
+}
@@ -1,12 +1,25 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 var (
 	ErrLoginNotFound = errors.New("login not found")
 	ErrLoginExists   = errors.New("login exists")
 	ErrWrongPassword   = errors.New("wrong password")
 	ErrIncorrectDate   = errors.New("incorrect date")
+	ErrLoginLocked     = errors.New("login locked")
+	ErrUserDisabled    = errors.New("user disabled")
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrNotMember       = errors.New("not a workspace member")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrOrderMismatch    = errors.New("order does not list the tasks of the day")
 )
 
 type Task struct {
@@ -17,4 +30,330 @@ type Task struct {
 	Date        string 	 `json:"date"`
 	Status 		string 	 `json:"status,omitempty"`	
 	Type 		string 	 `json:"type,omitempty"`
-}
\ No newline at end of file
+	// WorkspaceID is set for tasks shared in a workspace, which may be
+	// assigned to any of its members.
+	WorkspaceID *int64   `json:"workspace_id,omitempty"`
+	Assignees   []string `json:"assignees,omitempty"`
+	// ParentID is set for subtasks, see SaveSubtask.
+	ParentID *int64 `json:"parent_id,omitempty"`
+	// DeletedAt is set for tasks in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ServerRev increases with every change to any task, see SyncDelta.
+	ServerRev int64 `json:"server_rev,omitempty"`
+	// Comments and Activity are only filled in when asked for.
+	Comments []Comment  `json:"comments,omitempty"`
+	Activity []Activity `json:"activity,omitempty"`
+}
+// Roles of a user. Admins manage users, members read and write tasks and
+// read-only users only read them.
+const (
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleReadOnly = "read-only"
+)
+
+// User is an account stored in the users table.
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// PasswordHash is the bcrypt hash of the password.
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+	Disabled     bool   `json:"disabled"`
+}
+
+// Failed logins are counted separately for every username and every remote
+// IP, so a single address guessing many usernames is caught as well.
+const (
+	LoginKeyUser = "user"
+	LoginKeyIP   = "ip"
+)
+
+// LoginKey identifies a failed login counter.
+type LoginKey struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// LoginFailures is the state of a failed login counter.
+type LoginFailures struct {
+	LoginKey
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Roles of a workspace member. Owners manage the members and every task of
+// the workspace.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleMember = "member"
+)
+
+type Workspace struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+	// Role is the role of the user the workspace was listed for.
+	Role string `json:"role,omitempty"`
+}
+
+type WorkspaceMember struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Author    string    `json:"author"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Activity records the change of a single task field.
+type Activity struct {
+	ID     int64     `json:"id"`
+	TaskID int64     `json:"task_id"`
+	Actor  string    `json:"actor"`
+	Field  string    `json:"field"`
+	From   string    `json:"from"`
+	To     string    `json:"to"`
+	At     time.Time `json:"at"`
+}
+
+// TaskPatch holds the editable fields of a task to change, nil fields are
+// left as they are.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	Owner       *string
+	Date        *string
+	Status      *string
+	Type        *string
+}
+
+// Apply sets the fields of t that p holds.
+func (p TaskPatch) Apply(t *Task) {
+	for _, f := range []struct {
+		dst *string
+		src *string
+	}{
+		{&t.Title, p.Title},
+		{&t.Description, p.Description},
+		{&t.Owner, p.Owner},
+		{&t.Date, p.Date},
+		{&t.Status, p.Status},
+		{&t.Type, p.Type},
+	} {
+		if f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+}
+
+// Change is the old and new value of a task field.
+type Change struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Diff lists the editable fields that differ between a and b.
+func Diff(a, b Task) []Change {
+	fields := []Change{
+		{"title", a.Title, b.Title},
+		{"description", a.Description, b.Description},
+		{"owner", a.Owner, b.Owner},
+		{"date", a.Date, b.Date},
+		{"status", a.Status, b.Status},
+		{"type", a.Type, b.Type},
+	}
+
+	var changes []Change
+	for _, f := range fields {
+		if f.From != f.To {
+			changes = append(changes, f)
+		}
+	}
+
+	return changes
+}
+
+// Merge combines the editable fields of a task changed to client from base
+// by an offline client while the server changed it to server. Fields only
+// the client changed take its value, fields both sides changed to different
+// values keep the server's and are returned as conflicts.
+func Merge(base, server, client Task) (Task, []string) {
+	merged := server
+	var conflicts []string
+
+	for _, f := range []struct {
+		name                 string
+		dst                  *string
+		base, server, client string
+	}{
+		{"title", &merged.Title, base.Title, server.Title, client.Title},
+		{"description", &merged.Description, base.Description, server.Description, client.Description},
+		{"owner", &merged.Owner, base.Owner, server.Owner, client.Owner},
+		{"date", &merged.Date, base.Date, server.Date, client.Date},
+		{"status", &merged.Status, base.Status, server.Status, client.Status},
+		{"type", &merged.Type, base.Type, server.Type, client.Type},
+	} {
+		switch {
+		case f.client == f.base || f.client == f.server:
+		case f.server == f.base:
+			*f.dst = f.client
+		default:
+			conflicts = append(conflicts, f.name)
+		}
+	}
+
+	return merged, conflicts
+}
+
+// SyncChange is a task changed after the revision a client synced to, or a
+// tombstone when it was deleted or the user no longer owns or is assigned
+// to it.
+type SyncChange struct {
+	ID        int64 `json:"id"`
+	ServerRev int64 `json:"server_rev"`
+	Deleted   bool  `json:"deleted,omitempty"`
+	Task      *Task `json:"task,omitempty"`
+}
+
+// SyncDelta is a page of the changes after a revision in ServerRev order.
+// Rev is the revision to ask for the next changes from, which there may
+// already be when More is set.
+type SyncDelta struct {
+	Rev     int64        `json:"rev"`
+	Changes []SyncChange `json:"changes"`
+	More    bool         `json:"more,omitempty"`
+}
+
+// Revision is a version of the editable fields of a task, numbered from 1 in
+// the order they were saved.
+type Revision struct {
+	Rev   int       `json:"rev"`
+	Actor string    `json:"actor"`
+	At    time.Time `json:"at"`
+	Task  Task      `json:"task"`
+}
+
+type actorKey struct{}
+
+// WithActor returns a copy of ctx naming the user on whose behalf storage
+// changes are made, see Actor.
+func WithActor(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, actorKey{}, user)
+}
+
+// Actor returns the user set by WithActor, or "" if there is none.
+func Actor(ctx context.Context) string {
+	user, _ := ctx.Value(actorKey{}).(string)
+	return user
+}
+
+type remoteAddrKey struct{}
+
+// WithRemoteAddr returns a copy of ctx carrying the address of the client on
+// whose behalf storage changes are made, see RemoteAddr.
+func WithRemoteAddr(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, remoteAddrKey{}, addr)
+}
+
+// RemoteAddr returns the address set by WithRemoteAddr, or "" if there is
+// none.
+func RemoteAddr(ctx context.Context) string {
+	addr, _ := ctx.Value(remoteAddrKey{}).(string)
+	return addr
+}
+
+// Audit actions.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditEntry records a single change: who made it, from where, in which
+// request and the changed entity before and after it. Before is null for
+// creations and After for deletions that removed the entity for good, moving
+// a task to the trash keeps it with deleted_at set.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	At         time.Time       `json:"at"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"request_id"`
+	RemoteAddr string          `json:"remote_addr"`
+	Action     string          `json:"action"`
+	Entity     string          `json:"entity"`
+	EntityID   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before" swaggertype:"object"`
+	After      json.RawMessage `json:"after" swaggertype:"object"`
+}
+
+// AuditFilter selects audit entries, zero fields match everything.
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID string
+	Since    time.Time
+	Until    time.Time
+	// AfterID skips entries up to and including this ID.
+	AfterID int64
+	Limit   int
+}
+
+// StatusDone is the status of a completed task.
+const StatusDone = "done"
+
+// Task events webhooks subscribe to. A task whose status changes to
+// StatusDone emits both EventTaskUpdated and EventTaskCompleted.
+const (
+	EventTaskCreated   = "task.created"
+	EventTaskUpdated   = "task.updated"
+	EventTaskCompleted = "task.completed"
+	EventTaskDeleted   = "task.deleted"
+)
+
+// Webhook receives the events of the tasks owned by Owner. Deliveries are
+// signed with Secret, which is only shown once, when the webhook is created.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	Owner     string    `json:"owner"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery is an event queued for a webhook, along with the outcome of the
+// attempts to send it so far.
+type Delivery struct {
+	ID          int64           `json:"id"`
+	WebhookID   int64           `json:"webhook_id"`
+	Event       string          `json:"event"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt,omitempty"`
+	// LastError and ResponseCode describe the last attempt.
+	LastError    string    `json:"last_error,omitempty"`
+	ResponseCode int       `json:"response_code,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	DeliveredAt  time.Time `json:"delivered_at,omitempty"`
+
+	// URL and Secret of the webhook, filled in for sending.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}